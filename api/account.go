@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/pkg/errors"
@@ -58,25 +59,56 @@ import (
 type Account struct {
 	PrimaryKey        int64
 	Name              string
+	AccountType       int
 	Balance           money.Money
 	IsBucketOptional  bool
 	IncludeInCashFlow bool
-	CurrencyCode      string
-	AccountGroup      int64
+	IncludeInNetWorth bool
+	IsDebt            bool
+	IsHidden          bool
+	CreditLimit       money.Money
+	// InterestRate is the annual interest rate as a percentage, e.g. 19.99.
+	InterestRate float64
+	CurrencyCode string
+	AccountGroup int64
+	// AccountNumber and RoutingNumber identify the account at its bank, if known.
+	AccountNumber string
+	RoutingNumber string
 }
 
+const (
+	AccountTypeChequing     = 0
+	AccountTypeSavings      = 1
+	AccountTypeCreditCard   = 2
+	AccountTypeLineOfCredit = 3
+	AccountTypeCash         = 5
+)
+
 // GetAccounts fetches the set of accounts in a MoneyWell document, sorted by the display order
 // as MoneyWell itself would render.
-func GetAccounts(database *sql.DB) ([]Account, error) {
-	rows, err := database.Query(`
+func GetAccounts(database Querier) ([]Account, error) {
+	return GetAccountsContext(context.Background(), database)
+}
+
+// GetAccountsContext is like GetAccounts, but runs within the given context.
+func GetAccountsContext(ctx context.Context, database Querier) ([]Account, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 za.Z_PK, 
                 za.ZNAME,
+                za.ZTYPE,
                 CAST(ROUND(za.ZBALANCE * 100) AS INTEGER),
                 za.ZISBUCKETOPTIONAL,
                 za.ZINCLUDEINCASHFLOW,
+                za.ZINCLUDEINNETWORTH,
+                za.ZISDEBT,
+                za.ZISHIDDEN,
+                CAST(ROUND(za.ZCREDITLIMIT * 100) AS INTEGER),
+                za.ZINTERESTRATE,
                 za.ZCURRENCYCODE,
-                za.ZACCOUNTGROUP
+                za.ZACCOUNTGROUP,
+                za.ZACCOUNTNUMBER,
+                za.ZROUTINGNUMBER
             FROM 
                 ZACCOUNT za
             LEFT JOIN
@@ -94,43 +126,73 @@ func GetAccounts(database *sql.DB) ([]Account, error) {
 
 	var primaryKey int64
 	var name string
-	var balanceRaw, accountGroup sql.NullInt64
+	var accountType, balanceRaw, creditLimitRaw, accountGroup sql.NullInt64
 	var isBucketOptional, includeInCashFlow int
-	var currencyCode sql.NullString
+	var includeInNetWorth, isDebt, isHidden sql.NullInt64
+	var interestRate sql.NullFloat64
+	var currencyCode, accountNumber, routingNumber sql.NullString
 	for rows.Next() {
 		err := rows.Scan(
 			&primaryKey,
 			&name,
+			&accountType,
 			&balanceRaw,
 			&isBucketOptional,
 			&includeInCashFlow,
+			&includeInNetWorth,
+			&isDebt,
+			&isHidden,
+			&creditLimitRaw,
+			&interestRate,
 			&currencyCode,
 			&accountGroup,
+			&accountNumber,
+			&routingNumber,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan account")
 		}
 
 		accounts = append(accounts, Account{
-			PrimaryKey: primaryKey,
-			Name:       name,
+			PrimaryKey:  primaryKey,
+			Name:        name,
+			AccountType: int(accountType.Int64),
 			Balance: money.Money{
 				Currency: currencyCode.String,
 				Amount:   balanceRaw.Int64,
 			},
 			IsBucketOptional:  isBucketOptional > 0,
 			IncludeInCashFlow: includeInCashFlow > 0,
-			CurrencyCode:      currencyCode.String,
-			AccountGroup:      accountGroup.Int64,
+			IncludeInNetWorth: includeInNetWorth.Int64 > 0,
+			IsDebt:            isDebt.Int64 > 0,
+			IsHidden:          isHidden.Int64 > 0,
+			CreditLimit: money.Money{
+				Currency: currencyCode.String,
+				Amount:   creditLimitRaw.Int64,
+			},
+			InterestRate:  interestRate.Float64,
+			CurrencyCode:  currencyCode.String,
+			AccountGroup:  accountGroup.Int64,
+			AccountNumber: accountNumber.String,
+			RoutingNumber: routingNumber.String,
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read accounts")
+	}
+
 	return accounts, nil
 }
 
 // GetAccountsMap gets a map from the account primary key to the account.
-func GetAccountsMap(database *sql.DB) (map[int64]Account, error) {
-	accounts, err := GetAccounts(database)
+func GetAccountsMap(database Querier) (map[int64]Account, error) {
+	return GetAccountsMapContext(context.Background(), database)
+}
+
+// GetAccountsMapContext is like GetAccountsMap, but runs within the given context.
+func GetAccountsMapContext(ctx context.Context, database Querier) (map[int64]Account, error) {
+	accounts, err := GetAccountsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -143,6 +205,18 @@ func GetAccountsMap(database *sql.DB) (map[int64]Account, error) {
 	return accountsMap, nil
 }
 
+// IsLiability determines if the account is a liability rather than an asset. MoneyWell doesn't
+// necessarily flag credit cards and lines of credit as debts, so these are always considered
+// liabilities.
+func (a Account) IsLiability() bool {
+	switch a.AccountType {
+	case AccountTypeCreditCard, AccountTypeLineOfCredit:
+		return true
+	}
+
+	return a.IsDebt
+}
+
 // GetAccountBalance uses the given transactions to compute the balance of an account at the given
 // time.
 func GetAccountBalance(account Account, transactions []Transaction) money.Money {
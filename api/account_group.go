@@ -1,7 +1,7 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/pkg/errors"
 )
@@ -27,8 +27,13 @@ type AccountGroup struct {
 
 // GetAccountGroups fetches the set of accounts in a MoneyWell document, sorted by the display
 // order as MoneyWell itself would render.
-func GetAccountGroups(database *sql.DB) ([]AccountGroup, error) {
-	rows, err := database.Query(`
+func GetAccountGroups(database Querier) ([]AccountGroup, error) {
+	return GetAccountGroupsContext(context.Background(), database)
+}
+
+// GetAccountGroupsContext is like GetAccountGroups, but runs within the given context.
+func GetAccountGroupsContext(ctx context.Context, database Querier) ([]AccountGroup, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 zag.Z_PK, 
                 zag.ZNAME
@@ -59,11 +64,20 @@ func GetAccountGroups(database *sql.DB) ([]AccountGroup, error) {
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read account groups")
+	}
+
 	return accountGroups, nil
 }
 
-func GetAccountGroupsMap(database *sql.DB) (map[int64]AccountGroup, error) {
-	accountGroups, err := GetAccountGroups(database)
+func GetAccountGroupsMap(database Querier) (map[int64]AccountGroup, error) {
+	return GetAccountGroupsMapContext(context.Background(), database)
+}
+
+// GetAccountGroupsMapContext is like GetAccountGroupsMap, but runs within the given context.
+func GetAccountGroupsMapContext(ctx context.Context, database Querier) (map[int64]AccountGroup, error) {
+	accountGroups, err := GetAccountGroupsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
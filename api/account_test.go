@@ -23,56 +23,104 @@ func TestGetAccounts(t *testing.T) {
 		{
 			PrimaryKey:        3,
 			Name:              "Cash",
+			AccountType:       api.AccountTypeCash,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      0,
+			AccountNumber:     "",
+			RoutingNumber:     "",
 		},
 		{
 			PrimaryKey:        4,
 			Name:              "Cash (USD)",
+			AccountType:       api.AccountTypeCash,
 			Balance:           money.Money{Currency: "USD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "USD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "USD",
 			AccountGroup:      0,
+			AccountNumber:     "",
+			RoutingNumber:     "",
 		},
 		{
 			PrimaryKey:        1,
 			Name:              "Chequing Account",
+			AccountType:       api.AccountTypeChequing,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      2,
+			AccountNumber:     "5678",
+			RoutingNumber:     "1245",
 		},
 		{
 			PrimaryKey:        2,
 			Name:              "Savings",
+			AccountType:       api.AccountTypeSavings,
 			Balance:           money.Money{Currency: "CAD", Amount: 100 * 100},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: false,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      2,
+			AccountNumber:     "6789",
+			RoutingNumber:     "2345",
 		},
 		{
 			PrimaryKey:        5,
 			Name:              "Line of Credit",
+			AccountType:       api.AccountTypeLineOfCredit,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      1,
+			AccountNumber:     "",
+			RoutingNumber:     "",
 		},
 		{
 			PrimaryKey:        6,
 			Name:              "Visa",
+			AccountType:       api.AccountTypeCreditCard,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      1,
+			AccountNumber:     "0987654321",
+			RoutingNumber:     "1234567890",
 		},
 	}
 
@@ -93,62 +141,160 @@ func TestGetAccountsMap(t *testing.T) {
 		3: {
 			PrimaryKey:        3,
 			Name:              "Cash",
+			AccountType:       api.AccountTypeCash,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      0,
+			AccountNumber:     "",
+			RoutingNumber:     "",
 		},
 		4: {
 			PrimaryKey:        4,
 			Name:              "Cash (USD)",
+			AccountType:       api.AccountTypeCash,
 			Balance:           money.Money{Currency: "USD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "USD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "USD",
 			AccountGroup:      0,
+			AccountNumber:     "",
+			RoutingNumber:     "",
 		},
 		1: {
 			PrimaryKey:        1,
 			Name:              "Chequing Account",
+			AccountType:       api.AccountTypeChequing,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      2,
+			AccountNumber:     "5678",
+			RoutingNumber:     "1245",
 		},
 		2: {
 			PrimaryKey:        2,
 			Name:              "Savings",
+			AccountType:       api.AccountTypeSavings,
 			Balance:           money.Money{Currency: "CAD", Amount: 100 * 100},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: false,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      2,
+			AccountNumber:     "6789",
+			RoutingNumber:     "2345",
 		},
 		5: {
 			PrimaryKey:        5,
 			Name:              "Line of Credit",
+			AccountType:       api.AccountTypeLineOfCredit,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      1,
+			AccountNumber:     "",
+			RoutingNumber:     "",
 		},
 		6: {
 			PrimaryKey:        6,
 			Name:              "Visa",
+			AccountType:       api.AccountTypeCreditCard,
 			Balance:           money.Money{Currency: "CAD", Amount: 0},
 			IsBucketOptional:  false,
 			IncludeInCashFlow: true,
+			IncludeInNetWorth: true,
+			IsDebt:            false,
+			IsHidden:          false,
+			CreditLimit:       money.Money{Currency: "CAD", Amount: 0},
+			InterestRate:      0,
 			CurrencyCode:      "CAD",
 			AccountGroup:      1,
+			AccountNumber:     "0987654321",
+			RoutingNumber:     "1234567890",
 		},
 	}
 
 	assert.Equal(t, expectedAccounts, accounts)
 }
 
+func TestAccountIsLiability(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	accounts, err := api.GetAccountsMap(database)
+	assert.NoError(t, err)
+
+	assert.False(t, accounts[1].IsLiability())
+	assert.False(t, accounts[3].IsLiability())
+
+	// MoneyWell flags neither the line of credit nor the credit card as a debt.
+	assert.True(t, accounts[5].IsLiability())
+	assert.True(t, accounts[6].IsLiability())
+
+	assert.True(t, api.Account{AccountType: api.AccountTypeChequing, IsDebt: true}.IsLiability())
+}
+
+func TestGetAccountsNetWorthDetails(t *testing.T) {
+	t.Parallel()
+
+	// The test document leaves these at their defaults, so set them on a copy as MoneyWell would
+	// when editing the accounts.
+	database := copyTestStore(t)
+	defer database.Close()
+
+	_, err := database.Exec(`
+            UPDATE ZACCOUNT SET Z_OPT = Z_OPT + 1, ZINCLUDEINNETWORTH = 0, ZISHIDDEN = 1
+            WHERE Z_PK = 4
+        `)
+	assert.NoError(t, err)
+	_, err = database.Exec(`
+            UPDATE ZACCOUNT SET Z_OPT = Z_OPT + 1, ZISDEBT = 1, ZCREDITLIMIT = 10000,
+                ZINTERESTRATE = 6.5
+            WHERE Z_PK = 5
+        `)
+	assert.NoError(t, err)
+
+	accounts, err := api.GetAccountsMap(database)
+	assert.NoError(t, err)
+
+	assert.False(t, accounts[4].IncludeInNetWorth)
+	assert.True(t, accounts[4].IsHidden)
+	assert.True(t, accounts[5].IsDebt)
+	assert.Equal(t, money.Money{Currency: "CAD", Amount: 10000 * 100}, accounts[5].CreditLimit)
+	assert.Equal(t, 6.5, accounts[5].InterestRate)
+}
+
 func TestGetAccountBalance(t *testing.T) {
 	t.Parallel()
 
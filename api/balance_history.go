@@ -0,0 +1,156 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+const (
+	// BalanceIntervalDaily samples the balance at the end of every day.
+	BalanceIntervalDaily = 1
+	// BalanceIntervalMonthly samples the balance at the end of every month.
+	BalanceIntervalMonthly = 2
+)
+
+// BalancePoint represents a running balance at the end of a given date.
+type BalancePoint struct {
+	Date    time.Time
+	Balance money.Money
+}
+
+// GetAccountBalanceHistory uses the given transactions to compute the running balance of an
+// account at the end of each day or month between from and to inclusive. Monthly balances are
+// sampled at the end of each month, except for the last which is sampled at to.
+//
+// As with GetAccountBalance, voided and pending transactions are ignored, as are split children.
+func GetAccountBalanceHistory(
+	account Account,
+	transactions []Transaction,
+	from time.Time,
+	to time.Time,
+	interval int,
+) ([]BalancePoint, error) {
+	dates, err := getBalanceDates(from, to, interval)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	accountTransactions := []Event{}
+	for _, transaction := range transactions {
+		transaction := transaction
+
+		switch transaction.Status {
+		case TransactionStatusVoided:
+			fallthrough
+		case TransactionStatusPending:
+			continue
+		}
+
+		if transaction.Account == account.PrimaryKey && transaction.SplitParent == 0 {
+			accountTransactions = append(accountTransactions, &transaction)
+		}
+	}
+
+	return getBalanceHistory(
+		money.Money{Currency: account.CurrencyCode},
+		accountTransactions,
+		dates,
+	), nil
+}
+
+// GetBucketBalanceHistory uses the given events to compute the running balance of a bucket at
+// the end of each day or month between from and to inclusive. Monthly balances are sampled at
+// the end of each month, except for the last which is sampled at to.
+//
+// As with GetBucketBalance, the balance starts from the bucket's starting balance and ignores
+// events before the cash flow start date.
+func GetBucketBalanceHistory(
+	bucket Bucket,
+	events []Event,
+	settings Settings,
+	from time.Time,
+	to time.Time,
+	interval int,
+) ([]BalancePoint, error) {
+	dates, err := getBalanceDates(from, to, interval)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	bucketEvents := []Event{}
+	for _, event := range events {
+		if event.GetDate().Before(settings.CashFlowStartDate) {
+			continue
+		}
+		if event.GetBucket() != bucket.PrimaryKey {
+			continue
+		}
+
+		bucketEvents = append(bucketEvents, event)
+	}
+
+	return getBalanceHistory(bucket.StartingBalance, bucketEvents, dates), nil
+}
+
+// getBalanceDates returns the dates at the end of which to sample a balance.
+func getBalanceDates(from time.Time, to time.Time, interval int) ([]time.Time, error) {
+	from = truncateDate(from)
+	to = truncateDate(to)
+	if to.Before(from) {
+		return nil, errors.Errorf(
+			"%s is before %s",
+			to.Format("2006-01-02"),
+			from.Format("2006-01-02"),
+		)
+	}
+
+	dates := []time.Time{}
+	switch interval {
+	case BalanceIntervalDaily:
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			dates = append(dates, date)
+		}
+
+	case BalanceIntervalMonthly:
+		month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for ; !month.After(to); month = month.AddDate(0, 1, 0) {
+			monthEnd := month.AddDate(0, 1, -1)
+			if monthEnd.After(to) {
+				monthEnd = to
+			}
+			dates = append(dates, monthEnd)
+		}
+
+	default:
+		return nil, errors.Errorf("unsupported balance interval %d", interval)
+	}
+
+	return dates, nil
+}
+
+// getBalanceHistory accumulates the given events onto the starting balance, sampling the running
+// balance at the end of each of the given sorted dates.
+func getBalanceHistory(balance money.Money, events []Event, dates []time.Time) []BalancePoint {
+	sortedEvents := make([]Event, len(events))
+	copy(sortedEvents, events)
+	sort.Stable(ByEventDate(sortedEvents))
+
+	history := make([]BalancePoint, 0, len(dates))
+	next := 0
+	for _, date := range dates {
+		for ; next < len(sortedEvents) && !sortedEvents[next].GetDate().After(date); next++ {
+			balance = balance.Add(sortedEvents[next].GetAmount())
+		}
+
+		history = append(history, BalancePoint{
+			Date:    date,
+			Balance: balance,
+		})
+	}
+
+	return history
+}
@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+func TestGetAccountBalanceHistory(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	accounts, err := api.GetAccountsMap(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount * 100}
+	}
+
+	t.Run("daily", func(t *testing.T) {
+		history, err := api.GetAccountBalanceHistory(
+			accounts[1],
+			transactions,
+			date(2017, 11, 4),
+			date(2017, 11, 6),
+			api.BalanceIntervalDaily,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []api.BalancePoint{
+			{Date: date(2017, 11, 4), Balance: cad(1000)},
+			{Date: date(2017, 11, 5), Balance: cad(650)},
+			{Date: date(2017, 11, 6), Balance: cad(650)},
+		}, history)
+	})
+
+	t.Run("monthly", func(t *testing.T) {
+		// Excludes the split children, and the voided and pending transactions.
+		history, err := api.GetAccountBalanceHistory(
+			accounts[1],
+			transactions,
+			date(2017, 10, 15),
+			date(2017, 12, 15),
+			api.BalanceIntervalMonthly,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []api.BalancePoint{
+			{Date: date(2017, 10, 31), Balance: cad(0)},
+			{Date: date(2017, 11, 30), Balance: cad(-350)},
+			{Date: date(2017, 12, 15), Balance: cad(-350)},
+		}, history)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := api.GetAccountBalanceHistory(
+			accounts[1],
+			transactions,
+			date(2017, 11, 6),
+			date(2017, 11, 4),
+			api.BalanceIntervalDaily,
+		)
+		assert.Error(t, err)
+
+		_, err = api.GetAccountBalanceHistory(
+			accounts[1],
+			transactions,
+			date(2017, 11, 4),
+			date(2017, 11, 6),
+			0,
+		)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetBucketBalanceHistory(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	settings, err := api.GetSettings(database)
+	assert.NoError(t, err)
+
+	buckets, err := api.GetBucketsMap(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	assert.NoError(t, err)
+
+	bucket := buckets[13]
+	events, err := api.GetBucketEvents(bucket, transactions, bucketTransfers)
+	assert.NoError(t, err)
+
+	date := func(day int) time.Time {
+		return time.Date(2017, 11, day, 0, 0, 0, 0, time.UTC)
+	}
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount * 100}
+	}
+
+	history, err := api.GetBucketBalanceHistory(
+		bucket,
+		events,
+		settings,
+		date(11),
+		date(19),
+		api.BalanceIntervalDaily,
+	)
+	assert.NoError(t, err)
+
+	balances := []money.Money{}
+	for _, point := range history {
+		balances = append(balances, point.Balance)
+	}
+	assert.Equal(t, []money.Money{
+		cad(-350), cad(-450), cad(-450), cad(-450), cad(-450),
+		cad(-450), cad(-450), cad(-450), cad(-200),
+	}, balances)
+
+	history, err = api.GetBucketBalanceHistory(
+		bucket,
+		events,
+		settings,
+		date(1),
+		date(30),
+		api.BalanceIntervalMonthly,
+	)
+	assert.NoError(t, err)
+
+	balance, err := api.GetBucketBalance(bucket, events, settings)
+	assert.NoError(t, err)
+	assert.Equal(t, []api.BalancePoint{{Date: date(30), Balance: balance}}, history)
+}
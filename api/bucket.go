@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"sort"
 
@@ -42,10 +43,20 @@ type Bucket struct {
 	CurrencyCode    string
 }
 
+const (
+	BucketTypeIncome  = 1
+	BucketTypeExpense = 2
+)
+
 // GetBuckets fetches the set of buckets in a MoneyWell document, sorted by the display order
 // as MoneyWell itself would render.
-func GetBuckets(database *sql.DB) ([]Bucket, error) {
-	rows, err := database.Query(`
+func GetBuckets(database Querier) ([]Bucket, error) {
+	return GetBucketsContext(context.Background(), database)
+}
+
+// GetBucketsContext is like GetBuckets, but runs within the given context.
+func GetBucketsContext(ctx context.Context, database Querier) ([]Bucket, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 zb.Z_PK, 
                 zb.ZTYPE,
@@ -102,12 +113,21 @@ func GetBuckets(database *sql.DB) ([]Bucket, error) {
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read buckets")
+	}
+
 	return buckets, nil
 }
 
 // GetBucketsMap gets a map from the bucket primary key to the bucket.
-func GetBucketsMap(database *sql.DB) (map[int64]Bucket, error) {
-	buckets, err := GetBuckets(database)
+func GetBucketsMap(database Querier) (map[int64]Bucket, error) {
+	return GetBucketsMapContext(context.Background(), database)
+}
+
+// GetBucketsMapContext is like GetBucketsMap, but runs within the given context.
+func GetBucketsMapContext(ctx context.Context, database Querier) (map[int64]Bucket, error) {
+	buckets, err := GetBucketsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/pkg/errors"
 )
@@ -33,8 +33,13 @@ const (
 )
 
 // GetBucketGroups fetches the set of bucket groups in a MoneyWell document.
-func GetBucketGroups(database *sql.DB) ([]BucketGroup, error) {
-	rows, err := database.Query(`
+func GetBucketGroups(database Querier) ([]BucketGroup, error) {
+	return GetBucketGroupsContext(context.Background(), database)
+}
+
+// GetBucketGroupsContext is like GetBucketGroups, but runs within the given context.
+func GetBucketGroupsContext(ctx context.Context, database Querier) ([]BucketGroup, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 zbg.Z_PK, 
                 zbg.ZTYPE,
@@ -69,11 +74,20 @@ func GetBucketGroups(database *sql.DB) ([]BucketGroup, error) {
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read bucket groups")
+	}
+
 	return bucketGroups, nil
 }
 
-func GetBucketGroupsMap(database *sql.DB) (map[int64]BucketGroup, error) {
-	bucketGroups, err := GetBucketGroups(database)
+func GetBucketGroupsMap(database Querier) (map[int64]BucketGroup, error) {
+	return GetBucketGroupsMapContext(context.Background(), database)
+}
+
+// GetBucketGroupsMapContext is like GetBucketGroupsMap, but runs within the given context.
+func GetBucketGroupsMapContext(ctx context.Context, database Querier) (map[int64]BucketGroup, error) {
+	bucketGroups, err := GetBucketGroupsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
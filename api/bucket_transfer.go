@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -31,12 +32,14 @@ import (
 //     ZUNIQUEID VARCHAR
 //  );
 type BucketTransfer struct {
-	PrimaryKey   int
-	Date         time.Time
-	TransferType int
-	Amount       money.Money
-	Bucket       int64
-	TargetBucket int64
+	PrimaryKey    int
+	Date          time.Time
+	TransferType  int
+	Amount        money.Money
+	Bucket        int64
+	TargetBucket  int64
+	IsUserCreated bool
+	Event         int64
 }
 
 // GetDate implements the Event interface to return the bucket transfer date.
@@ -55,8 +58,13 @@ func (bt *BucketTransfer) GetBucket() int64 {
 }
 
 // GetBucketTransfers fetches the set of bucket transfers in a MoneyWell document.
-func GetBucketTransfers(database *sql.DB) ([]BucketTransfer, error) {
-	rows, err := database.Query(`
+func GetBucketTransfers(database Querier) ([]BucketTransfer, error) {
+	return GetBucketTransfersContext(context.Background(), database)
+}
+
+// GetBucketTransfersContext is like GetBucketTransfers, but runs within the given context.
+func GetBucketTransfersContext(ctx context.Context, database Querier) ([]BucketTransfer, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 zbt.Z_PK, 
                 zbt.ZDATEYMD,
@@ -64,7 +72,9 @@ func GetBucketTransfers(database *sql.DB) ([]BucketTransfer, error) {
                 CAST(ROUND(zbt.ZAMOUNT * 100) AS INTEGER),
                 zbt.ZBUCKET,
                 zbt2.ZBUCKET,
-                zb.ZCURRENCYCODE
+                zb.ZCURRENCYCODE,
+                zbt.ZISUSERCREATED,
+                zbt.ZEVENT
             FROM 
                 ZBUCKETTRANSFER zbt
             JOIN
@@ -85,6 +95,8 @@ func GetBucketTransfers(database *sql.DB) ([]BucketTransfer, error) {
 	var primaryKey, dateymd, transferType int
 	var amountRaw, bucket, targetBucket int64
 	var currencyCode string
+	var isUserCreated bool
+	var event sql.NullInt64
 	for rows.Next() {
 		err := rows.Scan(
 			&primaryKey,
@@ -94,6 +106,8 @@ func GetBucketTransfers(database *sql.DB) ([]BucketTransfer, error) {
 			&bucket,
 			&targetBucket,
 			&currencyCode,
+			&isUserCreated,
+			&event,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan account")
@@ -112,10 +126,16 @@ func GetBucketTransfers(database *sql.DB) ([]BucketTransfer, error) {
 				Currency: currencyCode,
 				Amount:   amountRaw,
 			},
-			Bucket:       bucket,
-			TargetBucket: targetBucket,
+			Bucket:        bucket,
+			TargetBucket:  targetBucket,
+			IsUserCreated: isUserCreated,
+			Event:         event.Int64,
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read bucket transfers")
+	}
+
 	return bucketTransfers, nil
 }
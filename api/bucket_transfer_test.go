@@ -22,52 +22,58 @@ func TestGetBucketTransfers(t *testing.T) {
 
 	expectedBucketTransfers := []api.BucketTransfer{
 		{
-			PrimaryKey:   2,
-			Date:         time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
-			TransferType: 0,
-			Amount:       money.Money{Currency: "CAD", Amount: 100 * 100},
-			Bucket:       27,
-			TargetBucket: 2,
+			PrimaryKey:    2,
+			Date:          time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
+			TransferType:  0,
+			Amount:        money.Money{Currency: "CAD", Amount: 100 * 100},
+			Bucket:        27,
+			TargetBucket:  2,
+			IsUserCreated: true,
 		},
 		{
-			PrimaryKey:   5,
-			Date:         time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
-			TransferType: 0,
-			Amount:       money.Money{Currency: "CAD", Amount: 250 * 100},
-			Bucket:       13,
-			TargetBucket: 3,
+			PrimaryKey:    5,
+			Date:          time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
+			TransferType:  0,
+			Amount:        money.Money{Currency: "CAD", Amount: 250 * 100},
+			Bucket:        13,
+			TargetBucket:  3,
+			IsUserCreated: true,
 		},
 		{
-			PrimaryKey:   3,
-			Date:         time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
-			TransferType: 1,
-			Amount:       money.Money{Currency: "CAD", Amount: -100 * 100},
-			Bucket:       2,
-			TargetBucket: 27,
+			PrimaryKey:    3,
+			Date:          time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
+			TransferType:  1,
+			Amount:        money.Money{Currency: "CAD", Amount: -100 * 100},
+			Bucket:        2,
+			TargetBucket:  27,
+			IsUserCreated: true,
 		},
 		{
-			PrimaryKey:   4,
-			Date:         time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
-			TransferType: 1,
-			Amount:       money.Money{Currency: "CAD", Amount: -250 * 100},
-			Bucket:       3,
-			TargetBucket: 13,
+			PrimaryKey:    4,
+			Date:          time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
+			TransferType:  1,
+			Amount:        money.Money{Currency: "CAD", Amount: -250 * 100},
+			Bucket:        3,
+			TargetBucket:  13,
+			IsUserCreated: true,
 		},
 		{
-			PrimaryKey:   1,
-			Date:         time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
-			TransferType: 0,
-			Amount:       money.Money{Currency: "CAD", Amount: 650 * 100},
-			Bucket:       2,
-			TargetBucket: 3,
+			PrimaryKey:    1,
+			Date:          time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
+			TransferType:  0,
+			Amount:        money.Money{Currency: "CAD", Amount: 650 * 100},
+			Bucket:        2,
+			TargetBucket:  3,
+			IsUserCreated: true,
 		},
 		{
-			PrimaryKey:   6,
-			Date:         time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
-			TransferType: 1,
-			Amount:       money.Money{Currency: "CAD", Amount: -650 * 100},
-			Bucket:       3,
-			TargetBucket: 2,
+			PrimaryKey:    6,
+			Date:          time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC),
+			TransferType:  1,
+			Amount:        money.Money{Currency: "CAD", Amount: -650 * 100},
+			Bucket:        3,
+			TargetBucket:  2,
+			IsUserCreated: true,
 		},
 	}
 
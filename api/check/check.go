@@ -0,0 +1,252 @@
+package check
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+// Input is the transaction being checked, along with the context needed to diagnose it.
+type Input struct {
+	// Account is the account against which the transaction was recorded.
+	Account     api.Account
+	Transaction api.Transaction
+	// Tags are the primary keys of the tags assigned to the transaction, if known.
+	Tags []int64
+	// Document is the document being checked, for looking up settings, accounts, split children
+	// and the like.
+	Document *api.Document
+}
+
+// ProblematicTransaction represents a transaction diagnosed with a potential problem.
+type ProblematicTransaction struct {
+	Transaction int64
+	Problem     int
+	Description string
+	// Related are the primary keys of any other transactions involved in the problem, such as
+	// the earlier transaction probably duplicated by this one.
+	Related []int64
+}
+
+// Check diagnoses transactions with one or more kinds of problems.
+//
+// Built-in checks report problem codes below ProblemCustom. Custom checks should report problem
+// codes of at least ProblemCustom to avoid colliding with future built-in checks.
+type Check interface {
+	// Problems returns the problem codes the check may report.
+	Problems() []int
+	// Check returns the problems, if any, with the given transaction.
+	Check(input Input) ([]ProblematicTransaction, error)
+}
+
+// ProblemCustom is the first problem code reserved for custom checks.
+const ProblemCustom = 1000
+
+type checkFunc struct {
+	problems []int
+	check    func(input Input) ([]ProblematicTransaction, error)
+}
+
+// New adapts the given function, reporting only the given problem codes, into a Check.
+func New(
+	problems []int,
+	check func(input Input) ([]ProblematicTransaction, error),
+) Check {
+	return &checkFunc{
+		problems: problems,
+		check:    check,
+	}
+}
+
+func (c *checkFunc) Problems() []int {
+	return c.problems
+}
+
+func (c *checkFunc) Check(input Input) ([]ProblematicTransaction, error) {
+	return c.check(input)
+}
+
+// Registry is an ordered set of checks, any of whose problems may be disabled.
+type Registry struct {
+	checks   []Check
+	disabled map[int]bool
+}
+
+// NewRegistry creates a registry with the given checks, all enabled.
+func NewRegistry(checks ...Check) *Registry {
+	registry := &Registry{
+		disabled: make(map[int]bool),
+	}
+	for _, check := range checks {
+		registry.Register(check)
+	}
+
+	return registry
+}
+
+// Register adds the given check after any already registered.
+func (r *Registry) Register(check Check) {
+	r.checks = append(r.checks, check)
+}
+
+// GetProblems returns the sorted problem codes reported by the registered checks.
+func (r *Registry) GetProblems() []int {
+	problems := []int{}
+	for _, check := range r.checks {
+		problems = append(problems, check.Problems()...)
+	}
+	sort.Ints(problems)
+
+	return problems
+}
+
+// Disable prevents the given problems from being reported, failing if any problem is not
+// reported by a registered check.
+func (r *Registry) Disable(problems ...int) error {
+	if err := r.validateProblems(problems); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, problem := range problems {
+		r.disabled[problem] = true
+	}
+
+	return nil
+}
+
+// Enable allows the given previously disabled problems to be reported, failing if any problem
+// is not reported by a registered check.
+func (r *Registry) Enable(problems ...int) error {
+	if err := r.validateProblems(problems); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, problem := range problems {
+		delete(r.disabled, problem)
+	}
+
+	return nil
+}
+
+// IsEnabled determines if the given problem will be reported.
+func (r *Registry) IsEnabled(problem int) bool {
+	return !r.disabled[problem]
+}
+
+func (r *Registry) validateProblems(problems []int) error {
+	known := make(map[int]bool)
+	for _, problem := range r.GetProblems() {
+		known[problem] = true
+	}
+
+	for _, problem := range problems {
+		if !known[problem] {
+			return errors.Errorf("unknown problem %d", problem)
+		}
+	}
+
+	return nil
+}
+
+// Config enables or disables problems by code. It is typically loaded from a JSON file such as:
+//
+//	{"disable": [7, 9]}
+type Config struct {
+	Disable []int `json:"disable"`
+	Enable  []int `json:"enable"`
+}
+
+// LoadConfig reads a Config from the JSON file at the given path.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	return config, nil
+}
+
+// Configure disables and then enables the problems given by the config.
+func (r *Registry) Configure(config Config) error {
+	if err := r.Disable(config.Disable...); err != nil {
+		return errors.Wrap(err, "failed to disable problems")
+	}
+
+	if err := r.Enable(config.Enable...); err != nil {
+		return errors.Wrap(err, "failed to enable problems")
+	}
+
+	return nil
+}
+
+// GetProblematicTransactions runs the enabled checks against each transaction in the given
+// document, returning any problems found in the order of the transactions and then of the
+// registered checks.
+func (r *Registry) GetProblematicTransactions(
+	document *api.Document,
+) ([]ProblematicTransaction, error) {
+	checks := []Check{}
+	for _, check := range r.checks {
+		for _, problem := range check.Problems() {
+			if r.IsEnabled(problem) {
+				checks = append(checks, check)
+				break
+			}
+		}
+	}
+
+	problematicTransactions := []ProblematicTransaction{}
+
+	for _, transaction := range document.Transactions {
+		// Ignore transactions before the cash flow start date. They won't contribute
+		// to any current imbalance.
+		if transaction.Date.Before(document.Settings.CashFlowStartDate) {
+			continue
+		}
+
+		// Ignore $0.00 transactions. These won't contribute to an imbalance, and might
+		// be used to demarcate initial balances.
+		if transaction.Amount.IsZero() {
+			continue
+		}
+
+		account, ok := document.GetAccount(transaction.Account)
+		if !ok {
+			return nil, errors.Errorf("failed to find account %v", transaction.Account)
+		}
+
+		input := Input{
+			Account:     account,
+			Transaction: transaction,
+			Tags:        document.TransactionTags[transaction.PrimaryKey],
+			Document:    document,
+		}
+
+		for _, check := range checks {
+			checkProblematicTransactions, err := check.Check(input)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			for _, problematicTransaction := range checkProblematicTransactions {
+				if r.IsEnabled(problematicTransaction.Problem) {
+					problematicTransactions = append(
+						problematicTransactions,
+						problematicTransaction,
+					)
+				}
+			}
+		}
+	}
+
+	return problematicTransactions, nil
+}
@@ -0,0 +1,146 @@
+package check_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+func TestRegistry(t *testing.T) {
+	database, err := api.OpenDocument("../Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	const problemWithdrawal = check.ProblemCustom
+	const problemDeposit = check.ProblemCustom + 1
+
+	// newSignCheck reports the given problem for each transaction of the given sign.
+	newSignCheck := func(problem int, sign int64) check.Check {
+		return check.New([]int{problem}, func(input check.Input) ([]check.ProblematicTransaction, error) {
+			if input.Transaction.Amount.Amount*sign <= 0 {
+				return nil, nil
+			}
+
+			return []check.ProblematicTransaction{
+				{
+					Transaction: input.Transaction.PrimaryKey,
+					Problem:     problem,
+					Description: fmt.Sprintf("transaction[%d]", input.Transaction.PrimaryKey),
+				},
+			}, nil
+		})
+	}
+
+	getProblematicPrimaryKeys := func(registry *check.Registry) []int64 {
+		problematicTransactions, err := registry.GetProblematicTransactions(document)
+		assert.NoError(t, err)
+
+		primaryKeys := []int64{}
+		for _, problematicTransaction := range problematicTransactions {
+			primaryKeys = append(primaryKeys, problematicTransaction.Transaction)
+		}
+
+		return primaryKeys
+	}
+
+	t.Run("custom checks", func(t *testing.T) {
+		registry := check.NewRegistry(newSignCheck(problemWithdrawal, -1))
+		registry.Register(newSignCheck(problemDeposit, 1))
+		assert.Equal(t, []int{problemWithdrawal, problemDeposit}, registry.GetProblems())
+
+		assert.Equal(
+			t,
+			[]int64{2, 4, 5, 15, 13, 14, 16, 8, 20, 18},
+			getProblematicPrimaryKeys(registry),
+		)
+	})
+
+	t.Run("disable and enable problems", func(t *testing.T) {
+		registry := check.NewRegistry(
+			newSignCheck(problemWithdrawal, -1),
+			newSignCheck(problemDeposit, 1),
+		)
+
+		assert.NoError(t, registry.Disable(problemWithdrawal))
+		assert.False(t, registry.IsEnabled(problemWithdrawal))
+		assert.Equal(t, []int64{2, 15, 8}, getProblematicPrimaryKeys(registry))
+
+		assert.NoError(t, registry.Configure(check.Config{
+			Disable: []int{problemDeposit},
+			Enable:  []int{problemWithdrawal},
+		}))
+		assert.True(t, registry.IsEnabled(problemWithdrawal))
+		assert.Equal(t, []int64{4, 5, 13, 14, 16, 20, 18}, getProblematicPrimaryKeys(registry))
+	})
+
+	t.Run("disable unknown problem", func(t *testing.T) {
+		registry := check.NewRegistry(newSignCheck(problemWithdrawal, -1))
+		assert.Error(t, registry.Disable(problemDeposit))
+	})
+}
+
+func TestNewLargeUntaggedCheck(t *testing.T) {
+	database, err := api.OpenDocument("../Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	registry := check.NewRegistry(check.NewLargeUntaggedCheck(
+		check.ProblemCustom,
+		money.Money{Currency: "CAD", Amount: 450 * 100},
+	))
+
+	problematicTransactions, err := registry.GetProblematicTransactions(&api.Document{
+		Settings:        document.Settings,
+		Accounts:        document.Accounts,
+		Transactions:    document.Transactions,
+		TransactionTags: map[int64][]int64{2: {1}, 5: {3, 4}},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []check.ProblematicTransaction{
+		{
+			Transaction: 14,
+			Problem:     check.ProblemCustom,
+			Description: "transaction[14] on 2017-11-12 against Chequing Account for " +
+				"-$500.00 CAD over $450.00 CAD is not tagged",
+		},
+	}, problematicTransactions)
+
+	// A limit without a currency applies to every currency.
+	registry = check.NewRegistry(check.NewLargeUntaggedCheck(
+		check.ProblemCustom,
+		money.Money{Amount: 450 * 100},
+	))
+
+	problematicTransactions, err = registry.GetProblematicTransactions(&api.Document{
+		Settings: document.Settings,
+		Accounts: document.Accounts,
+		Transactions: append(append([]api.Transaction{}, document.Transactions...), api.Transaction{
+			PrimaryKey: 1001,
+			Date:       time.Date(2017, 11, 20, 0, 0, 0, 0, time.UTC),
+			Amount:     money.Money{Currency: "USD", Amount: -600 * 100},
+			Account:    4,
+			Status:     api.TransactionStatusCleared,
+		}),
+		TransactionTags: document.TransactionTags,
+	})
+	assert.NoError(t, err)
+
+	problems := []int64{}
+	for _, problematicTransaction := range problematicTransactions {
+		problems = append(problems, problematicTransaction.Transaction)
+	}
+	assert.Equal(t, []int64{14, 1001}, problems)
+}
@@ -0,0 +1,6 @@
+// Package check defines the checks run by moneywelldoctor against each transaction in a MoneyWell
+// document, and a registry of checks, any of whose problems may be disabled.
+//
+// Beyond the built-in checks, callers may implement Check to diagnose their own problems, such as
+// the sample check returned by NewLargeUntaggedCheck.
+package check
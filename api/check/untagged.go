@@ -0,0 +1,48 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// NewLargeUntaggedCheck creates a sample custom check reporting the given problem for each
+// transaction over the given limit, whether a deposit or a withdrawal, that is not tagged.
+//
+// Only transactions in the currency of the limit are considered, unless the limit has no
+// currency. Split children are tagged independently of their parent, and so are checked as any
+// other transaction.
+func NewLargeUntaggedCheck(problem int, limit money.Money) Check {
+	return New([]int{problem}, func(input Input) ([]ProblematicTransaction, error) {
+		transaction := input.Transaction
+		if limit.Currency != "" && transaction.Amount.Currency != limit.Currency {
+			return nil, nil
+		}
+		if len(input.Tags) > 0 {
+			return nil, nil
+		}
+
+		amount := transaction.Amount.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		if amount <= limit.Amount {
+			return nil, nil
+		}
+
+		return []ProblematicTransaction{
+			{
+				Transaction: transaction.PrimaryKey,
+				Problem:     problem,
+				Description: fmt.Sprintf(
+					"transaction[%d] on %s against %s for %s over %s is not tagged",
+					transaction.PrimaryKey,
+					transaction.Date.Format("2006-01-02"),
+					input.Account.Name,
+					transaction.Amount,
+					limit,
+				),
+			},
+		}, nil
+	})
+}
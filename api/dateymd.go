@@ -20,3 +20,18 @@ func parseDateymd(dateymd int) (time.Time, error) {
 
 	return date, nil
 }
+
+// formatDateymd formats the given date as an integer as recorded in a MoneyWell document.
+func formatDateymd(date time.Time) int {
+	if date.IsZero() {
+		return 0
+	}
+
+	return date.Year()*10000 + int(date.Month())*100 + date.Day()
+}
+
+// truncateDate discards any time of day from the given time, returning midnight UTC of the same
+// calendar date as MoneyWell itself only records dates.
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -1,5 +1,10 @@
-// Package api exposes a programmatic, low-level, read-only interface to a MoneyWell document,
-// itself a Core Data SQLite database.
+// Package api exposes a programmatic, low-level interface to a MoneyWell document, itself a Core
+// Data SQLite database.
+//
+// Documents are read through OpenDocument, either one kind of entity at a time with the Get*
+// functions, or all at once as a consistent, indexed snapshot with LoadDocument. Transactions may
+// also be inserted, but only into a copy of a document made by CopyDocument and opened with
+// OpenWritableDocument.
 //
 // This project is in no way affiliated with No Thirst Software.
 package api
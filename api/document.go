@@ -0,0 +1,340 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Querier is the interface shared by *sql.DB and *sql.Tx, allowing entities to be fetched either
+// directly from a MoneyWell document or within a database transaction.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Document is a snapshot of the entities of a MoneyWell document, indexed for lookup by primary
+// key and by relationship.
+//
+// A Document is typically loaded once by LoadDocument. It may also be built directly from
+// entities fetched elsewhere, with the indexes built on first use. Either way, the entities must
+// not be changed once the Document is in use.
+type Document struct {
+	Settings        Settings
+	AccountGroups   []AccountGroup
+	Accounts        []Account
+	BucketGroups    []BucketGroup
+	Buckets         []Bucket
+	BucketTransfers []BucketTransfer
+	Tags            []Tag
+	// Transactions are sorted by the display order as MoneyWell itself would render.
+	Transactions []Transaction
+	// TransactionTags maps the primary key of each tagged transaction to those of its tags.
+	TransactionTags map[int64][]int64
+	Statements      []Statement
+
+	indexOnce           sync.Once
+	accountGroups       map[int64]int
+	accounts            map[int64]int
+	bucketGroups        map[int64]int
+	buckets             map[int64]int
+	tags                map[int64]int
+	transactions        map[int64]int
+	splitChildren       map[int64][]int
+	transferSiblings    map[int64]int
+	accountTransactions map[int64][]int
+	bucketTransactions  map[int64][]int
+	tagTransactions     map[int64][]int
+}
+
+// LoadDocument fetches the entities of the given MoneyWell document within a single database
+// transaction, so that they are consistent with each other even if the document is changed
+// concurrently.
+func LoadDocument(database *sql.DB) (*Document, error) {
+	return LoadDocumentContext(context.Background(), database)
+}
+
+// LoadDocumentContext is like LoadDocument, but runs within the given context.
+func LoadDocumentContext(ctx context.Context, database *sql.DB) (*Document, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to begin transaction")
+	}
+	// Nothing is written, so the transaction is only ever rolled back.
+	defer tx.Rollback()
+
+	document := &Document{}
+
+	document.Settings, err = GetSettingsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get settings")
+	}
+
+	document.AccountGroups, err = GetAccountGroupsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get account groups")
+	}
+
+	document.Accounts, err = GetAccountsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get accounts")
+	}
+
+	document.BucketGroups, err = GetBucketGroupsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get bucket groups")
+	}
+
+	document.Buckets, err = GetBucketsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get buckets")
+	}
+
+	document.BucketTransfers, err = GetBucketTransfersContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get bucket transfers")
+	}
+
+	document.Tags, err = GetTagsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get tags")
+	}
+
+	document.Transactions, err = GetTransactionsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get transactions")
+	}
+
+	document.TransactionTags, err = GetTransactionTagMapContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get transaction tags")
+	}
+
+	document.Statements, err = GetStatementsContext(ctx, tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get statements")
+	}
+
+	document.index()
+
+	return document, nil
+}
+
+// index builds the lookups over the entities of the document, once.
+func (d *Document) index() {
+	d.indexOnce.Do(func() {
+		d.accountGroups = make(map[int64]int, len(d.AccountGroups))
+		for i, accountGroup := range d.AccountGroups {
+			d.accountGroups[accountGroup.PrimaryKey] = i
+		}
+
+		d.accounts = make(map[int64]int, len(d.Accounts))
+		for i, account := range d.Accounts {
+			d.accounts[account.PrimaryKey] = i
+		}
+
+		d.bucketGroups = make(map[int64]int, len(d.BucketGroups))
+		for i, bucketGroup := range d.BucketGroups {
+			d.bucketGroups[bucketGroup.PrimaryKey] = i
+		}
+
+		d.buckets = make(map[int64]int, len(d.Buckets))
+		for i, bucket := range d.Buckets {
+			d.buckets[bucket.PrimaryKey] = i
+		}
+
+		d.tags = make(map[int64]int, len(d.Tags))
+		for i, tag := range d.Tags {
+			d.tags[tag.PrimaryKey] = i
+		}
+
+		d.transactions = make(map[int64]int, len(d.Transactions))
+		d.splitChildren = make(map[int64][]int)
+		d.transferSiblings = make(map[int64]int)
+		d.accountTransactions = make(map[int64][]int)
+		d.bucketTransactions = make(map[int64][]int)
+		d.tagTransactions = make(map[int64][]int)
+		for i, transaction := range d.Transactions {
+			d.transactions[transaction.PrimaryKey] = i
+
+			if transaction.SplitParent != 0 {
+				d.splitChildren[transaction.SplitParent] = append(
+					d.splitChildren[transaction.SplitParent],
+					i,
+				)
+			}
+
+			if transaction.Account != 0 {
+				d.accountTransactions[transaction.Account] = append(
+					d.accountTransactions[transaction.Account],
+					i,
+				)
+			}
+
+			if transaction.Bucket != 0 {
+				d.bucketTransactions[transaction.Bucket] = append(
+					d.bucketTransactions[transaction.Bucket],
+					i,
+				)
+			}
+
+			for _, tag := range d.TransactionTags[transaction.PrimaryKey] {
+				d.tagTransactions[tag] = append(d.tagTransactions[tag], i)
+			}
+		}
+
+		// Both sides of a transfer usually refer to each other, but index the reverse
+		// relationship too in case only one side does.
+		for i, transaction := range d.Transactions {
+			if transaction.TransferSibling == 0 {
+				continue
+			}
+
+			if sibling, ok := d.transactions[transaction.TransferSibling]; ok {
+				d.transferSiblings[transaction.PrimaryKey] = sibling
+				if _, ok := d.transferSiblings[transaction.TransferSibling]; !ok {
+					d.transferSiblings[transaction.TransferSibling] = i
+				}
+			}
+		}
+	})
+}
+
+// getTransactions returns the transactions at the given indexes, preserving their order.
+func (d *Document) getTransactions(indexes []int) []Transaction {
+	transactions := make([]Transaction, 0, len(indexes))
+	for _, i := range indexes {
+		transactions = append(transactions, d.Transactions[i])
+	}
+
+	return transactions
+}
+
+// GetAccountGroup finds the account group with the given primary key.
+func (d *Document) GetAccountGroup(primaryKey int64) (AccountGroup, bool) {
+	d.index()
+
+	i, ok := d.accountGroups[primaryKey]
+	if !ok {
+		return AccountGroup{}, false
+	}
+
+	return d.AccountGroups[i], true
+}
+
+// GetAccount finds the account with the given primary key.
+func (d *Document) GetAccount(primaryKey int64) (Account, bool) {
+	d.index()
+
+	i, ok := d.accounts[primaryKey]
+	if !ok {
+		return Account{}, false
+	}
+
+	return d.Accounts[i], true
+}
+
+// FindAccount finds the first account with the given name, in the display order.
+func (d *Document) FindAccount(name string) (Account, bool) {
+	for _, account := range d.Accounts {
+		if account.Name == name {
+			return account, true
+		}
+	}
+
+	return Account{}, false
+}
+
+// GetBucketGroup finds the bucket group with the given primary key.
+func (d *Document) GetBucketGroup(primaryKey int64) (BucketGroup, bool) {
+	d.index()
+
+	i, ok := d.bucketGroups[primaryKey]
+	if !ok {
+		return BucketGroup{}, false
+	}
+
+	return d.BucketGroups[i], true
+}
+
+// GetBucket finds the bucket with the given primary key.
+func (d *Document) GetBucket(primaryKey int64) (Bucket, bool) {
+	d.index()
+
+	i, ok := d.buckets[primaryKey]
+	if !ok {
+		return Bucket{}, false
+	}
+
+	return d.Buckets[i], true
+}
+
+// GetTag finds the tag with the given primary key.
+func (d *Document) GetTag(primaryKey int64) (Tag, bool) {
+	d.index()
+
+	i, ok := d.tags[primaryKey]
+	if !ok {
+		return Tag{}, false
+	}
+
+	return d.Tags[i], true
+}
+
+// GetTransaction finds the transaction with the given primary key.
+func (d *Document) GetTransaction(primaryKey int64) (Transaction, bool) {
+	d.index()
+
+	i, ok := d.transactions[primaryKey]
+	if !ok {
+		return Transaction{}, false
+	}
+
+	return d.Transactions[i], true
+}
+
+// GetSplitChildren returns the children of the split transaction with the given primary key, in
+// display order.
+func (d *Document) GetSplitChildren(primaryKey int64) []Transaction {
+	d.index()
+
+	return d.getTransactions(d.splitChildren[primaryKey])
+}
+
+// GetTransferSibling finds the other side of the transfer with the given primary key.
+func (d *Document) GetTransferSibling(primaryKey int64) (Transaction, bool) {
+	d.index()
+
+	i, ok := d.transferSiblings[primaryKey]
+	if !ok {
+		return Transaction{}, false
+	}
+
+	return d.Transactions[i], true
+}
+
+// GetAccountTransactions returns the transactions recorded against the account with the given
+// primary key, in display order. Split children, voided and pending transactions are included.
+func (d *Document) GetAccountTransactions(account int64) []Transaction {
+	d.index()
+
+	return d.getTransactions(d.accountTransactions[account])
+}
+
+// GetBucketTransactions returns the transactions assigned to the bucket with the given primary
+// key, in display order. Voided and pending transactions are included.
+func (d *Document) GetBucketTransactions(bucket int64) []Transaction {
+	d.index()
+
+	return d.getTransactions(d.bucketTransactions[bucket])
+}
+
+// GetTagTransactions returns the transactions assigned the tag with the given primary key, in
+// display order.
+func (d *Document) GetTagTransactions(tag int64) []Transaction {
+	d.index()
+
+	return d.getTransactions(d.tagTransactions[tag])
+}
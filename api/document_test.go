@@ -0,0 +1,144 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+func getPrimaryKeys(transactions []api.Transaction) []int64 {
+	primaryKeys := []int64{}
+	for _, transaction := range transactions {
+		primaryKeys = append(primaryKeys, transaction.PrimaryKey)
+	}
+
+	return primaryKeys
+}
+
+func TestLoadDocument(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+	assert.Equal(t, transactions, document.Transactions)
+
+	transactionTagMap, err := api.GetTransactionTagMap(database)
+	assert.NoError(t, err)
+	assert.Equal(t, transactionTagMap, document.TransactionTags)
+
+	assert.Len(t, document.Accounts, 6)
+	assert.Len(t, document.Buckets, 4)
+	assert.Len(t, document.Tags, 4)
+	assert.Len(t, document.Statements, 0)
+
+	t.Run("entities", func(t *testing.T) {
+		account, ok := document.GetAccount(1)
+		assert.True(t, ok)
+		assert.Equal(t, "Chequing Account", account.Name)
+
+		account, ok = document.FindAccount("Visa")
+		assert.True(t, ok)
+		assert.EqualValues(t, 6, account.PrimaryKey)
+
+		_, ok = document.FindAccount("Unknown")
+		assert.False(t, ok)
+
+		bucket, ok := document.GetBucket(13)
+		assert.True(t, ok)
+		assert.Equal(t, "Groceries", bucket.Name)
+
+		tag, ok := document.GetTag(4)
+		assert.True(t, ok)
+		assert.Equal(t, "tag4", tag.Name)
+
+		transaction, ok := document.GetTransaction(14)
+		assert.True(t, ok)
+		assert.True(t, transaction.IsSplit)
+
+		_, ok = document.GetAccount(100)
+		assert.False(t, ok)
+
+		_, ok = document.GetTransaction(100)
+		assert.False(t, ok)
+	})
+
+	t.Run("relationships", func(t *testing.T) {
+		assert.Equal(t, []int64{13, 16}, getPrimaryKeys(document.GetSplitChildren(14)))
+		assert.Equal(t, []int64{}, getPrimaryKeys(document.GetSplitChildren(13)))
+
+		sibling, ok := document.GetTransferSibling(15)
+		assert.True(t, ok)
+		assert.EqualValues(t, 16, sibling.PrimaryKey)
+
+		sibling, ok = document.GetTransferSibling(16)
+		assert.True(t, ok)
+		assert.EqualValues(t, 15, sibling.PrimaryKey)
+
+		_, ok = document.GetTransferSibling(14)
+		assert.False(t, ok)
+
+		assert.Equal(
+			t,
+			[]int64{1, 2, 4, 5, 13, 14, 16, 20, 18},
+			getPrimaryKeys(document.GetAccountTransactions(1)),
+		)
+		assert.Equal(t, []int64{11, 15}, getPrimaryKeys(document.GetAccountTransactions(3)))
+		assert.Equal(t, []int64{2, 20, 18}, getPrimaryKeys(document.GetBucketTransactions(3)))
+		assert.Equal(t, []int64{}, getPrimaryKeys(document.GetBucketTransactions(27)))
+		assert.Equal(t, []int64{4, 5}, getPrimaryKeys(document.GetTagTransactions(4)))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := api.LoadDocumentContext(ctx, database)
+		assert.Error(t, err)
+	})
+}
+
+func TestDocument(t *testing.T) {
+	t.Parallel()
+
+	document := &api.Document{
+		Accounts: []api.Account{
+			{PrimaryKey: 1, Name: "Chequing"},
+			{PrimaryKey: 2, Name: "Savings"},
+		},
+		Transactions: []api.Transaction{
+			{PrimaryKey: 1, Account: 1, TransferAccount: 2},
+			{PrimaryKey: 2, Account: 2, TransferAccount: 1, TransferSibling: 1},
+			{PrimaryKey: 3, Account: 1, IsSplit: true},
+			{PrimaryKey: 4, Account: 1, Bucket: 5, SplitParent: 3},
+		},
+		TransactionTags: map[int64][]int64{4: {6}},
+	}
+
+	account, ok := document.GetAccount(2)
+	assert.True(t, ok)
+	assert.Equal(t, "Savings", account.Name)
+
+	// Only one side of the transfer refers to the other.
+	sibling, ok := document.GetTransferSibling(1)
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, sibling.PrimaryKey)
+
+	sibling, ok = document.GetTransferSibling(2)
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, sibling.PrimaryKey)
+
+	assert.Equal(t, []int64{4}, getPrimaryKeys(document.GetSplitChildren(3)))
+	assert.Equal(t, []int64{1, 3, 4}, getPrimaryKeys(document.GetAccountTransactions(1)))
+	assert.Equal(t, []int64{4}, getPrimaryKeys(document.GetBucketTransactions(5)))
+	assert.Equal(t, []int64{4}, getPrimaryKeys(document.GetTagTransactions(6)))
+}
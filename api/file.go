@@ -3,8 +3,11 @@ package api
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -14,9 +17,43 @@ import (
 // OpenDocument opens the given MoneyWell document and returns an interface to the SQLite database
 // therein.
 func OpenDocument(moneywellPath string) (*sql.DB, error) {
+	persistentStorePath, err := getPersistentStorePath(moneywellPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	database, err := sql.Open("sqlite3", fmt.Sprintf("%s?mode=ro", persistentStorePath))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open database")
+	}
+
+	return database, nil
+}
+
+// OpenWritableDocument opens the given MoneyWell document for writing and returns an interface to
+// the SQLite database therein.
+//
+// Only ever open a copy of a document made by CopyDocument, and never while the document is open
+// in MoneyWell itself.
+func OpenWritableDocument(moneywellPath string) (*sql.DB, error) {
+	persistentStorePath, err := getPersistentStorePath(moneywellPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	database, err := sql.Open("sqlite3", persistentStorePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open database")
+	}
+
+	return database, nil
+}
+
+// getPersistentStorePath resolves the given MoneyWell document to the SQLite database therein.
+func getPersistentStorePath(moneywellPath string) (string, error) {
 	fi, err := os.Stat(moneywellPath)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to stat %s", moneywellPath)
+		return "", errors.Wrapf(err, "failed to stat %s", moneywellPath)
 	}
 
 	switch mode := fi.Mode(); {
@@ -27,10 +64,130 @@ func OpenDocument(moneywellPath string) (*sql.DB, error) {
 		// Attempt to access the path as-is.
 	}
 
-	database, err := sql.Open("sqlite3", fmt.Sprintf("%s?mode=ro", moneywellPath))
+	return moneywellPath, nil
+}
+
+// CopyDocument copies the given MoneyWell document, either a `.moneywell` bundle or the SQLite
+// database therein, to a destination that must not already exist and must not be within the
+// document. Copying the SQLite database alone also copies its write-ahead log and shared-memory
+// files, if any, so as not to lose changes not yet written back to the database.
+func CopyDocument(moneywellPath string, destinationPath string) error {
+	if _, err := os.Stat(destinationPath); err == nil {
+		return errors.Errorf("failed to copy to %s: already exists", destinationPath)
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to stat %s", destinationPath)
+	}
+
+	resolvedMoneywellPath, err := resolvePath(moneywellPath)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to open database")
+		return errors.WithStack(err)
+	}
+	resolvedDestinationPath, err := resolvePath(destinationPath)
+	if err != nil {
+		return errors.WithStack(err)
 	}
 
-	return database, nil
+	relativePath, err := filepath.Rel(resolvedMoneywellPath, resolvedDestinationPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", destinationPath)
+	}
+	if relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return errors.Errorf("failed to copy to %s: within %s", destinationPath, moneywellPath)
+	}
+
+	err = filepath.Walk(moneywellPath, func(sourcePath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to walk %s", sourcePath)
+		}
+
+		relativePath, err := filepath.Rel(moneywellPath, sourcePath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve %s", sourcePath)
+		}
+		targetPath := filepath.Join(destinationPath, relativePath)
+
+		switch mode := fi.Mode(); {
+		case mode.IsDir():
+			if err := os.Mkdir(targetPath, mode.Perm()); err != nil {
+				return errors.Wrapf(err, "failed to create %s", targetPath)
+			}
+		case mode.IsRegular():
+			if err := copyFile(sourcePath, targetPath, mode.Perm()); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy %s", moneywellPath)
+	}
+
+	if fi, err := os.Stat(moneywellPath); err == nil && fi.Mode().IsRegular() {
+		for _, suffix := range []string{"-wal", "-shm"} {
+			fi, err := os.Stat(moneywellPath + suffix)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return errors.Wrapf(err, "failed to stat %s", moneywellPath+suffix)
+			}
+
+			err = copyFile(moneywellPath+suffix, destinationPath+suffix, fi.Mode().Perm())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolvePath makes the given path absolute, resolving any symbolic links in the path, or in its
+// parent directory if the path does not yet exist.
+func resolvePath(filePath string) (string, error) {
+	absolutePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve %s", filePath)
+	}
+
+	resolvedPath, err := filepath.EvalSymlinks(absolutePath)
+	if err == nil {
+		return resolvedPath, nil
+	} else if !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "failed to resolve %s", filePath)
+	}
+
+	parentPath, err := filepath.EvalSymlinks(filepath.Dir(absolutePath))
+	if os.IsNotExist(err) {
+		return absolutePath, nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve %s", filePath)
+	}
+
+	return filepath.Join(parentPath, filepath.Base(absolutePath)), nil
+}
+
+// copyFile copies the given file to a new file with the given permissions.
+func copyFile(sourcePath string, targetPath string, perm os.FileMode) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", sourcePath)
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", targetPath)
+	}
+
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return errors.Wrapf(err, "failed to copy %s", sourcePath)
+	}
+
+	if err := target.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close %s", targetPath)
+	}
+
+	return nil
 }
@@ -1,6 +1,9 @@
 package api_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,3 +28,107 @@ func TestOpenDocument(t *testing.T) {
 	_, err = api.OpenDocument("NoSuchFile.moneywell/StoreContent/persistentStore")
 	assert.Error(t, err)
 }
+
+func TestCopyDocument(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	// Copy from a copy of the test document, lest opening the original leave files behind.
+	sourcePath := copyTestDocument(t)
+
+	moneywellPath := filepath.Join(directory, "Copy.moneywell")
+	assert.NoError(t, api.CopyDocument(sourcePath, moneywellPath))
+	assert.Error(t, api.CopyDocument(sourcePath, moneywellPath))
+
+	persistentStorePath := filepath.Join(directory, "persistentStore")
+	assert.NoError(t, api.CopyDocument(
+		filepath.Join(sourcePath, "StoreContent/persistentStore"),
+		persistentStorePath,
+	))
+
+	original, err := ioutil.ReadFile("Test.moneywell/StoreContent/persistentStore")
+	assert.NoError(t, err)
+	for _, path := range []string{
+		filepath.Join(sourcePath, "StoreContent/persistentStore"),
+		filepath.Join(moneywellPath, "StoreContent/persistentStore"),
+		persistentStorePath,
+	} {
+		copied, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, original, copied)
+	}
+
+	assert.Error(t, api.CopyDocument("NoSuchFile.moneywell", filepath.Join(directory, "Other")))
+
+	database, err := api.OpenWritableDocument(moneywellPath)
+	assert.NoError(t, err)
+	defer database.Close()
+
+	_, err = database.Exec(`UPDATE ZACCOUNT SET ZNAME = 'Renamed' WHERE Z_PK = 1`)
+	assert.NoError(t, err)
+
+	sourceDatabase, err := api.OpenDocument(sourcePath)
+	assert.NoError(t, err)
+	defer sourceDatabase.Close()
+
+	accounts, err := api.GetAccountsMap(sourceDatabase)
+	assert.NoError(t, err)
+	assert.Equal(t, "Chequing Account", accounts[1].Name)
+}
+
+func TestCopyDocumentWithin(t *testing.T) {
+	t.Parallel()
+
+	moneywellPath := copyTestDocument(t)
+	persistentStorePath := filepath.Join(moneywellPath, "StoreContent/persistentStore")
+
+	for _, destinationPath := range []string{
+		moneywellPath,
+		filepath.Join(moneywellPath, "Fixed"),
+		filepath.Join(moneywellPath, "StoreContent/Fixed"),
+		filepath.Join(moneywellPath, "..", filepath.Base(moneywellPath), "Fixed"),
+	} {
+		assert.Error(t, api.CopyDocument(moneywellPath, destinationPath))
+	}
+	assert.Error(t, api.CopyDocument(persistentStorePath, persistentStorePath+"/Fixed"))
+
+	// Symbolic links to the document are resolved.
+	linkPath := filepath.Join(filepath.Dir(moneywellPath), "Link.moneywell")
+	assert.NoError(t, os.Symlink(moneywellPath, linkPath))
+	assert.Error(t, api.CopyDocument(linkPath, filepath.Join(moneywellPath, "Fixed")))
+	assert.Error(t, api.CopyDocument(moneywellPath, filepath.Join(linkPath, "Fixed")))
+
+	// A sibling sharing the name of the document as a prefix is not within it.
+	assert.NoError(t, api.CopyDocument(moneywellPath, moneywellPath+"-fixed"))
+	assert.NoError(t, api.CopyDocument(persistentStorePath, persistentStorePath+"-fixed"))
+}
+
+func TestCopyDocumentWriteAheadLog(t *testing.T) {
+	t.Parallel()
+
+	moneywellPath := copyTestDocument(t)
+	persistentStorePath := filepath.Join(moneywellPath, "StoreContent/persistentStore")
+
+	// Keep the database open while copying, leaving the change in the write-ahead log.
+	database, err := api.OpenWritableDocument(moneywellPath)
+	assert.NoError(t, err)
+	defer database.Close()
+
+	_, err = database.Exec(`UPDATE ZACCOUNT SET ZNAME = 'Renamed' WHERE Z_PK = 1`)
+	assert.NoError(t, err)
+
+	_, err = os.Stat(persistentStorePath + "-wal")
+	assert.NoError(t, err)
+
+	copyPath := filepath.Join(t.TempDir(), "persistentStore")
+	assert.NoError(t, api.CopyDocument(persistentStorePath, copyPath))
+
+	copyDatabase, err := api.OpenDocument(copyPath)
+	assert.NoError(t, err)
+	defer copyDatabase.Close()
+
+	accounts, err := api.GetAccountsMap(copyDatabase)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", accounts[1].Name)
+}
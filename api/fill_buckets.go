@@ -0,0 +1,216 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// BucketFill represents the amount transferred into an expense bucket when filling buckets in a
+// MoneyWell document.
+type BucketFill struct {
+	Bucket  int64
+	Balance money.Money
+	Planned money.Money
+	Amount  money.Money
+}
+
+// IsShort determines if insufficient income prevented the bucket from being filled as planned.
+func (f BucketFill) IsShort() bool {
+	return f.Amount.Amount < f.Planned.Amount
+}
+
+// GetBucketFills simulates MoneyWell's "Fill Buckets" action on the given date, returning the
+// amount that would be transferred into each expense bucket in display order.
+//
+// The planned amount for each bucket is the sum of the spending plan fills scheduled after the
+// last time buckets were filled (or since the cash flow start date) up to and including the given
+// date. The current balance of the income buckets is then allocated to the expense buckets in
+// display order: once income is exhausted, the remaining buckets are filled only partially, if
+// at all.
+func GetBucketFills(
+	date time.Time,
+	settings Settings,
+	buckets []Bucket,
+	spendingPlan []SpendingPlan,
+	recurrenceRules map[int64]RecurrenceRule,
+	transactions []Transaction,
+	bucketTransfers []BucketTransfer,
+) ([]BucketFill, error) {
+	bucketsMap := make(map[int64]Bucket, len(buckets))
+	for _, bucket := range buckets {
+		bucketsMap[bucket.PrimaryKey] = bucket
+	}
+
+	from := settings.CashFlowStartDate
+	if !settings.LastFillBucketsDate.IsZero() {
+		from = settings.LastFillBucketsDate.AddDate(0, 0, 1)
+	}
+
+	planned := make(map[int64]money.Money)
+	if !from.After(date) {
+		schedule := GetSpendingPlanSchedule(spendingPlan, recurrenceRules, bucketsMap, from, date)
+		for _, occurrence := range schedule {
+			if occurrence.Type != SpendingPlanOccurrenceTypeFill {
+				continue
+			}
+
+			planned[occurrence.Bucket] = planned[occurrence.Bucket].Add(occurrence.Amount)
+		}
+	}
+
+	// Track the available income by currency, since buckets may be denominated in different
+	// currencies.
+	available := make(map[string]money.Money)
+	balances := make(map[int64]money.Money, len(buckets))
+	for _, bucket := range buckets {
+		events, err := GetBucketEvents(bucket, transactions, bucketTransfers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get bucket events for %s", bucket.Name)
+		}
+
+		balance, err := GetBucketBalance(bucket, events, settings)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get bucket balance for %s", bucket.Name)
+		}
+		balances[bucket.PrimaryKey] = balance
+
+		if bucket.Type == BucketTypeIncome && balance.Amount > 0 {
+			available[balance.Currency] = available[balance.Currency].Add(balance)
+		}
+	}
+
+	bucketFills := []BucketFill{}
+	for _, bucket := range buckets {
+		plannedAmount, ok := planned[bucket.PrimaryKey]
+		if !ok || plannedAmount.IsZero() {
+			continue
+		}
+
+		amount := plannedAmount
+		if remaining := available[plannedAmount.Currency]; amount.Amount > remaining.Amount {
+			amount = money.Money{Currency: plannedAmount.Currency, Amount: remaining.Amount}
+			if amount.Amount < 0 {
+				amount.Amount = 0
+			}
+		}
+		available[plannedAmount.Currency] = available[plannedAmount.Currency].Add(
+			amount.Multiply(-1),
+		)
+
+		bucketFills = append(bucketFills, BucketFill{
+			Bucket:  bucket.PrimaryKey,
+			Balance: balances[bucket.PrimaryKey],
+			Planned: plannedAmount,
+			Amount:  amount,
+		})
+	}
+
+	return bucketFills, nil
+}
+
+// BucketFillAudit compares the amount actually transferred into a bucket when buckets were filled
+// on a given date against the amount the spending plan called for.
+type BucketFillAudit struct {
+	Date     time.Time
+	Bucket   int64
+	Expected money.Money
+	Actual   money.Money
+}
+
+// IsMatch determines if the bucket was filled exactly as planned.
+func (a BucketFillAudit) IsMatch() bool {
+	return a.Expected.Amount == a.Actual.Amount
+}
+
+// AuditBucketFills compares the bucket transfers MoneyWell recorded when filling buckets against
+// the fills scheduled by the spending plan since the previous fill, returning one audit per
+// bucket per fill date sorted by date and then bucket display order.
+//
+// MoneyWell records fills as bucket transfers not created by the user. Only transfers into
+// expense buckets are considered.
+func AuditBucketFills(
+	settings Settings,
+	buckets []Bucket,
+	spendingPlan []SpendingPlan,
+	recurrenceRules map[int64]RecurrenceRule,
+	bucketTransfers []BucketTransfer,
+) []BucketFillAudit {
+	bucketsMap := make(map[int64]Bucket, len(buckets))
+	bucketOrder := make(map[int64]int, len(buckets))
+	for i, bucket := range buckets {
+		bucketsMap[bucket.PrimaryKey] = bucket
+		bucketOrder[bucket.PrimaryKey] = i
+	}
+
+	actual := make(map[time.Time]map[int64]money.Money)
+	for _, bucketTransfer := range bucketTransfers {
+		if bucketTransfer.IsUserCreated {
+			continue
+		}
+		if bucketsMap[bucketTransfer.Bucket].Type != BucketTypeExpense {
+			continue
+		}
+
+		if actual[bucketTransfer.Date] == nil {
+			actual[bucketTransfer.Date] = make(map[int64]money.Money)
+		}
+		actual[bucketTransfer.Date][bucketTransfer.Bucket] = actual[bucketTransfer.Date][bucketTransfer.Bucket].Add(
+			bucketTransfer.Amount,
+		)
+	}
+
+	fillDates := make([]time.Time, 0, len(actual))
+	for fillDate := range actual {
+		fillDates = append(fillDates, fillDate)
+	}
+	sort.Slice(fillDates, func(i, j int) bool { return fillDates[i].Before(fillDates[j]) })
+
+	audits := []BucketFillAudit{}
+	from := settings.CashFlowStartDate
+	for _, fillDate := range fillDates {
+		expected := make(map[int64]money.Money)
+		schedule := GetSpendingPlanSchedule(spendingPlan, recurrenceRules, bucketsMap, from, fillDate)
+		for _, occurrence := range schedule {
+			if occurrence.Type != SpendingPlanOccurrenceTypeFill {
+				continue
+			}
+
+			expected[occurrence.Bucket] = expected[occurrence.Bucket].Add(occurrence.Amount)
+		}
+
+		fillDateAudits := []BucketFillAudit{}
+		for bucket, amount := range actual[fillDate] {
+			fillDateAudits = append(fillDateAudits, BucketFillAudit{
+				Date:     fillDate,
+				Bucket:   bucket,
+				Expected: expected[bucket],
+				Actual:   amount,
+			})
+		}
+		for bucket, amount := range expected {
+			if _, ok := actual[fillDate][bucket]; ok {
+				continue
+			}
+
+			fillDateAudits = append(fillDateAudits, BucketFillAudit{
+				Date:     fillDate,
+				Bucket:   bucket,
+				Expected: amount,
+				Actual:   money.Money{Currency: amount.Currency},
+			})
+		}
+
+		sort.Slice(fillDateAudits, func(i, j int) bool {
+			return bucketOrder[fillDateAudits[i].Bucket] < bucketOrder[fillDateAudits[j].Bucket]
+		})
+		audits = append(audits, fillDateAudits...)
+
+		from = fillDate.AddDate(0, 0, 1)
+	}
+
+	return audits
+}
@@ -0,0 +1,170 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+func TestGetBucketFills(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	settings, err := api.GetSettings(database)
+	assert.NoError(t, err)
+
+	buckets, err := api.GetBuckets(database)
+	assert.NoError(t, err)
+
+	spendingPlan, err := api.GetSpendingPlan(database)
+	assert.NoError(t, err)
+
+	recurrenceRules, err := api.GetRecurrenceRulesMap(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	assert.NoError(t, err)
+
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+
+	t.Run("sufficient income", func(t *testing.T) {
+		bucketFills, err := api.GetBucketFills(
+			time.Date(2018, 4, 3, 0, 0, 0, 0, time.UTC),
+			settings,
+			buckets,
+			spendingPlan,
+			recurrenceRules,
+			transactions,
+			bucketTransfers,
+		)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []api.BucketFill{
+			{Bucket: 13, Balance: cad(-20000), Planned: cad(1000), Amount: cad(1000)},
+			{Bucket: 27, Balance: cad(10000), Planned: cad(4000), Amount: cad(4000)},
+			{Bucket: 2, Balance: cad(5000), Planned: cad(3000), Amount: cad(3000)},
+		}, bucketFills)
+
+		for _, bucketFill := range bucketFills {
+			assert.False(t, bucketFill.IsShort())
+		}
+	})
+
+	t.Run("insufficient income", func(t *testing.T) {
+		bucketFills, err := api.GetBucketFills(
+			time.Date(2018, 4, 4, 0, 0, 0, 0, time.UTC),
+			settings,
+			buckets,
+			spendingPlan,
+			recurrenceRules,
+			transactions,
+			bucketTransfers,
+		)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []api.BucketFill{
+			{Bucket: 13, Balance: cad(-20000), Planned: cad(5000), Amount: cad(5000)},
+			{Bucket: 27, Balance: cad(10000), Planned: cad(6000), Amount: cad(5000)},
+			{Bucket: 2, Balance: cad(5000), Planned: cad(3429), Amount: cad(0)},
+		}, bucketFills)
+
+		assert.False(t, bucketFills[0].IsShort())
+		assert.True(t, bucketFills[1].IsShort())
+		assert.True(t, bucketFills[2].IsShort())
+	})
+
+	t.Run("already filled", func(t *testing.T) {
+		filledSettings := settings
+		filledSettings.LastFillBucketsDate = time.Date(2018, 4, 3, 0, 0, 0, 0, time.UTC)
+
+		bucketFills, err := api.GetBucketFills(
+			time.Date(2018, 4, 3, 0, 0, 0, 0, time.UTC),
+			filledSettings,
+			buckets,
+			spendingPlan,
+			recurrenceRules,
+			transactions,
+			bucketTransfers,
+		)
+		assert.NoError(t, err)
+		assert.Empty(t, bucketFills)
+	})
+}
+
+func TestAuditBucketFills(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	settings, err := api.GetSettings(database)
+	assert.NoError(t, err)
+
+	buckets, err := api.GetBuckets(database)
+	assert.NoError(t, err)
+
+	spendingPlan, err := api.GetSpendingPlan(database)
+	assert.NoError(t, err)
+
+	recurrenceRules, err := api.GetRecurrenceRulesMap(database)
+	assert.NoError(t, err)
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	assert.NoError(t, err)
+
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+
+	fill := func(date time.Time, bucket int64, amount int64) api.BucketTransfer {
+		return api.BucketTransfer{
+			Date:         date,
+			Amount:       cad(amount),
+			Bucket:       bucket,
+			TargetBucket: 3,
+		}
+	}
+
+	firstFill := time.Date(2018, 4, 3, 0, 0, 0, 0, time.UTC)
+	secondFill := time.Date(2018, 4, 5, 0, 0, 0, 0, time.UTC)
+
+	// The user created bucket transfers in the document are not fills, and are ignored.
+	bucketTransfers = append(
+		bucketTransfers,
+		fill(firstFill, 13, 1000),
+		fill(firstFill, 27, 4000),
+		fill(firstFill, 2, 3000),
+		fill(firstFill, 3, -8000),
+		fill(secondFill, 13, 5000),
+		fill(secondFill, 27, 4000),
+		fill(secondFill, 3, -9000),
+	)
+
+	audits := api.AuditBucketFills(settings, buckets, spendingPlan, recurrenceRules, bucketTransfers)
+
+	assert.Equal(t, []api.BucketFillAudit{
+		{Date: firstFill, Bucket: 13, Expected: cad(1000), Actual: cad(1000)},
+		{Date: firstFill, Bucket: 27, Expected: cad(4000), Actual: cad(4000)},
+		{Date: firstFill, Bucket: 2, Expected: cad(3000), Actual: cad(3000)},
+		{Date: secondFill, Bucket: 13, Expected: cad(9000), Actual: cad(5000)},
+		{Date: secondFill, Bucket: 27, Expected: cad(4000), Actual: cad(4000)},
+		{Date: secondFill, Bucket: 2, Expected: cad(858), Actual: cad(0)},
+	}, audits)
+
+	assert.True(t, audits[0].IsMatch())
+	assert.False(t, audits[3].IsMatch())
+	assert.False(t, audits[5].IsMatch())
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// InvestmentSecurity represents a stock, fund or other security held in an investment account in
+// a MoneyWell document. A security correlates 1:1 with a record in the ZINVESTMENTSECURITY
+// table. Not all columns are exported.
+//
+// Prices are recorded to more precision than a cent, and so are not represented as money.
+//
+// The MoneyWell SQLite schema for the ZINVESTMENTSECURITY table is as follows:
+//  > .schema ZINVESTMENTSECURITY
+//  CREATE TABLE ZINVESTMENTSECURITY (
+//      Z_PK INTEGER PRIMARY KEY,
+//      Z_ENT INTEGER,
+//      Z_OPT INTEGER,
+//      ZDATECURRENTPRICEYMD INTEGER,
+//      ZDATEYMD INTEGER,
+//      ZISSELECTED INTEGER,
+//      ZTYPE INTEGER,
+//      ZCURRENTPRICE DECIMAL,
+//      ZCURRENCYCODE VARCHAR,
+//      ZMEMO VARCHAR,
+//      ZNAME VARCHAR,
+//      ZSYMBOL VARCHAR,
+//      ZTICDSSYNCID VARCHAR
+//  );
+type InvestmentSecurity struct {
+	PrimaryKey       int64
+	Name             string
+	Symbol           string
+	SecurityType     int
+	CurrencyCode     string
+	CurrentPrice     float64
+	CurrentPriceDate time.Time
+	Memo             string
+}
+
+// InvestmentHolding represents a position in a single security within an investment account in
+// a MoneyWell document. A holding correlates 1:1 with a record in the ZINVESTMENTHOLDING table,
+// and references its security indirectly through the ZINVESTMENTSECURITYID table.
+//
+// The MoneyWell SQLite schema for the ZINVESTMENTHOLDING table is as follows:
+//  > .schema ZINVESTMENTHOLDING
+//  CREATE TABLE ZINVESTMENTHOLDING (
+//      Z_PK INTEGER PRIMARY KEY,
+//      Z_ENT INTEGER,
+//      Z_OPT INTEGER,
+//      ZUNITPRICEDATEYMD INTEGER,
+//      ZACCOUNT INTEGER,
+//      ZINVESTMENTSECURITYID INTEGER,
+//      ZMARKETVALUE DECIMAL,
+//      ZUNITPRICE DECIMAL,
+//      ZUNITS DECIMAL,
+//      ZMEMO VARCHAR,
+//      ZNAME VARCHAR,
+//      ZTICDSSYNCID VARCHAR
+//  );
+type InvestmentHolding struct {
+	PrimaryKey    int64
+	Account       int64
+	Security      int64
+	Name          string
+	Units         float64
+	UnitPrice     float64
+	UnitPriceDate time.Time
+	// MarketValue is the value of the holding as last recorded, at the unit price date.
+	MarketValue money.Money
+	Memo        string
+}
+
+// GetMarketValue computes the value of the holding at the given security's current price, in the
+// currency of the security. A security without a currency is assumed to be priced in the
+// currency of the holding's account.
+func (h InvestmentHolding) GetMarketValue(security InvestmentSecurity) money.Money {
+	currency := security.CurrencyCode
+	if currency == "" {
+		currency = h.MarketValue.Currency
+	}
+
+	return money.Money{
+		Currency: currency,
+		Amount:   int64(math.Round(h.Units * security.CurrentPrice * 100)),
+	}
+}
+
+// InvestmentTransaction represents the investment details of a transaction in a MoneyWell
+// document, recorded in the ZSHARES, ZSHAREPRICE, ZCOMMISSION and ZFEES columns of the ZACTIVITY
+// table alongside the transaction itself.
+type InvestmentTransaction struct {
+	Transaction int64
+	Security    int64
+	Shares      float64
+	SharePrice  float64
+	Commission  money.Money
+	Fees        money.Money
+}
+
+// GetInvestmentSecurities fetches the set of investment securities in a MoneyWell document,
+// sorted by symbol.
+func GetInvestmentSecurities(database Querier) ([]InvestmentSecurity, error) {
+	return GetInvestmentSecuritiesContext(context.Background(), database)
+}
+
+// GetInvestmentSecuritiesContext is like GetInvestmentSecurities, but runs within the given context.
+func GetInvestmentSecuritiesContext(ctx context.Context, database Querier) ([]InvestmentSecurity, error) {
+	rows, err := database.QueryContext(ctx, `
+            SELECT 
+                zis.Z_PK, 
+                zis.ZNAME,
+                zis.ZSYMBOL,
+                zis.ZTYPE,
+                zis.ZCURRENCYCODE,
+                zis.ZCURRENTPRICE,
+                zis.ZDATECURRENTPRICEYMD,
+                zis.ZMEMO
+            FROM 
+                ZINVESTMENTSECURITY zis
+            ORDER BY
+                zis.ZSYMBOL ASC,
+                zis.Z_PK ASC
+        `)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query investment securities")
+	}
+	defer rows.Close()
+
+	securities := []InvestmentSecurity{}
+
+	var primaryKey int64
+	var securityType, currentPriceDateymd sql.NullInt64
+	var currentPrice sql.NullFloat64
+	var name, symbol, currencyCode, memo sql.NullString
+	for rows.Next() {
+		err := rows.Scan(
+			&primaryKey,
+			&name,
+			&symbol,
+			&securityType,
+			&currencyCode,
+			&currentPrice,
+			&currentPriceDateymd,
+			&memo,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan investment security")
+		}
+
+		currentPriceDate, err := parseDateymd(int(currentPriceDateymd.Int64))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse investment security price date")
+		}
+
+		securities = append(securities, InvestmentSecurity{
+			PrimaryKey:       primaryKey,
+			Name:             name.String,
+			Symbol:           symbol.String,
+			SecurityType:     int(securityType.Int64),
+			CurrencyCode:     currencyCode.String,
+			CurrentPrice:     currentPrice.Float64,
+			CurrentPriceDate: currentPriceDate,
+			Memo:             memo.String,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read investment securities")
+	}
+
+	return securities, nil
+}
+
+// GetInvestmentSecuritiesMap gets a map from the investment security primary key to the
+// security.
+func GetInvestmentSecuritiesMap(database Querier) (map[int64]InvestmentSecurity, error) {
+	return GetInvestmentSecuritiesMapContext(context.Background(), database)
+}
+
+// GetInvestmentSecuritiesMapContext is like GetInvestmentSecuritiesMap, but runs within the given context.
+func GetInvestmentSecuritiesMapContext(ctx context.Context, database Querier) (map[int64]InvestmentSecurity, error) {
+	securities, err := GetInvestmentSecuritiesContext(ctx, database)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	securitiesMap := make(map[int64]InvestmentSecurity, len(securities))
+	for _, security := range securities {
+		securitiesMap[security.PrimaryKey] = security
+	}
+
+	return securitiesMap, nil
+}
+
+// GetInvestmentHoldings fetches the set of investment holdings in a MoneyWell document, sorted
+// by account and then by name.
+func GetInvestmentHoldings(database Querier) ([]InvestmentHolding, error) {
+	return GetInvestmentHoldingsContext(context.Background(), database)
+}
+
+// GetInvestmentHoldingsContext is like GetInvestmentHoldings, but runs within the given context.
+func GetInvestmentHoldingsContext(ctx context.Context, database Querier) ([]InvestmentHolding, error) {
+	rows, err := database.QueryContext(ctx, `
+            SELECT 
+                zih.Z_PK, 
+                zih.ZACCOUNT,
+                zisi.ZINVESTMENTSECURITY,
+                zih.ZNAME,
+                zih.ZUNITS,
+                zih.ZUNITPRICE,
+                zih.ZUNITPRICEDATEYMD,
+                CAST(ROUND(zih.ZMARKETVALUE * 100) AS INTEGER),
+                zih.ZMEMO,
+                zac.ZCURRENCYCODE
+            FROM 
+                ZINVESTMENTHOLDING zih
+            LEFT JOIN
+                ZINVESTMENTSECURITYID zisi ON ( zisi.Z_PK = zih.ZINVESTMENTSECURITYID )
+            LEFT JOIN
+                ZACCOUNT zac ON ( zac.Z_PK = zih.ZACCOUNT )
+            ORDER BY
+                zih.ZACCOUNT ASC,
+                zih.ZNAME ASC,
+                zih.Z_PK ASC
+        `)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query investment holdings")
+	}
+	defer rows.Close()
+
+	holdings := []InvestmentHolding{}
+
+	var primaryKey int64
+	var account, security, unitPriceDateymd, marketValueRaw sql.NullInt64
+	var units, unitPrice sql.NullFloat64
+	var name, memo, currencyCode sql.NullString
+	for rows.Next() {
+		err := rows.Scan(
+			&primaryKey,
+			&account,
+			&security,
+			&name,
+			&units,
+			&unitPrice,
+			&unitPriceDateymd,
+			&marketValueRaw,
+			&memo,
+			&currencyCode,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan investment holding")
+		}
+
+		unitPriceDate, err := parseDateymd(int(unitPriceDateymd.Int64))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse investment holding price date")
+		}
+
+		holdings = append(holdings, InvestmentHolding{
+			PrimaryKey:    primaryKey,
+			Account:       account.Int64,
+			Security:      security.Int64,
+			Name:          name.String,
+			Units:         units.Float64,
+			UnitPrice:     unitPrice.Float64,
+			UnitPriceDate: unitPriceDate,
+			MarketValue: money.Money{
+				Currency: currencyCode.String,
+				Amount:   marketValueRaw.Int64,
+			},
+			Memo: memo.String,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read investment holdings")
+	}
+
+	return holdings, nil
+}
+
+// GetInvestmentTransactions fetches the investment details of those transactions in a MoneyWell
+// document that buy, sell or otherwise involve a security, in the same order as GetTransactions.
+func GetInvestmentTransactions(database Querier) ([]InvestmentTransaction, error) {
+	return GetInvestmentTransactionsContext(context.Background(), database)
+}
+
+// GetInvestmentTransactionsContext is like GetInvestmentTransactions, but runs within the given context.
+func GetInvestmentTransactionsContext(ctx context.Context, database Querier) ([]InvestmentTransaction, error) {
+	rows, err := database.QueryContext(ctx, `
+            SELECT 
+                za.Z_PK, 
+                zisi.ZINVESTMENTSECURITY,
+                za.ZSHARES,
+                za.ZSHAREPRICE,
+                CAST(ROUND(za.ZCOMMISSION * 100) AS INTEGER),
+                CAST(ROUND(za.ZFEES * 100) AS INTEGER),
+                zac.ZCURRENCYCODE
+            FROM 
+                ZACTIVITY za
+            JOIN
+                ZACCOUNT zac ON (zac.Z_PK = za.ZACCOUNT2)
+            LEFT JOIN
+                ZACCOUNTGROUP zacg ON ( zacg.Z_PK = zac.ZACCOUNTGROUP )
+            LEFT JOIN
+                ZINVESTMENTSECURITYID zisi ON ( zisi.Z_PK = za.ZINVESTMENTSECURITYID )
+            WHERE
+                za.Z_ENT = ? AND
+                (
+                    za.ZINVESTMENTSECURITYID IS NOT NULL OR
+                    COALESCE(za.ZSHARES, 0) != 0
+                )
+            ORDER BY
+                za.ZDATEYMD ASC,
+                zacg.ZSEQUENCE ASC,
+                zac.ZSEQUENCE ASC,
+                za.ZTYPE ASC
+        `, ActivityTypeTransactions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query investment transactions")
+	}
+	defer rows.Close()
+
+	investmentTransactions := []InvestmentTransaction{}
+
+	var primaryKey int64
+	var security, commissionRaw, feesRaw sql.NullInt64
+	var shares, sharePrice sql.NullFloat64
+	var currencyCode sql.NullString
+	for rows.Next() {
+		err := rows.Scan(
+			&primaryKey,
+			&security,
+			&shares,
+			&sharePrice,
+			&commissionRaw,
+			&feesRaw,
+			&currencyCode,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan investment transaction")
+		}
+
+		investmentTransactions = append(investmentTransactions, InvestmentTransaction{
+			Transaction: primaryKey,
+			Security:    security.Int64,
+			Shares:      shares.Float64,
+			SharePrice:  sharePrice.Float64,
+			Commission: money.Money{
+				Currency: currencyCode.String,
+				Amount:   commissionRaw.Int64,
+			},
+			Fees: money.Money{
+				Currency: currencyCode.String,
+				Amount:   feesRaw.Int64,
+			},
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read investment transactions")
+	}
+
+	return investmentTransactions, nil
+}
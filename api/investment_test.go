@@ -0,0 +1,168 @@
+package api_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// copyInvestmentTestDocument copies the test document, which has no investments, adding two
+// securities held in the savings account and recording its initial balance as the purchase of
+// one of them, and opens the copy.
+func copyInvestmentTestDocument(t *testing.T) *sql.DB {
+	database := copyTestStore(t)
+
+	statements := []string{
+		`INSERT INTO ZINVESTMENTSECURITY (
+            Z_PK, Z_ENT, Z_OPT, ZDATECURRENTPRICEYMD, ZDATEYMD, ZISSELECTED, ZTYPE,
+            ZCURRENTPRICE, ZCURRENCYCODE, ZMEMO, ZNAME, ZSYMBOL
+        ) VALUES
+            (1, 15, 1, 20171130, 20171112, 0, 0, 31.42, 'CAD', NULL,
+                'Vanguard FTSE Canada All Cap Index ETF', 'VCN'),
+            (2, 15, 1, 20171130, 20171112, 0, 0, 25.105, 'CAD', NULL,
+                'iShares Core MSCI All Country World ex Canada Index ETF', 'XAW')`,
+		`INSERT INTO ZINVESTMENTSECURITYID (
+            Z_PK, Z_ENT, Z_OPT, ZINVESTMENTHOLDING, ZINVESTMENTSECURITY, ZUNIQUEID, ZUNIQUEIDTYPE
+        ) VALUES
+            (1, 16, 1, 1, 1, 'VCN', 'TICKER'),
+            (2, 16, 1, 2, 2, 'XAW', 'TICKER')`,
+		`INSERT INTO ZINVESTMENTHOLDING (
+            Z_PK, Z_ENT, Z_OPT, ZUNITPRICEDATEYMD, ZACCOUNT, ZINVESTMENTSECURITYID,
+            ZMARKETVALUE, ZUNITPRICE, ZUNITS, ZMEMO, ZNAME
+        ) VALUES
+            (1, 14, 1, 20171112, 2, 1, 98.5, 24.625, 4, NULL, 'VCN'),
+            (2, 14, 1, 20171112, 2, 2, 260.4, 24.8, 10.5, 'Quarterly contributions', 'XAW')`,
+		`UPDATE Z_PRIMARYKEY SET Z_MAX = 2 WHERE Z_ENT IN (14, 15, 16)`,
+	}
+	for _, statement := range statements {
+		_, err := database.Exec(statement)
+		assert.NoError(t, err)
+	}
+
+	_, err := database.Exec(`
+            UPDATE ZACTIVITY
+            SET Z_OPT = Z_OPT + 1, ZINVESTMENTSECURITYID = 1, ZSHARES = 4, ZSHAREPRICE = 24.625,
+                ZCOMMISSION = 1.5
+            WHERE Z_PK = 8
+        `)
+	assert.NoError(t, err)
+
+	return database
+}
+
+func TestGetInvestmentSecurities(t *testing.T) {
+	t.Parallel()
+
+	database := copyInvestmentTestDocument(t)
+	defer database.Close()
+
+	securities, err := api.GetInvestmentSecurities(database)
+	assert.NoError(t, err)
+
+	expectedSecurities := []api.InvestmentSecurity{
+		{
+			PrimaryKey:       1,
+			Name:             "Vanguard FTSE Canada All Cap Index ETF",
+			Symbol:           "VCN",
+			CurrencyCode:     "CAD",
+			CurrentPrice:     31.42,
+			CurrentPriceDate: time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			PrimaryKey:       2,
+			Name:             "iShares Core MSCI All Country World ex Canada Index ETF",
+			Symbol:           "XAW",
+			CurrencyCode:     "CAD",
+			CurrentPrice:     25.105,
+			CurrentPriceDate: time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	assert.Equal(t, expectedSecurities, securities)
+}
+
+func TestGetInvestmentHoldings(t *testing.T) {
+	t.Parallel()
+
+	database := copyInvestmentTestDocument(t)
+	defer database.Close()
+
+	holdings, err := api.GetInvestmentHoldings(database)
+	assert.NoError(t, err)
+
+	expectedHoldings := []api.InvestmentHolding{
+		{
+			PrimaryKey:    1,
+			Account:       2,
+			Security:      1,
+			Name:          "VCN",
+			Units:         4,
+			UnitPrice:     24.625,
+			UnitPriceDate: time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC),
+			MarketValue:   money.Money{Currency: "CAD", Amount: 9850},
+		},
+		{
+			PrimaryKey:    2,
+			Account:       2,
+			Security:      2,
+			Name:          "XAW",
+			Units:         10.5,
+			UnitPrice:     24.8,
+			UnitPriceDate: time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC),
+			MarketValue:   money.Money{Currency: "CAD", Amount: 26040},
+			Memo:          "Quarterly contributions",
+		},
+	}
+
+	assert.Equal(t, expectedHoldings, holdings)
+
+	securities, err := api.GetInvestmentSecuritiesMap(database)
+	assert.NoError(t, err)
+
+	marketValues := []money.Money{}
+	for _, holding := range holdings {
+		marketValues = append(marketValues, holding.GetMarketValue(securities[holding.Security]))
+	}
+
+	assert.Equal(t, []money.Money{
+		{Currency: "CAD", Amount: 12568},
+		{Currency: "CAD", Amount: 26360},
+	}, marketValues)
+
+	// A security priced in another currency is valued in that currency, not the account's.
+	security := securities[holdings[0].Security]
+	security.CurrencyCode = "USD"
+	assert.Equal(
+		t,
+		money.Money{Currency: "USD", Amount: 12568},
+		holdings[0].GetMarketValue(security),
+	)
+}
+
+func TestGetInvestmentTransactions(t *testing.T) {
+	t.Parallel()
+
+	database := copyInvestmentTestDocument(t)
+	defer database.Close()
+
+	investmentTransactions, err := api.GetInvestmentTransactions(database)
+	assert.NoError(t, err)
+
+	expectedInvestmentTransactions := []api.InvestmentTransaction{
+		{
+			Transaction: 8,
+			Security:    1,
+			Shares:      4,
+			SharePrice:  24.625,
+			Commission:  money.Money{Currency: "CAD", Amount: 150},
+			Fees:        money.Money{Currency: "CAD", Amount: 0},
+		},
+	}
+
+	assert.Equal(t, expectedInvestmentTransactions, investmentTransactions)
+}
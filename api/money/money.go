@@ -2,6 +2,10 @@ package money
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // Money represents an amount and currency in a MoneyWell document.
@@ -9,9 +13,12 @@ import (
 // It represents the amount as some number of cents, assuming 100 cents in a dollar, and is not
 // useful for currencies that do not fit this mould. Naively assumes all currencies are prefixed
 // with "$".
+//
+// When encoded as JSON, the amount is given as an integer number of cents alongside the currency,
+// e.g. {"currency":"CAD","amount":-1050}.
 type Money struct {
-	Currency string
-	Amount   int64
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
 }
 
 // IsZero determines if the value of the money is 0.
@@ -64,3 +71,68 @@ func (c Money) String() string {
 		return fmt.Sprintf("$%d.%02d%s", dollars, cents, currency)
 	}
 }
+
+// Allocate divides the Money into the given number of parts as evenly as possible, distributing
+// any remaining cents to the earliest parts so that the parts always sum to the original amount.
+func (c Money) Allocate(parts int) []Money {
+	if parts <= 0 {
+		return nil
+	}
+
+	base := c.Amount / int64(parts)
+	remainder := c.Amount - base*int64(parts)
+
+	var step int64 = 1
+	if remainder < 0 {
+		step = -1
+		remainder = -remainder
+	}
+
+	allocated := make([]Money, parts)
+	for i := range allocated {
+		allocated[i] = Money{Currency: c.Currency, Amount: base}
+		if int64(i) < remainder {
+			allocated[i].Amount += step
+		}
+	}
+
+	return allocated
+}
+
+var amountPattern = regexp.MustCompile(`^[-+]?(\d+(\.\d{1,2})?|\.\d{1,2})$`)
+
+// ParseAmount parses a decimal amount, such as -12.50, as some number of cents.
+//
+// The amount must consist of digits with an optional sign and at most two decimal places. Unlike
+// strconv.ParseFloat, exponents, NaN and infinities are rejected, and the amount is never rounded.
+func ParseAmount(amount string) (int64, error) {
+	if !amountPattern.MatchString(amount) {
+		return 0, fmt.Errorf("invalid amount %s", amount)
+	}
+
+	negative := strings.HasPrefix(amount, "-")
+	whole, fraction := strings.TrimLeft(amount, "+-"), ""
+	if i := strings.Index(whole, "."); i >= 0 {
+		whole, fraction = whole[:i], whole[i+1:]
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	dollars, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || dollars >= math.MaxInt64/100 {
+		return 0, fmt.Errorf("invalid amount %s", amount)
+	}
+
+	cents, err := strconv.ParseInt((fraction + "00")[:2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s", amount)
+	}
+
+	cents += dollars * 100
+	if negative {
+		cents = -cents
+	}
+
+	return cents, nil
+}
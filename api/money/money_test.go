@@ -1,6 +1,7 @@
 package money_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -338,3 +339,156 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestAllocate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Description    string
+		Money          money.Money
+		Parts          int
+		ExpectedMonies []money.Money
+	}{
+		{
+			"no parts",
+			money.Money{Currency: "CAD", Amount: 100},
+			0,
+			nil,
+		},
+		{
+			"single part",
+			money.Money{Currency: "CAD", Amount: 100},
+			1,
+			[]money.Money{{Currency: "CAD", Amount: 100}},
+		},
+		{
+			"evenly divisible",
+			money.Money{Currency: "CAD", Amount: 300},
+			3,
+			[]money.Money{
+				{Currency: "CAD", Amount: 100},
+				{Currency: "CAD", Amount: 100},
+				{Currency: "CAD", Amount: 100},
+			},
+		},
+		{
+			"remainder distributed to earliest parts",
+			money.Money{Currency: "CAD", Amount: 3000},
+			7,
+			[]money.Money{
+				{Currency: "CAD", Amount: 429},
+				{Currency: "CAD", Amount: 429},
+				{Currency: "CAD", Amount: 429},
+				{Currency: "CAD", Amount: 429},
+				{Currency: "CAD", Amount: 428},
+				{Currency: "CAD", Amount: 428},
+				{Currency: "CAD", Amount: 428},
+			},
+		},
+		{
+			"negative remainder distributed to earliest parts",
+			money.Money{Currency: "CAD", Amount: -100},
+			3,
+			[]money.Money{
+				{Currency: "CAD", Amount: -34},
+				{Currency: "CAD", Amount: -33},
+				{Currency: "CAD", Amount: -33},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.ExpectedMonies,
+				testCase.Money.Allocate(testCase.Parts),
+			)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Description    string
+		Amount         string
+		ExpectedAmount int64
+		ExpectedError  bool
+	}{
+		{"whole amount", "12", 1200, false},
+		{"decimal amount", "12.50", 1250, false},
+		{"single decimal place", "-12.5", -1250, false},
+		{"explicitly positive", "+0.05", 5, false},
+		{"no whole part", "-.50", -50, false},
+		{"empty amount", "", 0, true},
+		{"sign alone", "-", 0, true},
+		{"too many decimal places", "12.345", 0, true},
+		{"thousands separator", "1,000", 0, true},
+		{"exponent", "1e5", 0, true},
+		{"not a number", "NaN", 0, true},
+		{"infinity", "Inf", 0, true},
+		{"overflow", "99999999999999999999", 0, true},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Description, func(t *testing.T) {
+			t.Parallel()
+
+			amount, err := money.ParseAmount(testCase.Amount)
+			if testCase.ExpectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.ExpectedAmount, amount)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Description  string
+		Money        money.Money
+		ExpectedJSON string
+	}{
+		{
+			"default money",
+			money.Money{},
+			`{"currency":"","amount":0}`,
+		},
+		{
+			"positive amount",
+			money.Money{Currency: "CAD", Amount: 1050},
+			`{"currency":"CAD","amount":1050}`,
+		},
+		{
+			"negative amount",
+			money.Money{Currency: "USD", Amount: -35000},
+			`{"currency":"USD","amount":-35000}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Description, func(t *testing.T) {
+			t.Parallel()
+
+			encoded, err := json.Marshal(testCase.Money)
+			assert.NoError(t, err)
+			assert.JSONEq(t, testCase.ExpectedJSON, string(encoded))
+
+			var decoded money.Money
+			assert.NoError(t, json.Unmarshal(encoded, &decoded))
+			assert.Equal(t, testCase.Money, decoded)
+		})
+	}
+}
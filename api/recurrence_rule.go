@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -177,8 +179,13 @@ func parsePlistAsWeekdaysOfTheMonth(plistStr *string) (int64, int64, interface{}
 }
 
 // GetRecurrenceRules fetches the set of recurrence rules in a MoneyWell document.
-func GetRecurrenceRules(database *sql.DB) ([]RecurrenceRule, error) {
-	rows, err := database.Query(`
+func GetRecurrenceRules(database Querier) ([]RecurrenceRule, error) {
+	return GetRecurrenceRulesContext(context.Background(), database)
+}
+
+// GetRecurrenceRulesContext is like GetRecurrenceRules, but runs within the given context.
+func GetRecurrenceRulesContext(ctx context.Context, database Querier) ([]RecurrenceRule, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
 		zr.Z_PK,
 		zr.ZENDDATEYMD,
@@ -261,12 +268,21 @@ func GetRecurrenceRules(database *sql.DB) ([]RecurrenceRule, error) {
 		recurrenceRules = append(recurrenceRules, recurrenceRule)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read recurrence rules")
+	}
+
 	return recurrenceRules, nil
 }
 
 // GetRecurrenceRulesMap gets a map from the bucket primary key to the bucket.
-func GetRecurrenceRulesMap(database *sql.DB) (map[int64]RecurrenceRule, error) {
-	recurrenceRules, err := GetRecurrenceRules(database)
+func GetRecurrenceRulesMap(database Querier) (map[int64]RecurrenceRule, error) {
+	return GetRecurrenceRulesMapContext(context.Background(), database)
+}
+
+// GetRecurrenceRulesMapContext is like GetRecurrenceRulesMap, but runs within the given context.
+func GetRecurrenceRulesMapContext(ctx context.Context, database Querier) (map[int64]RecurrenceRule, error) {
+	recurrenceRules, err := GetRecurrenceRulesContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -504,13 +520,19 @@ func DescribeRecurrenceRule(recurrenceRule RecurrenceRule) string {
 }
 
 func DescribeFillRecurrenceRule(recurrenceRule RecurrenceRule) string {
-	if recurrenceRule.RecurrenceType == RecurrenceTypeDaily && recurrenceRule.RecurrenceInterval == 0 {
+	if isEveryEventDate(recurrenceRule) {
 		return "Every Event Date"
 	}
 
 	return DescribeRecurrenceRule(recurrenceRule)
 }
 
+// isEveryEventDate determines if the given fill recurrence rule fills the bucket on the date of
+// each spending plan event, as MoneyWell represents with a rule that otherwise never repeats.
+func isEveryEventDate(recurrenceRule RecurrenceRule) bool {
+	return recurrenceRule.RecurrenceType == RecurrenceTypeDaily && recurrenceRule.RecurrenceInterval == 0
+}
+
 func bToP(b bool) *bool {
 	return &b
 }
@@ -583,3 +605,285 @@ func (s RecurrenceRuleSort) Less(i, j int) bool {
 	return false
 }
 func (s RecurrenceRuleSort) Swap(i, j int) { s[j], s[i] = s[i], s[j] }
+
+// maxEmptyRecurrencePeriods bounds the search for the next occurrence of a recurrence rule whose
+// constraints are never satisfied, e.g. a yearly rule restricted to an invalid month.
+const maxEmptyRecurrencePeriods = 1000
+
+// Occurrences expands the recurrence rule anchored at the given start date, returning the dates
+// on which the rule fires between from and to inclusive, sorted in ascending order.
+//
+// Following the EventKit conventions from which MoneyWell borrows its recurrence rules, the start
+// date is always the first occurrence, even if it does not otherwise satisfy the rule, and both
+// OccurrenceCount and EndDate are measured from the start date. A daily rule without an interval
+// never repeats. Days of the month beyond the end of a shorter month are clamped to the last day
+// of that month.
+func (r RecurrenceRule) Occurrences(start, from, to time.Time) []time.Time {
+	from = truncateDate(from)
+	to = truncateDate(to)
+
+	occurrences := []time.Time{}
+	r.forEachOccurrence(start, func(occurrence time.Time) bool {
+		if occurrence.After(to) {
+			return false
+		}
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+
+		return true
+	})
+
+	return occurrences
+}
+
+// NextOccurrence finds the first date after the given date on which the recurrence rule anchored
+// at the given start date fires, returning false if the rule has already ended.
+func (r RecurrenceRule) NextOccurrence(start, after time.Time) (time.Time, bool) {
+	after = truncateDate(after)
+
+	var next time.Time
+	found := false
+	r.forEachOccurrence(start, func(occurrence time.Time) bool {
+		if occurrence.After(after) {
+			next = occurrence
+			found = true
+			return false
+		}
+
+		return true
+	})
+
+	return next, found
+}
+
+// forEachOccurrence invokes the given callback for each occurrence of the recurrence rule in
+// ascending order until the rule ends or the callback returns false.
+func (r RecurrenceRule) forEachOccurrence(start time.Time, callback func(time.Time) bool) {
+	start = truncateDate(start)
+	endDate := truncateDate(r.EndDate)
+
+	var count int64
+	emit := func(occurrence time.Time) bool {
+		if r.OccurrenceCount > 0 && count >= r.OccurrenceCount {
+			return false
+		}
+		if !r.EndDate.IsZero() && occurrence.After(endDate) {
+			return false
+		}
+		count++
+
+		return callback(occurrence)
+	}
+
+	if !emit(start) {
+		return
+	}
+
+	switch r.RecurrenceType {
+	case RecurrenceTypeDaily, RecurrenceTypeWeekly, RecurrenceTypeMonthly, RecurrenceTypeYearly:
+	default:
+		return
+	}
+
+	// A daily rule without an interval is MoneyWell's representation of "Never".
+	if r.RecurrenceType == RecurrenceTypeDaily && r.RecurrenceInterval <= 0 {
+		return
+	}
+
+	interval := int(r.RecurrenceInterval)
+	if interval < 1 {
+		interval = 1
+	}
+
+	emptyPeriods := 0
+	for period := 0; emptyPeriods < maxEmptyRecurrencePeriods; period += interval {
+		candidates := r.getPeriodCandidates(start, period)
+		if len(candidates) == 0 {
+			emptyPeriods++
+			continue
+		}
+		emptyPeriods = 0
+
+		for _, candidate := range candidates {
+			// The start date has already been emitted, and any candidates preceding it
+			// within the first period are not occurrences.
+			if !candidate.After(start) {
+				continue
+			}
+
+			if !emit(candidate) {
+				return
+			}
+		}
+	}
+}
+
+// getPeriodCandidates returns the sorted dates satisfying the recurrence rule within the period
+// offset from the start date by the given number of days, weeks, months or years.
+func (r RecurrenceRule) getPeriodCandidates(start time.Time, period int) []time.Time {
+	switch r.RecurrenceType {
+	case RecurrenceTypeDaily:
+		return []time.Time{start.AddDate(0, 0, period)}
+
+	case RecurrenceTypeWeekly:
+		firstDayOfTheWeek := r.FirstDayOfTheWeek
+		if firstDayOfTheWeek < DayOfTheWeekSunday || firstDayOfTheWeek > DayOfTheWeekSaturday {
+			firstDayOfTheWeek = DayOfTheWeekSunday
+		}
+
+		weekStart := start.AddDate(0, 0, -daysSinceDayOfTheWeek(start, firstDayOfTheWeek))
+		weekStart = weekStart.AddDate(0, 0, 7*period)
+
+		daysOfTheWeek := r.DaysOfTheWeek
+		if len(daysOfTheWeek) == 0 {
+			daysOfTheWeek = []int64{getDayOfTheWeek(start)}
+		}
+
+		candidates := []time.Time{}
+		for _, dayOfTheWeek := range daysOfTheWeek {
+			if dayOfTheWeek < DayOfTheWeekSunday || dayOfTheWeek > DayOfTheWeekSaturday {
+				continue
+			}
+
+			offset := (dayOfTheWeek - firstDayOfTheWeek + 7) % 7
+			candidates = append(candidates, weekStart.AddDate(0, 0, int(offset)))
+		}
+
+		return sortUniqueDates(candidates)
+
+	case RecurrenceTypeMonthly:
+		month := time.Date(start.Year(), start.Month()+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+
+		return r.getMonthCandidates(month.Year(), month.Month(), start.Day())
+
+	case RecurrenceTypeYearly:
+		year := start.Year() + period
+
+		monthsOfTheYear := r.MonthsOfTheYear
+		if len(monthsOfTheYear) == 0 {
+			monthsOfTheYear = []int64{int64(start.Month())}
+		}
+
+		candidates := []time.Time{}
+		for _, monthOfTheYear := range monthsOfTheYear {
+			if monthOfTheYear < 1 || monthOfTheYear > 12 {
+				continue
+			}
+
+			candidates = append(
+				candidates,
+				r.getMonthCandidates(year, time.Month(monthOfTheYear), start.Day())...,
+			)
+		}
+
+		return sortUniqueDates(candidates)
+	}
+
+	return nil
+}
+
+// getMonthCandidates returns the sorted dates satisfying the recurrence rule within the given
+// month, honouring first the "on the" constraint, then the days of the month, and otherwise
+// falling back to the given default day.
+func (r RecurrenceRule) getMonthCandidates(year int, month time.Month, defaultDay int) []time.Time {
+	if r.OnThe.DayOfTheWeek != DayOfTheWeekNone {
+		return r.OnThe.getMonthCandidates(year, month)
+	}
+
+	daysOfTheMonth := r.DaysOfTheMonth
+	if len(daysOfTheMonth) == 0 {
+		daysOfTheMonth = []int64{int64(defaultDay)}
+	}
+
+	lastDay := daysInMonth(year, month)
+
+	candidates := []time.Time{}
+	for _, dayOfTheMonth := range daysOfTheMonth {
+		day := int(dayOfTheMonth)
+		if day < 1 {
+			continue
+		}
+		if day > lastDay {
+			day = lastDay
+		}
+
+		candidates = append(candidates, time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+	}
+
+	return sortUniqueDates(candidates)
+}
+
+// getMonthCandidates returns the dates matching the week number and day of the week within the
+// given month, e.g. the 3rd week day or the last Friday.
+func (o RecurrenceRuleOnThe) getMonthCandidates(year int, month time.Month) []time.Time {
+	matches := []time.Time{}
+	for day := 1; day <= daysInMonth(year, month); day++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		dayOfTheWeek := getDayOfTheWeek(date)
+		isWeekend := dayOfTheWeek == DayOfTheWeekSunday || dayOfTheWeek == DayOfTheWeekSaturday
+
+		switch o.DayOfTheWeek {
+		case DayOfTheWeekDay:
+		case DayOfTheWeekWeekday:
+			if isWeekend {
+				continue
+			}
+		case DayOfTheWeekWeekendday:
+			if !isWeekend {
+				continue
+			}
+		default:
+			if dayOfTheWeek != o.DayOfTheWeek {
+				continue
+			}
+		}
+
+		matches = append(matches, date)
+	}
+
+	switch o.WeekNumber {
+	case WeekNumberNone:
+		return matches
+	case WeekNumberLast:
+		if len(matches) > 0 {
+			return matches[len(matches)-1:]
+		}
+	default:
+		if o.WeekNumber > 0 && int(o.WeekNumber) <= len(matches) {
+			return matches[o.WeekNumber-1 : o.WeekNumber]
+		}
+	}
+
+	return nil
+}
+
+// getDayOfTheWeek converts the weekday of the given date into MoneyWell's representation.
+func getDayOfTheWeek(date time.Time) int64 {
+	return int64(date.Weekday()) + DayOfTheWeekSunday
+}
+
+// daysSinceDayOfTheWeek counts the days elapsed since the most recent given day of the week.
+func daysSinceDayOfTheWeek(date time.Time, dayOfTheWeek int64) int {
+	return int((getDayOfTheWeek(date) - dayOfTheWeek + 7) % 7)
+}
+
+// daysInMonth counts the days in the given month.
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// sortUniqueDates sorts the given dates in ascending order, discarding duplicates.
+func sortUniqueDates(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	unique := dates[:0]
+	for _, date := range dates {
+		if len(unique) > 0 && date.Equal(unique[len(unique)-1]) {
+			continue
+		}
+		unique = append(unique, date)
+	}
+
+	return unique
+}
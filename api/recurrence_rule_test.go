@@ -679,3 +679,304 @@ func TestSortRecurrenceRules(t *testing.T) {
 	assert.Equal(t, expectedRecurrenceRuleDescriptions, sortedRecurrenceRuleDescriptions)
 
 }
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	spendingPlan, err := api.GetSpendingPlan(database)
+	assert.NoError(t, err)
+
+	recurrenceRules, err := api.GetRecurrenceRulesMap(database)
+	assert.NoError(t, err)
+
+	// Expected occurrences of each spending plan event's recurrence rule, anchored at the
+	// event date and expanded from the event date through the given window.
+	expectedOccurrences := map[int64]struct {
+		To          time.Time
+		Occurrences []time.Time
+	}{
+		// Never
+		21: {date(2018, 12, 31), []time.Time{date(2018, 4, 1)}},
+		// Every day
+		22: {date(2018, 4, 5), []time.Time{
+			date(2018, 4, 2), date(2018, 4, 3), date(2018, 4, 4), date(2018, 4, 5),
+		}},
+		// Every week
+		23: {date(2018, 4, 30), []time.Time{
+			date(2018, 4, 3), date(2018, 4, 10), date(2018, 4, 17), date(2018, 4, 24),
+		}},
+		// Every 3 months
+		24: {date(2019, 1, 11), []time.Time{
+			date(2018, 4, 12), date(2018, 7, 12), date(2018, 10, 12),
+		}},
+		// Every year
+		27: {date(2020, 4, 14), []time.Time{
+			date(2018, 4, 14), date(2019, 4, 14), date(2020, 4, 14),
+		}},
+		// Every 4 weeks
+		28: {date(2018, 6, 30), []time.Time{
+			date(2018, 4, 6), date(2018, 5, 4), date(2018, 6, 1), date(2018, 6, 29),
+		}},
+		// Every month on the 15th and 31st
+		29: {date(2018, 6, 30), []time.Time{
+			date(2018, 4, 8),
+			date(2018, 4, 15), date(2018, 4, 30),
+			date(2018, 5, 15), date(2018, 5, 31),
+			date(2018, 6, 15), date(2018, 6, 30),
+		}},
+		// Every 2 years
+		35: {date(2022, 12, 31), []time.Time{
+			date(2018, 4, 15), date(2020, 4, 15), date(2022, 4, 15),
+		}},
+		// Every day, ending after 11 times
+		36: {date(2018, 12, 31), []time.Time{
+			date(2018, 4, 29), date(2018, 4, 30), date(2018, 5, 1), date(2018, 5, 2),
+			date(2018, 5, 3), date(2018, 5, 4), date(2018, 5, 5), date(2018, 5, 6),
+			date(2018, 5, 7), date(2018, 5, 8), date(2018, 5, 9),
+		}},
+		// Every week, ending on 2018-06-02
+		37: {date(2018, 12, 31), []time.Time{
+			date(2018, 4, 30), date(2018, 5, 7), date(2018, 5, 14), date(2018, 5, 21),
+			date(2018, 5, 28),
+		}},
+		// Every week on Sunday, Monday, Wednesday and Friday
+		38: {date(2018, 5, 9), []time.Time{
+			date(2018, 4, 28), date(2018, 4, 29), date(2018, 4, 30), date(2018, 5, 2),
+			date(2018, 5, 4), date(2018, 5, 6), date(2018, 5, 7), date(2018, 5, 9),
+		}},
+		// Every week on Tuesday, Thursday and Saturday
+		39: {date(2018, 5, 10), []time.Time{
+			date(2018, 5, 1), date(2018, 5, 3), date(2018, 5, 5), date(2018, 5, 8),
+			date(2018, 5, 10),
+		}},
+		// Every month on the 2nd day
+		40: {date(2020, 8, 1), []time.Time{
+			date(2020, 5, 2), date(2020, 6, 2), date(2020, 7, 2),
+		}},
+		// Every month on the 3rd week day
+		41: {date(2020, 8, 31), []time.Time{
+			date(2020, 5, 3), date(2020, 5, 5), date(2020, 6, 3), date(2020, 7, 3),
+			date(2020, 8, 5),
+		}},
+		// Every 2 days
+		42: {date(2020, 5, 10), []time.Time{
+			date(2020, 5, 4), date(2020, 5, 6), date(2020, 5, 8), date(2020, 5, 10),
+		}},
+		// Every month on the 1st weekend day
+		43: {date(2020, 9, 30), []time.Time{
+			date(2020, 5, 5), date(2020, 6, 6), date(2020, 7, 4), date(2020, 8, 1),
+			date(2020, 9, 5),
+		}},
+	}
+
+	visited := []int64{}
+	for _, spendingPlanEvent := range spendingPlan {
+		expected, ok := expectedOccurrences[spendingPlanEvent.PrimaryKey]
+		if !ok {
+			continue
+		}
+		visited = append(visited, spendingPlanEvent.PrimaryKey)
+
+		spendingPlanEvent := spendingPlanEvent
+		t.Run(fmt.Sprintf("spending plan %d", spendingPlanEvent.PrimaryKey), func(t *testing.T) {
+			recurrenceRule := recurrenceRules[spendingPlanEvent.RecurrenceRule]
+
+			assert.Equal(
+				t,
+				expected.Occurrences,
+				recurrenceRule.Occurrences(
+					spendingPlanEvent.Date,
+					spendingPlanEvent.Date,
+					expected.To,
+				),
+			)
+		})
+	}
+
+	// Every expected spending plan event was found in the test document.
+	expectedKeys := []int64{}
+	for primaryKey := range expectedOccurrences {
+		expectedKeys = append(expectedKeys, primaryKey)
+	}
+	assert.ElementsMatch(t, expectedKeys, visited)
+}
+
+func TestRecurrenceRuleOccurrencesWindow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Description         string
+		RecurrenceRule      api.RecurrenceRule
+		Start               time.Time
+		From                time.Time
+		To                  time.Time
+		ExpectedOccurrences []time.Time
+	}{
+		{
+			"window after start",
+			api.RecurrenceRule{RecurrenceType: api.RecurrenceTypeMonthly, RecurrenceInterval: 1},
+			date(2018, 1, 10),
+			date(2018, 3, 1),
+			date(2018, 5, 10),
+			[]time.Time{date(2018, 3, 10), date(2018, 4, 10), date(2018, 5, 10)},
+		},
+		{
+			"window before start",
+			api.RecurrenceRule{RecurrenceType: api.RecurrenceTypeMonthly, RecurrenceInterval: 1},
+			date(2018, 1, 10),
+			date(2017, 1, 1),
+			date(2017, 12, 31),
+			[]time.Time{},
+		},
+		{
+			"occurrence count measured from start",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeDaily,
+				RecurrenceInterval: 1,
+				OccurrenceCount:    5,
+			},
+			date(2018, 1, 1),
+			date(2018, 1, 3),
+			date(2018, 1, 31),
+			[]time.Time{date(2018, 1, 3), date(2018, 1, 4), date(2018, 1, 5)},
+		},
+		{
+			"monthly from the 31st clamps to shorter months",
+			api.RecurrenceRule{RecurrenceType: api.RecurrenceTypeMonthly, RecurrenceInterval: 1},
+			date(2018, 1, 31),
+			date(2018, 1, 1),
+			date(2018, 4, 30),
+			[]time.Time{
+				date(2018, 1, 31), date(2018, 2, 28), date(2018, 3, 31), date(2018, 4, 30),
+			},
+		},
+		{
+			"every 2 weeks on Sunday and Monday, weeks starting Sunday",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeWeekly,
+				RecurrenceInterval: 2,
+				DaysOfTheWeek:      []int64{api.DayOfTheWeekSunday, api.DayOfTheWeekMonday},
+			},
+			date(2018, 1, 1),
+			date(2018, 1, 1),
+			date(2018, 1, 31),
+			[]time.Time{
+				date(2018, 1, 1), date(2018, 1, 14), date(2018, 1, 15), date(2018, 1, 28),
+				date(2018, 1, 29),
+			},
+		},
+		{
+			"every 2 weeks on Sunday and Monday, weeks starting Monday",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeWeekly,
+				RecurrenceInterval: 2,
+				FirstDayOfTheWeek:  api.DayOfTheWeekMonday,
+				DaysOfTheWeek:      []int64{api.DayOfTheWeekSunday, api.DayOfTheWeekMonday},
+			},
+			date(2018, 1, 1),
+			date(2018, 1, 1),
+			date(2018, 1, 31),
+			[]time.Time{
+				date(2018, 1, 1), date(2018, 1, 7), date(2018, 1, 15), date(2018, 1, 21),
+				date(2018, 1, 29),
+			},
+		},
+		{
+			"every month on the last Friday",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeMonthly,
+				RecurrenceInterval: 1,
+				OnThe: api.RecurrenceRuleOnThe{
+					DayOfTheWeek: api.DayOfTheWeekFriday,
+					WeekNumber:   api.WeekNumberLast,
+				},
+			},
+			date(2018, 1, 26),
+			date(2018, 1, 1),
+			date(2018, 3, 31),
+			[]time.Time{date(2018, 1, 26), date(2018, 2, 23), date(2018, 3, 30)},
+		},
+		{
+			"every year on the 4th Thursday of November",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeYearly,
+				RecurrenceInterval: 1,
+				MonthsOfTheYear:    []int64{11},
+				OnThe: api.RecurrenceRuleOnThe{
+					DayOfTheWeek: api.DayOfTheWeekThursday,
+					WeekNumber:   api.WeekNumberFourth,
+				},
+			},
+			date(2018, 11, 22),
+			date(2018, 1, 1),
+			date(2020, 12, 31),
+			[]time.Time{date(2018, 11, 22), date(2019, 11, 28), date(2020, 11, 26)},
+		},
+		{
+			"every year in January and July",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeYearly,
+				RecurrenceInterval: 1,
+				MonthsOfTheYear:    []int64{1, 7},
+			},
+			date(2018, 1, 15),
+			date(2018, 1, 1),
+			date(2019, 1, 31),
+			[]time.Time{date(2018, 1, 15), date(2018, 7, 15), date(2019, 1, 15)},
+		},
+		{
+			"ending on a date",
+			api.RecurrenceRule{
+				RecurrenceType:     api.RecurrenceTypeWeekly,
+				RecurrenceInterval: 1,
+				EndDate:            date(2018, 1, 15),
+			},
+			date(2018, 1, 1),
+			date(2018, 1, 1),
+			date(2018, 12, 31),
+			[]time.Time{date(2018, 1, 1), date(2018, 1, 8), date(2018, 1, 15)},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Description, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.ExpectedOccurrences,
+				testCase.RecurrenceRule.Occurrences(testCase.Start, testCase.From, testCase.To),
+			)
+		})
+	}
+}
+
+func TestRecurrenceRuleNextOccurrence(t *testing.T) {
+	t.Parallel()
+
+	recurrenceRule := api.RecurrenceRule{
+		RecurrenceType:     api.RecurrenceTypeMonthly,
+		RecurrenceInterval: 1,
+		OccurrenceCount:    3,
+	}
+
+	next, ok := recurrenceRule.NextOccurrence(date(2018, 1, 10), date(2017, 12, 31))
+	assert.True(t, ok)
+	assert.Equal(t, date(2018, 1, 10), next)
+
+	next, ok = recurrenceRule.NextOccurrence(date(2018, 1, 10), date(2018, 1, 10))
+	assert.True(t, ok)
+	assert.Equal(t, date(2018, 2, 10), next)
+
+	_, ok = recurrenceRule.NextOccurrence(date(2018, 1, 10), date(2018, 3, 10))
+	assert.False(t, ok)
+}
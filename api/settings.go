@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -28,8 +29,13 @@ type Settings struct {
 }
 
 // GetSettings fetches the settings in a MoneyWell document.
-func GetSettings(database *sql.DB) (Settings, error) {
-	row := database.QueryRow(`
+func GetSettings(database Querier) (Settings, error) {
+	return GetSettingsContext(context.Background(), database)
+}
+
+// GetSettingsContext is like GetSettings, but runs within the given context.
+func GetSettingsContext(ctx context.Context, database Querier) (Settings, error) {
+	row := database.QueryRowContext(ctx, `
             SELECT 
                 zs.Z_PK, 
                 zs.ZCASHFLOWSTARTDATEYMD,
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -96,9 +98,29 @@ type SpendingPlan struct {
 	FillRecurrenceRule int64
 }
 
+// SpendingPlanOccurrence represents a single dated occurrence of a spending plan event, or of a
+// fill of the corresponding bucket in anticipation of that event.
+type SpendingPlanOccurrence struct {
+	SpendingPlan int64
+	Type         int
+	Date         time.Time
+	Amount       money.Money
+	Bucket       int64
+}
+
+const (
+	SpendingPlanOccurrenceTypeEvent = 0
+	SpendingPlanOccurrenceTypeFill  = 1
+)
+
 // GetSpendingPlan fetches the set of spending plan events in a MoneyWell document.
-func GetSpendingPlan(database *sql.DB) ([]SpendingPlan, error) {
-	rows, err := database.Query(`
+func GetSpendingPlan(database Querier) ([]SpendingPlan, error) {
+	return GetSpendingPlanContext(context.Background(), database)
+}
+
+// GetSpendingPlanContext is like GetSpendingPlan, but runs within the given context.
+func GetSpendingPlanContext(ctx context.Context, database Querier) ([]SpendingPlan, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT
 		za.Z_PK,
 		za.ZDATEYMD,
@@ -165,5 +187,138 @@ func GetSpendingPlan(database *sql.DB) ([]SpendingPlan, error) {
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read spending plan")
+	}
+
 	return spendingPlan, nil
 }
+
+// GetSpendingPlanSchedule expands the given spending plan events by their recurrence rules into
+// the dated events and bucket fills falling between from and to inclusive, sorted by date.
+//
+// Only expense buckets are filled. A bucket filled on every event date receives the event amount
+// on that date. Otherwise, the event amount is divided evenly amongst the fills following the
+// previous event up to and including the event itself, carrying the amount forward to the next
+// fill if no fill precedes the event.
+func GetSpendingPlanSchedule(
+	spendingPlan []SpendingPlan,
+	recurrenceRules map[int64]RecurrenceRule,
+	buckets map[int64]Bucket,
+	from time.Time,
+	to time.Time,
+) []SpendingPlanOccurrence {
+	from = truncateDate(from)
+	to = truncateDate(to)
+
+	occurrences := []SpendingPlanOccurrence{}
+	for _, spendingPlanEvent := range spendingPlan {
+		recurrenceRule := recurrenceRules[spendingPlanEvent.RecurrenceRule]
+
+		eventDates := recurrenceRule.Occurrences(spendingPlanEvent.Date, from, to)
+		for _, eventDate := range eventDates {
+			occurrences = append(occurrences, SpendingPlanOccurrence{
+				SpendingPlan: spendingPlanEvent.PrimaryKey,
+				Type:         SpendingPlanOccurrenceTypeEvent,
+				Date:         eventDate,
+				Amount:       spendingPlanEvent.Amount,
+				Bucket:       spendingPlanEvent.Bucket,
+			})
+		}
+
+		if buckets[spendingPlanEvent.Bucket].Type != BucketTypeExpense {
+			continue
+		}
+
+		fillRecurrenceRule := recurrenceRules[spendingPlanEvent.FillRecurrenceRule]
+		if isEveryEventDate(fillRecurrenceRule) {
+			for _, eventDate := range eventDates {
+				occurrences = append(occurrences, SpendingPlanOccurrence{
+					SpendingPlan: spendingPlanEvent.PrimaryKey,
+					Type:         SpendingPlanOccurrenceTypeFill,
+					Date:         eventDate,
+					Amount:       spendingPlanEvent.Amount,
+					Bucket:       spendingPlanEvent.Bucket,
+				})
+			}
+
+			continue
+		}
+
+		for _, fill := range getSpendingPlanFills(spendingPlanEvent, recurrenceRule, fillRecurrenceRule, to) {
+			if fill.Date.Before(from) {
+				continue
+			}
+
+			occurrences = append(occurrences, fill)
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if !occurrences[i].Date.Equal(occurrences[j].Date) {
+			return occurrences[i].Date.Before(occurrences[j].Date)
+		}
+
+		// Fill the bucket before the event that depends on it.
+		return occurrences[i].Type > occurrences[j].Type
+	})
+
+	return occurrences
+}
+
+// getSpendingPlanFills computes the fills of a bucket on a schedule independent of the spending
+// plan event itself, from the first event through the given date.
+func getSpendingPlanFills(
+	spendingPlanEvent SpendingPlan,
+	recurrenceRule RecurrenceRule,
+	fillRecurrenceRule RecurrenceRule,
+	to time.Time,
+) []SpendingPlanOccurrence {
+	// Fills through the given date may be funding the first event thereafter.
+	eventDates := recurrenceRule.Occurrences(spendingPlanEvent.Date, spendingPlanEvent.Date, to)
+	if nextEventDate, ok := recurrenceRule.NextOccurrence(spendingPlanEvent.Date, to); ok {
+		eventDates = append(eventDates, nextEventDate)
+	}
+	if len(eventDates) == 0 {
+		return nil
+	}
+
+	fillDates := fillRecurrenceRule.Occurrences(
+		spendingPlanEvent.Date,
+		spendingPlanEvent.Date,
+		eventDates[len(eventDates)-1],
+	)
+
+	fills := []SpendingPlanOccurrence{}
+	carried := money.Money{}
+	for _, eventDate := range eventDates {
+		var windowFillDates []time.Time
+		for len(fillDates) > 0 && !fillDates[0].After(eventDate) {
+			windowFillDates = append(windowFillDates, fillDates[0])
+			fillDates = fillDates[1:]
+		}
+
+		amount := carried.Add(spendingPlanEvent.Amount)
+		if len(windowFillDates) == 0 {
+			carried = amount
+			continue
+		}
+		carried = money.Money{}
+
+		for i, fillAmount := range amount.Allocate(len(windowFillDates)) {
+			if windowFillDates[i].After(to) {
+				break
+			}
+
+			fills = append(fills, SpendingPlanOccurrence{
+				SpendingPlan: spendingPlanEvent.PrimaryKey,
+				Type:         SpendingPlanOccurrenceTypeFill,
+				Date:         windowFillDates[i],
+				Amount:       fillAmount,
+				Bucket:       spendingPlanEvent.Bucket,
+			})
+		}
+	}
+
+	return fills
+}
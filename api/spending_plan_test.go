@@ -353,3 +353,115 @@ func TestGetSpendingPlanFillRecurrenceRuleDescriptions(t *testing.T) {
 
 	assert.Equal(t, expectedDescriptions, descriptions)
 }
+
+func TestGetSpendingPlanSchedule(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	spendingPlan, err := api.GetSpendingPlan(database)
+	assert.NoError(t, err)
+
+	recurrenceRules, err := api.GetRecurrenceRulesMap(database)
+	assert.NoError(t, err)
+
+	buckets, err := api.GetBucketsMap(database)
+	assert.NoError(t, err)
+
+	filterSpendingPlan := func(primaryKeys ...int64) []api.SpendingPlan {
+		filtered := []api.SpendingPlan{}
+		for _, spendingPlanEvent := range spendingPlan {
+			for _, primaryKey := range primaryKeys {
+				if spendingPlanEvent.PrimaryKey == primaryKey {
+					filtered = append(filtered, spendingPlanEvent)
+				}
+			}
+		}
+
+		return filtered
+	}
+
+	occurrence := func(spendingPlan int64, occurrenceType int, date time.Time, amount int64, bucket int64) api.SpendingPlanOccurrence {
+		return api.SpendingPlanOccurrence{
+			SpendingPlan: spendingPlan,
+			Type:         occurrenceType,
+			Date:         date,
+			Amount:       money.Money{Currency: "CAD", Amount: amount},
+			Bucket:       bucket,
+		}
+	}
+
+	const event = api.SpendingPlanOccurrenceTypeEvent
+	const fill = api.SpendingPlanOccurrenceTypeFill
+
+	t.Run("filled every event date and every day", func(t *testing.T) {
+		schedule := api.GetSpendingPlanSchedule(
+			filterSpendingPlan(21, 22, 23),
+			recurrenceRules,
+			buckets,
+			date(2018, 4, 1),
+			date(2018, 4, 5),
+		)
+
+		assert.Equal(t, []api.SpendingPlanOccurrence{
+			occurrence(21, fill, date(2018, 4, 1), 1000, 13),
+			occurrence(21, event, date(2018, 4, 1), 1000, 13),
+			occurrence(22, fill, date(2018, 4, 2), 2000, 27),
+			occurrence(22, event, date(2018, 4, 2), 2000, 27),
+			occurrence(22, fill, date(2018, 4, 3), 2000, 27),
+			occurrence(23, fill, date(2018, 4, 3), 3000, 2),
+			occurrence(22, event, date(2018, 4, 3), 2000, 27),
+			occurrence(23, event, date(2018, 4, 3), 3000, 2),
+			occurrence(22, fill, date(2018, 4, 4), 2000, 27),
+			occurrence(23, fill, date(2018, 4, 4), 429, 2),
+			occurrence(22, event, date(2018, 4, 4), 2000, 27),
+			occurrence(22, fill, date(2018, 4, 5), 2000, 27),
+			occurrence(23, fill, date(2018, 4, 5), 429, 2),
+			occurrence(22, event, date(2018, 4, 5), 2000, 27),
+		}, schedule)
+	})
+
+	t.Run("filled less often than the event", func(t *testing.T) {
+		schedule := api.GetSpendingPlanSchedule(
+			filterSpendingPlan(24),
+			recurrenceRules,
+			buckets,
+			date(2018, 5, 1),
+			date(2019, 1, 31),
+		)
+
+		assert.Equal(t, []api.SpendingPlanOccurrence{
+			occurrence(24, fill, date(2018, 6, 12), 12000, 13),
+			occurrence(24, event, date(2018, 7, 12), 12000, 13),
+			occurrence(24, fill, date(2018, 8, 12), 6000, 13),
+			occurrence(24, fill, date(2018, 10, 12), 6000, 13),
+			occurrence(24, event, date(2018, 10, 12), 12000, 13),
+			occurrence(24, fill, date(2018, 12, 12), 12000, 13),
+			occurrence(24, event, date(2019, 1, 12), 12000, 13),
+		}, schedule)
+	})
+
+	t.Run("income is never filled", func(t *testing.T) {
+		schedule := api.GetSpendingPlanSchedule(
+			[]api.SpendingPlan{
+				{
+					PrimaryKey: 100,
+					Date:       date(2018, 4, 1),
+					Name:       "Salary",
+					Amount:     money.Money{Currency: "CAD", Amount: 100000},
+					Bucket:     3,
+				},
+			},
+			recurrenceRules,
+			buckets,
+			date(2018, 4, 1),
+			date(2018, 4, 30),
+		)
+
+		assert.Equal(t, []api.SpendingPlanOccurrence{
+			occurrence(100, event, date(2018, 4, 1), 100000, 3),
+		}, schedule)
+	})
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// Statement represents a bank statement against which an account is reconciled in a MoneyWell
+// document. A statement correlates 1:1 with a record in the ZSTATEMENT table.
+//
+// The MoneyWell SQLite schema for the ZSTATEMENT table is as follows:
+//  > .schema ZSTATEMENT
+//  CREATE TABLE ZSTATEMENT (
+//      Z_PK INTEGER PRIMARY KEY,
+//      Z_ENT INTEGER,
+//      Z_OPT INTEGER,
+//      ZENDINGDATEYMD INTEGER,
+//      ZISLOCKED INTEGER,
+//      ZISRECONCILED INTEGER,
+//      ZSTARTINGDATEYMD INTEGER,
+//      ZACCOUNT INTEGER,
+//      ZENDINGBALANCE DECIMAL,
+//      ZSTARTINGBALANCE DECIMAL,
+//      ZTICDSSYNCID VARCHAR,
+//      ZUNIQUEID VARCHAR
+//  );
+type Statement struct {
+	PrimaryKey      int64
+	Account         int64
+	StartingDate    time.Time
+	EndingDate      time.Time
+	StartingBalance money.Money
+	EndingBalance   money.Money
+	IsReconciled    bool
+	IsLocked        bool
+}
+
+// GetStatements fetches the set of statements in a MoneyWell document, sorted by account and then
+// by date.
+func GetStatements(database Querier) ([]Statement, error) {
+	return GetStatementsContext(context.Background(), database)
+}
+
+// GetStatementsContext is like GetStatements, but runs within the given context.
+func GetStatementsContext(ctx context.Context, database Querier) ([]Statement, error) {
+	rows, err := database.QueryContext(ctx, `
+            SELECT 
+                zs.Z_PK, 
+                zs.ZACCOUNT,
+                zs.ZSTARTINGDATEYMD,
+                zs.ZENDINGDATEYMD,
+                CAST(ROUND(zs.ZSTARTINGBALANCE * 100) AS INTEGER),
+                CAST(ROUND(zs.ZENDINGBALANCE * 100) AS INTEGER),
+                zs.ZISRECONCILED,
+                zs.ZISLOCKED,
+                za.ZCURRENCYCODE
+            FROM 
+                ZSTATEMENT zs
+            LEFT JOIN
+                ZACCOUNT za ON ( za.Z_PK = zs.ZACCOUNT )
+            ORDER BY
+                zs.ZACCOUNT ASC,
+                zs.ZSTARTINGDATEYMD ASC,
+                zs.ZENDINGDATEYMD ASC
+        `)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query statements")
+	}
+	defer rows.Close()
+
+	statements := []Statement{}
+
+	var primaryKey int64
+	var account, startingDateymd, endingDateymd sql.NullInt64
+	var startingBalanceRaw, endingBalanceRaw sql.NullInt64
+	var isReconciled, isLocked sql.NullBool
+	var currencyCode sql.NullString
+	for rows.Next() {
+		err := rows.Scan(
+			&primaryKey,
+			&account,
+			&startingDateymd,
+			&endingDateymd,
+			&startingBalanceRaw,
+			&endingBalanceRaw,
+			&isReconciled,
+			&isLocked,
+			&currencyCode,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan statement")
+		}
+
+		startingDate, err := parseDateymd(int(startingDateymd.Int64))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse statement starting date")
+		}
+
+		endingDate, err := parseDateymd(int(endingDateymd.Int64))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse statement ending date")
+		}
+
+		statements = append(statements, Statement{
+			PrimaryKey:   primaryKey,
+			Account:      account.Int64,
+			StartingDate: startingDate,
+			EndingDate:   endingDate,
+			StartingBalance: money.Money{
+				Currency: currencyCode.String,
+				Amount:   startingBalanceRaw.Int64,
+			},
+			EndingBalance: money.Money{
+				Currency: currencyCode.String,
+				Amount:   endingBalanceRaw.Int64,
+			},
+			IsReconciled: isReconciled.Bool,
+			IsLocked:     isLocked.Bool,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read statements")
+	}
+
+	return statements, nil
+}
+
+// GetReconciledBalance uses the given transactions to compute the balance of the statement's
+// account at the end of the statement, counting only the reconciled transactions dated within
+// the statement from its starting balance.
+func (s Statement) GetReconciledBalance(transactions []Transaction) money.Money {
+	balance := s.StartingBalance
+
+	for _, transaction := range transactions {
+		if transaction.Account != s.Account || transaction.SplitParent != 0 {
+			continue
+		}
+
+		if transaction.Status != TransactionStatusReconciled {
+			continue
+		}
+
+		if transaction.Date.Before(s.StartingDate) || transaction.Date.After(s.EndingDate) {
+			continue
+		}
+
+		balance = balance.Add(transaction.Amount)
+	}
+
+	return balance
+}
@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// copyTestStore copies the SQLite database within the test document to a temporary directory,
+// and opens the copy for writing.
+func copyTestStore(t *testing.T) *sql.DB {
+	original, err := ioutil.ReadFile("Test.moneywell/StoreContent/persistentStore")
+	assert.NoError(t, err)
+
+	persistentStorePath := filepath.Join(t.TempDir(), "persistentStore")
+	assert.NoError(t, ioutil.WriteFile(persistentStorePath, original, 0600))
+
+	database, err := sql.Open("sqlite3", persistentStorePath)
+	assert.NoError(t, err)
+
+	return database
+}
+
+// copyStatementTestDocument copies the test document, which has no statements, adding a
+// statement to each of the chequing, savings and cash accounts, and opens the copy. The
+// statement for the cash account is reconciled, but no longer balances.
+func copyStatementTestDocument(t *testing.T) *sql.DB {
+	database := copyTestStore(t)
+
+	_, err := database.Exec(`
+            INSERT INTO ZSTATEMENT (
+                Z_PK, Z_ENT, Z_OPT, ZENDINGDATEYMD, ZISLOCKED, ZISRECONCILED, ZSTARTINGDATEYMD,
+                ZACCOUNT, ZENDINGBALANCE, ZSTARTINGBALANCE, ZUNIQUEID
+            ) VALUES
+                (1, 23, 1, 20171130, 1, 1, 20171101, 1, 0, 0,
+                    '6F1B3D0C-1E5A-4C55-9A4E-2C0F1D7B8E01'),
+                (2, 23, 1, 20171130, 0, 1, 20171101, 3, 10, 0,
+                    '0C2B8F4E-7D19-4A63-B2C1-5E8A9F3D6C02'),
+                (3, 23, 1, 20171130, 0, 0, 20171101, 2, 5, 0,
+                    'A9E4C7B1-3F28-4D6A-8B05-7C1E2D9F4A03')
+        `)
+	assert.NoError(t, err)
+
+	_, err = database.Exec(`UPDATE Z_PRIMARYKEY SET Z_MAX = 3 WHERE Z_ENT = 23`)
+	assert.NoError(t, err)
+
+	return database
+}
+
+func TestGetStatements(t *testing.T) {
+	t.Parallel()
+
+	database := copyStatementTestDocument(t)
+	defer database.Close()
+
+	statements, err := api.GetStatements(database)
+	assert.NoError(t, err)
+
+	expectedStatements := []api.Statement{
+		{
+			PrimaryKey:      1,
+			Account:         1,
+			StartingDate:    time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC),
+			EndingDate:      time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+			StartingBalance: money.Money{Currency: "CAD", Amount: 0},
+			EndingBalance:   money.Money{Currency: "CAD", Amount: 0},
+			IsReconciled:    true,
+			IsLocked:        true,
+		},
+		{
+			PrimaryKey:      3,
+			Account:         2,
+			StartingDate:    time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC),
+			EndingDate:      time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+			StartingBalance: money.Money{Currency: "CAD", Amount: 0},
+			EndingBalance:   money.Money{Currency: "CAD", Amount: 5 * 100},
+			IsReconciled:    false,
+			IsLocked:        false,
+		},
+		{
+			PrimaryKey:      2,
+			Account:         3,
+			StartingDate:    time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC),
+			EndingDate:      time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+			StartingBalance: money.Money{Currency: "CAD", Amount: 0},
+			EndingBalance:   money.Money{Currency: "CAD", Amount: 10 * 100},
+			IsReconciled:    true,
+			IsLocked:        false,
+		},
+	}
+
+	assert.Equal(t, expectedStatements, statements)
+}
+
+func TestGetReconciledBalance(t *testing.T) {
+	t.Parallel()
+
+	database := copyStatementTestDocument(t)
+	defer database.Close()
+
+	statements, err := api.GetStatements(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	balances := []money.Money{}
+	for _, statement := range statements {
+		balances = append(balances, statement.GetReconciledBalance(transactions))
+	}
+
+	assert.Equal(t, []money.Money{
+		{Currency: "CAD", Amount: 0},
+		{Currency: "CAD", Amount: 100 * 100},
+		{Currency: "CAD", Amount: 0},
+	}, balances)
+}
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/pkg/errors"
 )
@@ -24,8 +24,13 @@ type Tag struct {
 }
 
 // GetTags fetches the set of tags in a MoneyWell document.
-func GetTags(database *sql.DB) ([]Tag, error) {
-	rows, err := database.Query(`
+func GetTags(database Querier) ([]Tag, error) {
+	return GetTagsContext(context.Background(), database)
+}
+
+// GetTagsContext is like GetTags, but runs within the given context.
+func GetTagsContext(ctx context.Context, database Querier) ([]Tag, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 zt.Z_PK, 
                 zt.ZNAME
@@ -55,12 +60,21 @@ func GetTags(database *sql.DB) ([]Tag, error) {
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read tags")
+	}
+
 	return tags, nil
 }
 
 // GetTagsMap gets a map from the tag primary key to the tag.
-func GetTagsMap(database *sql.DB) (map[int64]Tag, error) {
-	tags, err := GetTags(database)
+func GetTagsMap(database Querier) (map[int64]Tag, error) {
+	return GetTagsMapContext(context.Background(), database)
+}
+
+// GetTagsMapContext is like GetTagsMap, but runs within the given context.
+func GetTagsMapContext(ctx context.Context, database Querier) (map[int64]Tag, error) {
+	tags, err := GetTagsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -102,6 +103,10 @@ type Transaction struct {
 	Status           int
 	Payee            string
 	Memo             string
+	CheckNumber      string
+	UniqueID         string
+	// ExternalID identifies an imported transaction within the bank's own records, if known.
+	ExternalID string
 }
 
 const (
@@ -137,8 +142,49 @@ func (t *Transaction) GetBucket() int64 {
 
 // GetTransactions fetches the set of transactions in a MoneyWell document, sorted by the display
 // order as MoneyWell itself would render (and showing oldest to newest).
-func GetTransactions(database *sql.DB) ([]Transaction, error) {
-	rows, err := database.Query(`
+func GetTransactions(database Querier) ([]Transaction, error) {
+	return GetTransactionsContext(context.Background(), database)
+}
+
+// GetTransactionsContext is like GetTransactions, but runs within the given context.
+func GetTransactionsContext(ctx context.Context, database Querier) ([]Transaction, error) {
+	return QueryTransactions(ctx, database, TransactionQuery{})
+}
+
+// QueryTransactions fetches the transactions in a MoneyWell document matching the given query, in
+// the same order as GetTransactions.
+func QueryTransactions(
+	ctx context.Context,
+	database Querier,
+	query TransactionQuery,
+) ([]Transaction, error) {
+	transactions := []Transaction{}
+	err := ForEachTransaction(ctx, database, query, func(transaction Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return transactions, nil
+}
+
+// ForEachTransaction calls fn with each transaction in a MoneyWell document matching the given
+// query, in the same order as GetTransactions, without holding them all in memory. The query is
+// applied by SQL where possible, so only the matching transactions are read.
+//
+// If fn returns an error, iteration stops and that error is returned unchanged. Iteration also
+// stops once the context is done, returning the context's error.
+func ForEachTransaction(
+	ctx context.Context,
+	database Querier,
+	query TransactionQuery,
+	fn func(transaction Transaction) error,
+) error {
+	conditions, args := query.getConditions()
+
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 za.Z_PK, 
                 za.ZDATEYMD,
@@ -164,6 +210,9 @@ func GetTransactions(database *sql.DB) ([]Transaction, error) {
                 COALESCE(za.ZSTATUS, -1),
                 za.ZPAYEE,
                 za.ZMEMO,
+                za.ZCHECKREFSTRING,
+                za.ZUNIQUEID,
+                za.ZEXTERNALID,
                 zac.ZCURRENCYCODE
             FROM 
                 ZACTIVITY za
@@ -174,25 +223,23 @@ func GetTransactions(database *sql.DB) ([]Transaction, error) {
             LEFT JOIN
                 ZACTIVITY zat ON ( zat.Z_PK = za.ZTRANSFERSIBLING )
             WHERE
-                za.Z_ENT = ?
+                za.Z_ENT = ?`+conditions+`
             ORDER BY
                 za.ZDATEYMD ASC,
                 zacg.ZSEQUENCE ASC,
                 zac.ZSEQUENCE ASC,
                 za.ZTYPE ASC
-        `, ActivityTypeTransactions)
+        `, append([]interface{}{ActivityTypeTransactions}, args...)...)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to query transactions")
+		return errors.Wrap(err, "failed to query transactions")
 	}
 	defer rows.Close()
 
-	transactions := []Transaction{}
-
 	var primaryKey, amountRaw int64
 	var dateymd, transactionType, status int
 	var bucket, account, transferAccount, transferSibling, splitParent sql.NullInt64
 	var isSplit, isBucketOptional bool
-	var payee, memo, currencyCode sql.NullString
+	var payee, memo, checkNumber, uniqueID, externalID, currencyCode sql.NullString
 	for rows.Next() {
 		err := rows.Scan(
 			&primaryKey,
@@ -209,18 +256,21 @@ func GetTransactions(database *sql.DB) ([]Transaction, error) {
 			&status,
 			&payee,
 			&memo,
+			&checkNumber,
+			&uniqueID,
+			&externalID,
 			&currencyCode,
 		)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan transaction")
+			return errors.Wrap(err, "failed to scan transaction")
 		}
 
 		date, err := parseDateymd(dateymd)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse transaction date")
+			return errors.Wrap(err, "failed to parse transaction date")
 		}
 
-		transactions = append(transactions, Transaction{
+		transaction := Transaction{
 			PrimaryKey:      primaryKey,
 			Date:            date,
 			TransactionType: transactionType,
@@ -238,8 +288,22 @@ func GetTransactions(database *sql.DB) ([]Transaction, error) {
 			Status:           status,
 			Payee:            payee.String,
 			Memo:             memo.String,
-		})
+			CheckNumber:      checkNumber.String,
+			UniqueID:         uniqueID.String,
+			ExternalID:       externalID.String,
+		}
+		if !query.matches(transaction) {
+			continue
+		}
+
+		if err := fn(transaction); err != nil {
+			return err
+		}
 	}
 
-	return transactions, nil
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "failed to read transactions")
+	}
+
+	return nil
 }
@@ -0,0 +1,511 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// InsertTransaction inserts the given transaction into a MoneyWell document opened by
+// OpenWritableDocument, returning the transaction as inserted.
+//
+// The date, amount and account are required, and the status must be given explicitly, since the
+// zero value is TransactionStatusVoided. The amount defaults to the currency of the account. The
+// primary key and unique id are assigned, and the transaction type is inferred from the check
+// number and the sign of the amount.
+//
+// If a transfer account is given, the other side of the transfer is inserted against that account
+// for the negated amount, without a bucket, and the two are linked as transfer siblings. Use
+// InsertSplitTransaction to insert a split transaction.
+func InsertTransaction(database *sql.DB, transaction Transaction) (Transaction, error) {
+	if transaction.SplitParent != 0 {
+		return Transaction{}, errors.New("split children must be inserted with InsertSplitTransaction")
+	}
+
+	transaction.IsSplit = false
+	err := withTransaction(database, func(tx *sql.Tx) error {
+		var err error
+		transaction, err = insertTransaction(tx, transaction, 0)
+		return err
+	})
+	if err != nil {
+		return Transaction{}, errors.WithStack(err)
+	}
+
+	return transaction, nil
+}
+
+// InsertSplitTransaction inserts the given split transaction and its children into a MoneyWell
+// document opened by OpenWritableDocument, returning the transactions as inserted.
+//
+// The parent is inserted as per InsertTransaction, but may be neither assigned a bucket nor a
+// transfer. Each child is inserted against the account, date and status of the parent, and with
+// its payee unless given. The children must sum to the amount of the parent.
+func InsertSplitTransaction(
+	database *sql.DB,
+	parent Transaction,
+	children []Transaction,
+) (Transaction, []Transaction, error) {
+	if parent.SplitParent != 0 {
+		return Transaction{}, nil, errors.New("split parent cannot itself be a split child")
+	}
+	if parent.Bucket != 0 {
+		return Transaction{}, nil, errors.New("split parent cannot be assigned a bucket")
+	}
+	if parent.IsTransfer() {
+		return Transaction{}, nil, errors.New("split parent cannot be a transfer")
+	}
+	if len(children) == 0 {
+		return Transaction{}, nil, errors.New("split transaction must have children")
+	}
+
+	total := money.Money{Currency: parent.Amount.Currency}
+	for _, child := range children {
+		if child.Amount.Currency != "" && parent.Amount.Currency != "" &&
+			child.Amount.Currency != parent.Amount.Currency {
+			return Transaction{}, nil, errors.Errorf(
+				"split child in %s does not match split parent in %s",
+				child.Amount.Currency,
+				parent.Amount.Currency,
+			)
+		}
+		total.Amount += child.Amount.Amount
+	}
+	if total.Amount != parent.Amount.Amount {
+		return Transaction{}, nil, errors.Errorf(
+			"split children total %s but split parent is %s",
+			total,
+			parent.Amount,
+		)
+	}
+
+	parent.IsSplit = true
+	insertedChildren := make([]Transaction, 0, len(children))
+	err := withTransaction(database, func(tx *sql.Tx) error {
+		var err error
+		parent, err = insertTransaction(tx, parent, 0)
+		if err != nil {
+			return errors.Wrap(err, "failed to insert split parent")
+		}
+
+		for i, child := range children {
+			child.Date = parent.Date
+			child.Account = parent.Account
+			child.Status = parent.Status
+			child.SplitParent = parent.PrimaryKey
+			child.IsSplit = false
+			child.Amount.Currency = parent.Amount.Currency
+			if child.Payee == "" {
+				child.Payee = parent.Payee
+			}
+
+			child, err = insertTransaction(tx, child, i)
+			if err != nil {
+				return errors.Wrapf(err, "failed to insert split child %d", i)
+			}
+
+			insertedChildren = append(insertedChildren, child)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Transaction{}, nil, errors.WithStack(err)
+	}
+
+	return parent, insertedChildren, nil
+}
+
+// withTransaction runs the given function within a database transaction, committing only if it
+// succeeds.
+func withTransaction(database *sql.DB, f func(tx *sql.Tx) error) error {
+	tx, err := database.Begin()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin database transaction")
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return errors.WithStack(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit database transaction")
+	}
+
+	return nil
+}
+
+// insertTransaction validates and inserts the given transaction, and the other side of any
+// transfer, at the given index within its split parent, if any.
+func insertTransaction(tx *sql.Tx, transaction Transaction, splitIndex int) (Transaction, error) {
+	if transaction.Date.IsZero() {
+		return Transaction{}, errors.New("transaction date is required")
+	}
+	transaction.Date = truncateDate(transaction.Date)
+
+	switch transaction.Status {
+	case TransactionStatusVoided,
+		TransactionStatusReconciled,
+		TransactionStatusCleared,
+		TransactionStatusOpen,
+		TransactionStatusPending:
+	default:
+		return Transaction{}, errors.Errorf("invalid transaction status %d", transaction.Status)
+	}
+
+	currencyCode, err := getAccountCurrencyCode(tx, transaction.Account)
+	if err != nil {
+		return Transaction{}, errors.WithStack(err)
+	}
+
+	if transaction.Amount.Currency == "" {
+		transaction.Amount.Currency = currencyCode
+	} else if transaction.Amount.Currency != currencyCode {
+		return Transaction{}, errors.Errorf(
+			"transaction in %s does not match account %d in %s",
+			transaction.Amount.Currency,
+			transaction.Account,
+			currencyCode,
+		)
+	}
+
+	if transaction.Bucket != 0 {
+		var found bool
+		err := tx.QueryRow(`SELECT 1 FROM ZBUCKET WHERE Z_PK = ?`, transaction.Bucket).Scan(&found)
+		if err == sql.ErrNoRows {
+			return Transaction{}, errors.Errorf("failed to find bucket %d", transaction.Bucket)
+		} else if err != nil {
+			return Transaction{}, errors.Wrapf(err, "failed to query bucket %d", transaction.Bucket)
+		}
+	}
+
+	var sibling Transaction
+	if transaction.TransferAccount != 0 {
+		if transaction.TransferAccount == transaction.Account {
+			return Transaction{}, errors.New("transfer account must differ from account")
+		}
+
+		transferCurrencyCode, err := getAccountCurrencyCode(tx, transaction.TransferAccount)
+		if err != nil {
+			return Transaction{}, errors.WithStack(err)
+		}
+
+		// A transfer between currencies would need the amount on both sides.
+		if transferCurrencyCode != currencyCode {
+			return Transaction{}, errors.Errorf(
+				"transfers between %s and %s are not supported",
+				currencyCode,
+				transferCurrencyCode,
+			)
+		}
+
+		sibling = Transaction{
+			Date:             transaction.Date,
+			Amount:           transaction.Amount.Multiply(-1),
+			Account:          transaction.TransferAccount,
+			TransferAccount:  transaction.Account,
+			IsBucketOptional: transaction.IsBucketOptional,
+			Status:           transaction.Status,
+			Payee:            transaction.Payee,
+			Memo:             transaction.Memo,
+		}
+	}
+
+	count := 1
+	if sibling.Account != 0 {
+		count = 2
+	}
+
+	primaryKey, err := allocatePrimaryKeys(tx, count)
+	if err != nil {
+		return Transaction{}, errors.WithStack(err)
+	}
+
+	transaction.PrimaryKey = primaryKey
+	transaction.TransactionType = getTransactionType(transaction)
+	transaction.TransferSibling = 0
+	if transaction.UniqueID, err = newUniqueID(); err != nil {
+		return Transaction{}, errors.WithStack(err)
+	}
+
+	if sibling.Account != 0 {
+		sibling.PrimaryKey = primaryKey + 1
+		sibling.TransactionType = getTransactionType(sibling)
+		sibling.TransferSibling = transaction.PrimaryKey
+		transaction.TransferSibling = sibling.PrimaryKey
+		if sibling.UniqueID, err = newUniqueID(); err != nil {
+			return Transaction{}, errors.WithStack(err)
+		}
+	}
+
+	if err := insertActivity(tx, transaction, splitIndex); err != nil {
+		return Transaction{}, errors.WithStack(err)
+	}
+
+	if sibling.Account != 0 {
+		if err := insertActivity(tx, sibling, 0); err != nil {
+			return Transaction{}, errors.Wrap(err, "failed to insert transfer sibling")
+		}
+	}
+
+	return transaction, nil
+}
+
+// getAccountCurrencyCode returns the currency of the given account, failing if it doesn't exist.
+func getAccountCurrencyCode(tx *sql.Tx, account int64) (string, error) {
+	var currencyCode sql.NullString
+	err := tx.QueryRow(`SELECT ZCURRENCYCODE FROM ZACCOUNT WHERE Z_PK = ?`, account).Scan(
+		&currencyCode,
+	)
+	if err == sql.ErrNoRows {
+		return "", errors.Errorf("failed to find account %d", account)
+	} else if err != nil {
+		return "", errors.Wrapf(err, "failed to query account %d", account)
+	}
+
+	return currencyCode.String, nil
+}
+
+// allocatePrimaryKeys reserves the given number of consecutive primary keys for transactions,
+// returning the first.
+//
+// Core Data allocates primary keys from the counter in Z_PRIMARYKEY of the root entity, which
+// for transactions is that of all activities, and refuses to load a document in which the
+// counter trails the rows themselves.
+func allocatePrimaryKeys(tx *sql.Tx, count int) (int64, error) {
+	entity := int64(ActivityTypeTransactions)
+	for {
+		var super int64
+		err := tx.QueryRow(`SELECT Z_SUPER FROM Z_PRIMARYKEY WHERE Z_ENT = ?`, entity).Scan(&super)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to query entity %d", entity)
+		}
+
+		if super == 0 {
+			break
+		}
+		entity = super
+	}
+
+	var max, maxPrimaryKey int64
+	err := tx.QueryRow(`SELECT Z_MAX FROM Z_PRIMARYKEY WHERE Z_ENT = ?`, entity).Scan(&max)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to query primary key of entity %d", entity)
+	}
+
+	err = tx.QueryRow(`SELECT COALESCE(MAX(Z_PK), 0) FROM ZACTIVITY`).Scan(&maxPrimaryKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query activities")
+	}
+	if maxPrimaryKey > max {
+		max = maxPrimaryKey
+	}
+
+	_, err = tx.Exec(
+		`UPDATE Z_PRIMARYKEY SET Z_MAX = ? WHERE Z_ENT = ?`,
+		max+int64(count),
+		entity,
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to update primary key of entity %d", entity)
+	}
+
+	return max + 1, nil
+}
+
+// getTransactionType infers the type of the given transaction from its check number and the sign
+// of its amount.
+func getTransactionType(transaction Transaction) int {
+	if transaction.CheckNumber != "" || transaction.TransactionType == TransactionTypeCheck {
+		return TransactionTypeCheck
+	}
+
+	if transaction.Amount.Amount < 0 {
+		return TransactionTypeWithdrawal
+	}
+
+	return TransactionTypeDeposit
+}
+
+// newUniqueID generates a random, uppercase UUID as MoneyWell itself uses to identify entities.
+func newUniqueID() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", errors.Wrap(err, "failed to generate unique id")
+	}
+
+	// Mark the UUID as version 4, variant 1.
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%X-%X-%X-%X-%X", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+// formatAmountString formats the given amount as MoneyWell caches it alongside the amount
+// itself, e.g. "-1234.50 -1234.5 -1,234.50".
+func formatAmountString(amount money.Money) string {
+	cents := amount.Amount
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	dollars := strconv.FormatInt(cents/100, 10)
+	decimal := fmt.Sprintf("%s%s.%02d", sign, dollars, cents%100)
+
+	compact := strconv.FormatFloat(float64(amount.Amount)/100, 'f', -1, 64)
+
+	groups := []string{}
+	for len(dollars) > 3 {
+		groups = append([]string{dollars[len(dollars)-3:]}, groups...)
+		dollars = dollars[:len(dollars)-3]
+	}
+	groups = append([]string{dollars}, groups...)
+	localized := fmt.Sprintf("%s%s.%02d", sign, strings.Join(groups, ","), cents%100)
+
+	return fmt.Sprintf("%s %s %s", decimal, compact, localized)
+}
+
+// insertActivity inserts a single row into ZACTIVITY for the given, already validated
+// transaction.
+func insertActivity(tx *sql.Tx, transaction Transaction, splitIndex int) error {
+	var sequence int64
+	err := tx.QueryRow(`
+            SELECT
+                COALESCE(MAX(ZSEQUENCE), 0) + 1
+            FROM
+                ZACTIVITY
+            WHERE
+                Z_ENT = ? AND ZACCOUNT2 = ? AND ZDATEYMD = ?
+        `,
+		ActivityTypeTransactions,
+		transaction.Account,
+		formatDateymd(transaction.Date),
+	).Scan(&sequence)
+	if err != nil {
+		return errors.Wrap(err, "failed to query transaction sequence")
+	}
+
+	dateReconciled := 0
+	if transaction.Status == TransactionStatusReconciled {
+		dateReconciled = formatDateymd(transaction.Date)
+	}
+
+	checkReference, _ := strconv.ParseInt(transaction.CheckNumber, 10, 64)
+
+	nullInt64 := func(value int64) sql.NullInt64 {
+		return sql.NullInt64{Int64: value, Valid: value != 0}
+	}
+	nullEntity := func(value int64) sql.NullInt64 {
+		return sql.NullInt64{Int64: ActivityTypeTransactions, Valid: value != 0}
+	}
+	nullString := func(value string) sql.NullString {
+		return sql.NullString{String: value, Valid: value != ""}
+	}
+
+	amount := float64(transaction.Amount.Amount) / 100
+	amountString := formatAmountString(transaction.Amount)
+
+	// A split parent records the total of its children, which must match its own amount.
+	var splitTotal float64
+	if transaction.IsSplit {
+		splitTotal = amount
+	}
+
+	_, err = tx.Exec(`
+            INSERT INTO ZACTIVITY (
+                Z_PK,
+                Z_ENT,
+                Z_OPT,
+                ZDATEYMD,
+                ZISBUCKETOPTIONAL,
+                ZISFLAGGED,
+                ZISLOCKED,
+                ZSEQUENCE,
+                ZSOURCE,
+                ZTYPE,
+                ZSPLITPARENT,
+                Z3_SPLITPARENT,
+                ZTRANSFERSIBLING,
+                Z3_TRANSFERSIBLING,
+                ZDATERECONCILEDYMD,
+                ZISLASTIMPORT,
+                ZISQUARANTINED,
+                ZISREPEATING,
+                ZSPLITINDEX,
+                ZSTATUS,
+                ZACCOUNT2,
+                ZBUCKET2,
+                ZAMOUNT,
+                ZCHECKREF,
+                ZCOMMISSION,
+                ZFEES,
+                ZLOCALIZEDAMOUNT1,
+                ZSALEAMOUNT1,
+                ZSHAREPRICE,
+                ZSHARES,
+                ZSHARESSPLITDENOMINATOR,
+                ZSHARESSPLITNUMERATOR,
+                ZSPLITTOTAL,
+                ZTAXES,
+                ZAMOUNTSTRING,
+                ZCHECKREFSTRING,
+                ZMEMO,
+                ZPAYEE,
+                ZUNIQUEID,
+                ZEXTERNALID,
+                ZLOCALIZEDAMOUNTSTRING,
+                ZSALEAMOUNTSTRING,
+                ZSALECURRENCYCODE1
+            ) VALUES (
+                ?, ?, 1, ?, ?, 0, 0, ?, 1, ?,
+                ?, ?, ?, ?, ?, 0, 0, 0, ?, ?,
+                ?, ?, ?, ?, 0, 0, ?, ?, 0, 0,
+                1, 1, ?, 0, ?, ?, ?, ?, ?, ?,
+                ?, ?, ?
+            )
+        `,
+		transaction.PrimaryKey,
+		ActivityTypeTransactions,
+		formatDateymd(transaction.Date),
+		transaction.IsBucketOptional,
+		sequence,
+		transaction.TransactionType,
+		nullInt64(transaction.SplitParent),
+		nullEntity(transaction.SplitParent),
+		nullInt64(transaction.TransferSibling),
+		nullEntity(transaction.TransferSibling),
+		dateReconciled,
+		splitIndex,
+		transaction.Status,
+		transaction.Account,
+		nullInt64(transaction.Bucket),
+		amount,
+		checkReference,
+		amount,
+		amount,
+		splitTotal,
+		amountString,
+		nullString(transaction.CheckNumber),
+		transaction.Memo,
+		transaction.Payee,
+		transaction.UniqueID,
+		nullString(transaction.ExternalID),
+		amountString,
+		amountString,
+		transaction.Amount.Currency,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to insert transaction %d", transaction.PrimaryKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package api_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// copyTestDocument copies the test document into a temporary directory, returning the path to
+// the copy.
+func copyTestDocument(t *testing.T) string {
+	moneywellPath := filepath.Join(t.TempDir(), "Test.moneywell")
+	assert.NoError(t, api.CopyDocument("Test.moneywell", moneywellPath))
+
+	return moneywellPath
+}
+
+func TestInsertTransaction(t *testing.T) {
+	t.Parallel()
+
+	moneywellPath := copyTestDocument(t)
+	database, err := api.OpenWritableDocument(moneywellPath)
+	assert.NoError(t, err)
+	defer database.Close()
+
+	date := time.Date(2017, 11, 25, 0, 0, 0, 0, time.UTC)
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+
+	t.Run("simple", func(t *testing.T) {
+		transaction, err := api.InsertTransaction(database, api.Transaction{
+			Date:        date,
+			Amount:      money.Money{Amount: -4250},
+			Account:     1,
+			Bucket:      13,
+			Status:      api.TransactionStatusCleared,
+			Payee:       "Grocery Store",
+			Memo:        "Receipt",
+			CheckNumber: "1043",
+			ExternalID:  "BANK-1",
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 44, transaction.PrimaryKey)
+		assert.Len(t, transaction.UniqueID, 36)
+
+		transactions, err := api.GetTransactions(database)
+		assert.NoError(t, err)
+		assert.Contains(t, transactions, api.Transaction{
+			PrimaryKey:      44,
+			Date:            date,
+			TransactionType: api.TransactionTypeCheck,
+			Amount:          cad(-4250),
+			Bucket:          13,
+			Account:         1,
+			Status:          api.TransactionStatusCleared,
+			Payee:           "Grocery Store",
+			Memo:            "Receipt",
+			CheckNumber:     "1043",
+			UniqueID:        transaction.UniqueID,
+			ExternalID:      "BANK-1",
+		})
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		transaction, err := api.InsertTransaction(database, api.Transaction{
+			Date:            date,
+			Amount:          cad(-2000),
+			Account:         1,
+			TransferAccount: 2,
+			Status:          api.TransactionStatusOpen,
+			Payee:           "Withdrawal",
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 45, transaction.PrimaryKey)
+		assert.EqualValues(t, 46, transaction.TransferSibling)
+
+		transactions, err := api.GetTransactions(database)
+		assert.NoError(t, err)
+
+		found := 0
+		for _, other := range transactions {
+			switch other.PrimaryKey {
+			case 45:
+				found++
+				assert.EqualValues(t, 2, other.TransferAccount)
+				assert.EqualValues(t, 46, other.TransferSibling)
+				assert.Equal(t, api.TransactionTypeWithdrawal, other.TransactionType)
+			case 46:
+				found++
+				assert.Equal(t, cad(2000), other.Amount)
+				assert.EqualValues(t, 2, other.Account)
+				assert.EqualValues(t, 1, other.TransferAccount)
+				assert.EqualValues(t, 45, other.TransferSibling)
+				assert.EqualValues(t, 0, other.Bucket)
+				assert.Equal(t, api.TransactionTypeDeposit, other.TransactionType)
+			}
+		}
+		assert.Equal(t, 2, found)
+	})
+
+	t.Run("split", func(t *testing.T) {
+		parent, children, err := api.InsertSplitTransaction(
+			database,
+			api.Transaction{
+				Date:    date,
+				Amount:  cad(-10000),
+				Account: 1,
+				Status:  api.TransactionStatusOpen,
+				Payee:   "Department Store",
+			},
+			[]api.Transaction{
+				{Amount: cad(-7500), Bucket: 13},
+				{Amount: cad(-2500), Bucket: 27, Memo: "Gift"},
+			},
+		)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 47, parent.PrimaryKey)
+		assert.True(t, parent.IsSplit)
+		assert.Len(t, children, 2)
+
+		transactions, err := api.GetTransactions(database)
+		assert.NoError(t, err)
+
+		splitChildren := []api.Transaction{}
+		for _, transaction := range transactions {
+			if transaction.PrimaryKey == parent.PrimaryKey {
+				assert.True(t, transaction.IsSplit)
+			}
+			if transaction.SplitParent == parent.PrimaryKey {
+				splitChildren = append(splitChildren, transaction)
+			}
+		}
+		assert.Len(t, splitChildren, 2)
+		for _, child := range splitChildren {
+			assert.Equal(t, "Department Store", child.Payee)
+			assert.EqualValues(t, 1, child.Account)
+			assert.Equal(t, date, child.Date)
+		}
+
+		// Only the split parent records the total of its children.
+		getSplitTotal := func(primaryKey int64) float64 {
+			var splitTotal float64
+			err := database.QueryRow(
+				`SELECT ZSPLITTOTAL FROM ZACTIVITY WHERE Z_PK = ?`,
+				primaryKey,
+			).Scan(&splitTotal)
+			assert.NoError(t, err)
+
+			return splitTotal
+		}
+		assert.Equal(t, -100.0, getSplitTotal(parent.PrimaryKey))
+		for _, child := range children {
+			assert.Equal(t, 0.0, getSplitTotal(child.PrimaryKey))
+		}
+
+		_, _, err = api.InsertSplitTransaction(
+			database,
+			api.Transaction{Date: date, Amount: cad(-10000), Account: 1},
+			[]api.Transaction{{Amount: cad(-7500), Bucket: 13}},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := api.InsertTransaction(database, api.Transaction{
+			Date:    date,
+			Amount:  cad(-100),
+			Account: 99,
+			Status:  api.TransactionStatusOpen,
+		})
+		assert.Error(t, err)
+
+		_, err = api.InsertTransaction(database, api.Transaction{
+			Date:    date,
+			Amount:  money.Money{Currency: "USD", Amount: -100},
+			Account: 1,
+			Status:  api.TransactionStatusOpen,
+		})
+		assert.Error(t, err)
+
+		_, err = api.InsertTransaction(database, api.Transaction{
+			Date:    date,
+			Amount:  cad(-100),
+			Account: 1,
+			Bucket:  999,
+			Status:  api.TransactionStatusOpen,
+		})
+		assert.Error(t, err)
+
+		_, err = api.InsertTransaction(database, api.Transaction{
+			Amount:  cad(-100),
+			Account: 1,
+			Status:  api.TransactionStatusOpen,
+		})
+		assert.Error(t, err)
+	})
+
+	var max int64
+	err = database.QueryRow(`SELECT Z_MAX FROM Z_PRIMARYKEY WHERE Z_NAME = 'Activity'`).Scan(&max)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 49, max)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TransactionQuery selects the transactions visited by ForEachTransaction. A transaction must
+// match every condition given, and the zero value matches every transaction.
+type TransactionQuery struct {
+	// From and To are the first and last dates to include, if not zero.
+	From time.Time
+	To   time.Time
+	// MinAmount and MaxAmount are the least and greatest amounts, in cents, to include, if not
+	// nil. Withdrawals are negative, so MaxAmount of -10000 matches withdrawals of $100 or more.
+	MinAmount *int64
+	MaxAmount *int64
+	// Payee and Memo are substrings, ignoring case, of the payee and memo to include, if not
+	// empty. SQLite only ignores the case of ASCII letters, so these are instead applied to each
+	// transaction as it is read.
+	Payee string
+	Memo  string
+	// PayeePattern and MemoPattern are regular expressions matching the payee and memo to
+	// include, if not nil. SQLite has no regular expressions, so these too are applied to each
+	// transaction as it is read.
+	PayeePattern *regexp.Regexp
+	MemoPattern  *regexp.Regexp
+	// Statuses are the statuses, such as TransactionStatusCleared, to include, if any.
+	Statuses []int
+	// Accounts and Buckets are the primary keys of the accounts and buckets to include, if any.
+	Accounts []int64
+	Buckets  []int64
+	// Tags are the primary keys of the tags to include, if any. A transaction with any one of
+	// the tags is included.
+	Tags []int64
+	// Transfer includes only transfers if true, or only transactions other than transfers if
+	// false, unless nil.
+	Transfer *bool
+	// Split includes only split parents if true, or only transactions other than split parents
+	// if false, unless nil. Split children are not themselves split.
+	Split *bool
+	// Uncategorized includes only transactions against accounts in the cash flow that are
+	// assigned no bucket. Transfers and split parents are never uncategorized.
+	Uncategorized bool
+}
+
+// The SQL expressions for the columns of a transaction, as selected by ForEachTransaction.
+const (
+	transactionAmountColumn   = "CAST(ROUND(za.ZAMOUNT * 100) AS INTEGER)"
+	transactionBucketColumn   = "COALESCE(za.ZBUCKET, za.ZBUCKET1, za.ZBUCKET2)"
+	transactionAccountColumn  = "COALESCE(za.ZACCOUNT, za.ZACCOUNT1, za.ZACCOUNT2)"
+	transactionSiblingColumn  = "COALESCE(za.ZTRANSFERSIBLING, za.Z3_TRANSFERSIBLING)"
+	transactionStatusColumn   = "COALESCE(za.ZSTATUS, -1)"
+	transactionIsSplitColumn  = "EXISTS (SELECT 1 FROM ZACTIVITY za2 WHERE za2.ZSPLITPARENT = za.Z_PK)"
+	transactionTaggedSubquery = "SELECT zt.Z_3ACTIVITIES FROM Z_3TAGS zt WHERE zt.Z_24TAGS IN "
+)
+
+// getConditions returns the SQL conditions, and their arguments, matching the query. Each
+// condition is prefixed with AND, for appending to an existing WHERE clause.
+func (q TransactionQuery) getConditions() (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if !q.From.IsZero() {
+		conditions = append(conditions, "za.ZDATEYMD >= ?")
+		args = append(args, formatDateymd(q.From))
+	}
+
+	if !q.To.IsZero() {
+		conditions = append(conditions, "za.ZDATEYMD <= ?")
+		args = append(args, formatDateymd(q.To))
+	}
+
+	if q.MinAmount != nil {
+		conditions = append(conditions, transactionAmountColumn+" >= ?")
+		args = append(args, *q.MinAmount)
+	}
+
+	if q.MaxAmount != nil {
+		conditions = append(conditions, transactionAmountColumn+" <= ?")
+		args = append(args, *q.MaxAmount)
+	}
+
+	if len(q.Statuses) > 0 {
+		conditions = append(
+			conditions,
+			transactionStatusColumn+" IN "+getPlaceholders(len(q.Statuses)),
+		)
+		for _, status := range q.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	if len(q.Accounts) > 0 {
+		conditions = append(
+			conditions,
+			transactionAccountColumn+" IN "+getPlaceholders(len(q.Accounts)),
+		)
+		for _, account := range q.Accounts {
+			args = append(args, account)
+		}
+	}
+
+	if len(q.Buckets) > 0 {
+		conditions = append(
+			conditions,
+			transactionBucketColumn+" IN "+getPlaceholders(len(q.Buckets)),
+		)
+		for _, bucket := range q.Buckets {
+			args = append(args, bucket)
+		}
+	}
+
+	if len(q.Tags) > 0 {
+		conditions = append(
+			conditions,
+			"za.Z_PK IN ("+transactionTaggedSubquery+getPlaceholders(len(q.Tags))+")",
+		)
+		for _, tag := range q.Tags {
+			args = append(args, tag)
+		}
+	}
+
+	if q.Transfer != nil {
+		if *q.Transfer {
+			conditions = append(conditions, transactionSiblingColumn+" IS NOT NULL")
+		} else {
+			conditions = append(conditions, transactionSiblingColumn+" IS NULL")
+		}
+	}
+
+	if q.Split != nil {
+		if *q.Split {
+			conditions = append(conditions, transactionIsSplitColumn)
+		} else {
+			conditions = append(conditions, "NOT "+transactionIsSplitColumn)
+		}
+	}
+
+	if q.Uncategorized {
+		conditions = append(
+			conditions,
+			transactionBucketColumn+" IS NULL",
+			transactionSiblingColumn+" IS NULL",
+			"NOT "+transactionIsSplitColumn,
+			"zac.ZINCLUDEINCASHFLOW = 1",
+		)
+	}
+
+	where := ""
+	for _, condition := range conditions {
+		where += "\n                AND " + condition
+	}
+
+	return where, args
+}
+
+// matches determines if the given transaction, already selected by the conditions of the query,
+// also matches those conditions applied only as transactions are read.
+func (q TransactionQuery) matches(transaction Transaction) bool {
+	if q.Payee != "" && !containsFold(transaction.Payee, q.Payee) {
+		return false
+	}
+
+	if q.Memo != "" && !containsFold(transaction.Memo, q.Memo) {
+		return false
+	}
+
+	if q.PayeePattern != nil && !q.PayeePattern.MatchString(transaction.Payee) {
+		return false
+	}
+
+	if q.MemoPattern != nil && !q.MemoPattern.MatchString(transaction.Memo) {
+		return false
+	}
+
+	return true
+}
+
+// containsFold determines if the given substring is within the given string, ignoring case.
+func containsFold(s, substring string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substring))
+}
+
+// getPlaceholders returns a parenthesized list of the given number of SQL placeholders.
+func getPlaceholders(count int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", count), ", ") + ")"
+}
@@ -0,0 +1,163 @@
+package api_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+func queryTransactions(t *testing.T, database *sql.DB, query api.TransactionQuery) []int64 {
+	t.Helper()
+
+	transactions := []api.Transaction{}
+	err := api.ForEachTransaction(
+		context.Background(),
+		database,
+		query,
+		func(transaction api.Transaction) error {
+			transactions = append(transactions, transaction)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+
+	return getPrimaryKeys(transactions)
+}
+
+func TestTransactionQuery(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	yes := true
+	no := false
+	amount := func(amount int64) *int64 {
+		return &amount
+	}
+
+	testCases := []struct {
+		Description  string
+		Query        api.TransactionQuery
+		Transactions []int64
+	}{
+		{
+			"exact amount",
+			api.TransactionQuery{MinAmount: amount(-10000), MaxAmount: amount(-10000)},
+			[]int64{13, 20, 18},
+		},
+		{
+			"minimum amount",
+			api.TransactionQuery{MinAmount: amount(10000)},
+			[]int64{2, 15, 8},
+		},
+		{
+			"payee substring, ignoring case",
+			api.TransactionQuery{Payee: "split"},
+			[]int64{15, 13, 14, 16},
+		},
+		{
+			"memo substring, ignoring case",
+			api.TransactionQuery{Memo: "TRANSACTION"},
+			[]int64{20, 18},
+		},
+		{
+			"payee pattern",
+			api.TransactionQuery{PayeePattern: regexp.MustCompile(`^(Rent|Work)$`)},
+			[]int64{2, 5},
+		},
+		{
+			"memo pattern",
+			api.TransactionQuery{MemoPattern: regexp.MustCompile(`(?i)^chick`)},
+			[]int64{4},
+		},
+		{
+			"statuses",
+			api.TransactionQuery{
+				Statuses: []int{api.TransactionStatusVoided, api.TransactionStatusPending},
+			},
+			[]int64{20, 18},
+		},
+		{
+			"any tag",
+			api.TransactionQuery{Tags: []int64{1, 3}},
+			[]int64{2, 5},
+		},
+		{
+			"transfers",
+			api.TransactionQuery{Transfer: &yes},
+			[]int64{15, 16},
+		},
+		{
+			"split parents",
+			api.TransactionQuery{Split: &yes},
+			[]int64{14},
+		},
+		{
+			"uncategorized",
+			api.TransactionQuery{Uncategorized: true},
+			[]int64{1, 11, 12, 10, 9},
+		},
+		{
+			"combined",
+			api.TransactionQuery{
+				From:     time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC),
+				Accounts: []int64{1},
+				Payee:    "Split",
+				Transfer: &no,
+				Split:    &no,
+			},
+			[]int64{13},
+		},
+		{
+			"nothing",
+			api.TransactionQuery{Payee: "Nobody"},
+			[]int64{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Description, func(t *testing.T) {
+			assert.Equal(t, testCase.Transactions, queryTransactions(t, database, testCase.Query))
+		})
+	}
+}
+
+func TestTransactionQueryUnicode(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenWritableDocument(copyTestDocument(t))
+	assert.NoError(t, err)
+	defer database.Close()
+
+	transaction, err := api.InsertTransaction(database, api.Transaction{
+		Date:    time.Date(2017, 11, 25, 0, 0, 0, 0, time.UTC),
+		Amount:  money.Money{Amount: -450},
+		Account: 1,
+		Bucket:  13,
+		Status:  api.TransactionStatusCleared,
+		Payee:   "Café Élise",
+		Memo:    "ÉPICERIE",
+	})
+	assert.NoError(t, err)
+
+	// Case is ignored beyond ASCII letters.
+	assert.Equal(t,
+		[]int64{transaction.PrimaryKey},
+		queryTransactions(t, database, api.TransactionQuery{Payee: "CAFÉ ÉLISE"}),
+	)
+	assert.Equal(t,
+		[]int64{transaction.PrimaryKey},
+		queryTransactions(t, database, api.TransactionQuery{Memo: "épicerie"}),
+	)
+}
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"database/sql"
+	"context"
 
 	"github.com/pkg/errors"
 )
@@ -21,8 +21,13 @@ type TransactionTag struct {
 }
 
 // GetTransactionTags fetches the set of transaction tags in a MoneyWell document.
-func GetTransactionTags(database *sql.DB) ([]TransactionTag, error) {
-	rows, err := database.Query(`
+func GetTransactionTags(database Querier) ([]TransactionTag, error) {
+	return GetTransactionTagsContext(context.Background(), database)
+}
+
+// GetTransactionTagsContext is like GetTransactionTags, but runs within the given context.
+func GetTransactionTagsContext(ctx context.Context, database Querier) ([]TransactionTag, error) {
+	rows, err := database.QueryContext(ctx, `
             SELECT 
                 zt.Z_3ACTIVITIES,
                 zt.Z_24TAGS
@@ -52,12 +57,21 @@ func GetTransactionTags(database *sql.DB) ([]TransactionTag, error) {
 		})
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read transaction tags")
+	}
+
 	return transactionTags, nil
 }
 
 // GetTransactionTagMap fetches a map from transaction to a set of tags.
-func GetTransactionTagMap(database *sql.DB) (map[int64][]int64, error) {
-	transactionTags, err := GetTransactionTags(database)
+func GetTransactionTagMap(database Querier) (map[int64][]int64, error) {
+	return GetTransactionTagMapContext(context.Background(), database)
+}
+
+// GetTransactionTagMapContext is like GetTransactionTagMap, but runs within the given context.
+func GetTransactionTagMapContext(ctx context.Context, database Querier) (map[int64][]int64, error) {
+	transactionTags, err := GetTransactionTagsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -74,8 +88,13 @@ func GetTransactionTagMap(database *sql.DB) (map[int64][]int64, error) {
 }
 
 // GetTagTransactionMap fetches a map from tag to a set of transactions.
-func GetTagTransactionMap(database *sql.DB) (map[int64][]int64, error) {
-	transactionTags, err := GetTransactionTags(database)
+func GetTagTransactionMap(database Querier) (map[int64][]int64, error) {
+	return GetTagTransactionMapContext(context.Background(), database)
+}
+
+// GetTagTransactionMapContext is like GetTagTransactionMap, but runs within the given context.
+func GetTagTransactionMapContext(ctx context.Context, database Querier) (map[int64][]int64, error) {
+	transactionTags, err := GetTransactionTagsContext(ctx, database)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -1,9 +1,11 @@
 package api_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lieut-data/go-moneywell/api"
@@ -36,6 +38,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusReconciled,
 			Payee:            "Initial Balance",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "E1B13D32-32B2-4D24-807E-C68CF1429FBA",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       2,
@@ -52,6 +57,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusCleared,
 			Payee:            "Work",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "324E2646-5C05-43B1-BC0A-33BC46941CE9",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       11,
@@ -68,6 +76,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusReconciled,
 			Payee:            "Initial Balance",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "2F343D0B-A78A-4A04-AFE5-7786A52F9897",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       4,
@@ -84,6 +95,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusOpen,
 			Payee:            "Grocery Store",
 			Memo:             "Chick peas and tuna.",
+			CheckNumber:      "",
+			UniqueID:         "659DBBAC-1741-45E5-851C-087772FD9200",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       5,
@@ -100,6 +114,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusOpen,
 			Payee:            "Rent",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "38B5327A-AD15-41D6-84C4-B0AE4154362A",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       15,
@@ -116,6 +133,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusOpen,
 			Payee:            "Split Test",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "BF00368C-F01E-40F6-9A83-245DA9D8FE45",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       12,
@@ -132,6 +152,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusReconciled,
 			Payee:            "Initial Balance",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "73EFD25C-6B08-4D92-9D30-CA2493AC1F09",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       13,
@@ -148,6 +171,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusOpen,
 			Payee:            "Split Test",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "B6636E3C-1022-4304-870D-E22965758A57",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       14,
@@ -164,6 +190,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusOpen,
 			Payee:            "Split Test",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "E894FBEB-C5D6-48E3-A27C-F3E3E1368739",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       16,
@@ -180,6 +209,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusOpen,
 			Payee:            "Split Test",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "CD1CBC78-E88F-49E4-9522-AE008459EAFC",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       8,
@@ -196,6 +228,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusReconciled,
 			Payee:            "Initial Balance",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "FADF3313-216E-4F06-AD53-A60FC2C84892",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       10,
@@ -212,6 +247,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusReconciled,
 			Payee:            "Initial Balance",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "F13AB622-C385-4144-B3D9-8E7995D2CC9B",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       9,
@@ -228,6 +266,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusReconciled,
 			Payee:            "Initial Balance",
 			Memo:             "",
+			CheckNumber:      "",
+			UniqueID:         "947B399A-DD7A-464B-9BA8-2D27C9F14883",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       20,
@@ -244,6 +285,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusVoided,
 			Payee:            "Voided",
 			Memo:             "Voided transaction.",
+			CheckNumber:      "",
+			UniqueID:         "2F08AB8C-2DEF-4E4F-AA68-268AFD526DC0",
+			ExternalID:       "",
 		},
 		{
 			PrimaryKey:       18,
@@ -260,6 +304,9 @@ func TestGetTransactions(t *testing.T) {
 			Status:           api.TransactionStatusPending,
 			Payee:            "Future",
 			Memo:             "Future transaction.",
+			CheckNumber:      "",
+			UniqueID:         "11B011DD-9AE7-4F27-9415-04A0408DAE20",
+			ExternalID:       "",
 		},
 	}
 
@@ -289,3 +336,101 @@ func TestIsTrnasfer(t *testing.T) {
 
 	assert.Equal(t, expectedTransferTransactions, actualTransferTransactions)
 }
+
+func TestForEachTransaction(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	getTransactions := func(t *testing.T, query api.TransactionQuery) []int64 {
+		t.Helper()
+
+		transactions := []api.Transaction{}
+		err := api.ForEachTransaction(
+			context.Background(),
+			database,
+			query,
+			func(transaction api.Transaction) error {
+				transactions = append(transactions, transaction)
+				return nil
+			},
+		)
+		assert.NoError(t, err)
+
+		return getPrimaryKeys(transactions)
+	}
+
+	t.Run("all", func(t *testing.T) {
+		transactions, err := api.GetTransactions(database)
+		assert.NoError(t, err)
+
+		assert.Equal(t, getPrimaryKeys(transactions), getTransactions(t, api.TransactionQuery{}))
+	})
+
+	t.Run("dates", func(t *testing.T) {
+		assert.Equal(t, []int64{11, 4, 5}, getTransactions(t, api.TransactionQuery{
+			From: time.Date(2017, 11, 5, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2017, 11, 10, 0, 0, 0, 0, time.UTC),
+		}))
+		assert.Equal(t, []int64{18}, getTransactions(t, api.TransactionQuery{
+			From: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		}))
+		assert.Equal(t, []int64{1, 2}, getTransactions(t, api.TransactionQuery{
+			To: time.Date(2017, 11, 4, 0, 0, 0, 0, time.UTC),
+		}))
+	})
+
+	t.Run("accounts and buckets", func(t *testing.T) {
+		assert.Equal(t, []int64{11, 15, 12}, getTransactions(t, api.TransactionQuery{
+			Accounts: []int64{3, 4},
+		}))
+		assert.Equal(t, []int64{2, 4, 13, 20, 18}, getTransactions(t, api.TransactionQuery{
+			Buckets: []int64{3, 13},
+		}))
+		assert.Equal(t, []int64{2, 20}, getTransactions(t, api.TransactionQuery{
+			To:       time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC),
+			Accounts: []int64{1},
+			Buckets:  []int64{3},
+		}))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		errStop := errors.New("stop")
+
+		count := 0
+		err := api.ForEachTransaction(
+			context.Background(),
+			database,
+			api.TransactionQuery{},
+			func(transaction api.Transaction) error {
+				count++
+				if count == 3 {
+					return errStop
+				}
+				return nil
+			},
+		)
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := api.ForEachTransaction(
+			ctx,
+			database,
+			api.TransactionQuery{},
+			func(transaction api.Transaction) error {
+				return nil
+			},
+		)
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+
+		_, err = api.GetTransactionsContext(ctx, database)
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// SetTransactionBucket assigns the given transaction to the given bucket, or to no bucket if
+// zero, in a MoneyWell document opened by OpenWritableDocument.
+func SetTransactionBucket(database *sql.DB, transaction int64, bucket int64) error {
+	err := withTransaction(database, func(tx *sql.Tx) error {
+		if bucket != 0 {
+			var found bool
+			err := tx.QueryRow(`SELECT 1 FROM ZBUCKET WHERE Z_PK = ?`, bucket).Scan(&found)
+			if err == sql.ErrNoRows {
+				return errors.Errorf("failed to find bucket %d", bucket)
+			} else if err != nil {
+				return errors.Wrapf(err, "failed to query bucket %d", bucket)
+			}
+		}
+
+		return updateActivity(
+			tx,
+			transaction,
+			`ZBUCKET = NULL, ZBUCKET1 = NULL, ZBUCKET2 = ?`,
+			sql.NullInt64{Int64: bucket, Valid: bucket != 0},
+		)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set bucket of transaction %d", transaction)
+	}
+
+	return nil
+}
+
+// SetTransactionBucketOptional marks the given transaction as bucket optional, or not, in a
+// MoneyWell document opened by OpenWritableDocument.
+func SetTransactionBucketOptional(
+	database *sql.DB,
+	transaction int64,
+	isBucketOptional bool,
+) error {
+	err := withTransaction(database, func(tx *sql.Tx) error {
+		return updateActivity(tx, transaction, `ZISBUCKETOPTIONAL = ?`, isBucketOptional)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set bucket optional of transaction %d", transaction)
+	}
+
+	return nil
+}
+
+// updateActivity applies the given assignments to the given transaction, bumping the version
+// Core Data uses to detect conflicting changes.
+func updateActivity(tx *sql.Tx, transaction int64, assignments string, args ...interface{}) error {
+	args = append(args, transaction, ActivityTypeTransactions)
+	result, err := tx.Exec(`
+            UPDATE
+                ZACTIVITY
+            SET
+                `+assignments+`,
+                Z_OPT = COALESCE(Z_OPT, 0) + 1
+            WHERE
+                Z_PK = ? AND Z_ENT = ?
+        `, args...)
+	if err != nil {
+		return errors.Wrap(err, "failed to update transaction")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "failed to update transaction")
+	}
+	if rowsAffected == 0 {
+		return errors.Errorf("failed to find transaction %d", transaction)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+func TestUpdateTransaction(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenWritableDocument(copyTestDocument(t))
+	assert.NoError(t, err)
+	defer database.Close()
+
+	getTransaction := func(primaryKey int64) api.Transaction {
+		transactions, err := api.GetTransactions(database)
+		assert.NoError(t, err)
+
+		for _, transaction := range transactions {
+			if transaction.PrimaryKey == primaryKey {
+				return transaction
+			}
+		}
+
+		t.Fatalf("failed to find transaction %d", primaryKey)
+		return api.Transaction{}
+	}
+
+	getVersion := func(primaryKey int64) int64 {
+		var version int64
+		err := database.QueryRow(`SELECT Z_OPT FROM ZACTIVITY WHERE Z_PK = ?`, primaryKey).Scan(
+			&version,
+		)
+		assert.NoError(t, err)
+
+		return version
+	}
+
+	version := getVersion(4)
+	assert.EqualValues(t, 13, getTransaction(4).Bucket)
+
+	assert.NoError(t, api.SetTransactionBucket(database, 4, 0))
+	assert.EqualValues(t, 0, getTransaction(4).Bucket)
+	assert.Equal(t, version+1, getVersion(4))
+
+	assert.NoError(t, api.SetTransactionBucket(database, 4, 27))
+	assert.EqualValues(t, 27, getTransaction(4).Bucket)
+
+	assert.Error(t, api.SetTransactionBucket(database, 4, 999))
+	assert.EqualValues(t, 27, getTransaction(4).Bucket)
+
+	assert.NoError(t, api.SetTransactionBucketOptional(database, 4, true))
+	assert.True(t, getTransaction(4).IsBucketOptional)
+	assert.NoError(t, api.SetTransactionBucketOptional(database, 4, false))
+	assert.False(t, getTransaction(4).IsBucketOptional)
+
+	assert.Error(t, api.SetTransactionBucket(database, 999, 0))
+	assert.Error(t, api.SetTransactionBucketOptional(database, 999, false))
+}
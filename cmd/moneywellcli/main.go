@@ -3,8 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"time"
 
 	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
 	"github.com/lieut-data/go-moneywell/internal/cli"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -12,13 +14,28 @@ import (
 
 func main() {
 	var verbose bool
-	var moneywellPath, list, tag, bucket, account string
+	var months int
+	var moneywellPath, list, report, export, checkImport, importConfig, format, tag, bucket, account, from, to, date, interval string
+	var payee, minAmount, maxAmount string
 	flag.BoolVar(&verbose, "verbose", false, "be more verbose")
 	flag.StringVar(&moneywellPath, "file", "", "the path to the MoneyWell document")
 	flag.StringVar(&list, "list", "", "list the given entity")
+	flag.StringVar(&report, "report", "", "run the given report")
+	flag.StringVar(&export, "export", "", "export the document in the given format: ledger, beancount, qif or ofx")
+	flag.StringVar(&checkImport, "check-import", "", "the bank statement (CSV) to match against an account")
+	flag.StringVar(&importConfig, "import-config", "", "the JSON file describing the bank statement columns")
+	flag.StringVar(&format, "format", cli.FormatTable, "the output format: table, json or csv")
 	flag.StringVar(&account, "account", "", "the bucket by which to filter transactions")
 	flag.StringVar(&bucket, "bucket", "", "the bucket by which to filter transactions")
 	flag.StringVar(&tag, "tag", "", "the tag by which to filter transactions")
+	flag.StringVar(&payee, "payee", "", "the text within the payee by which to filter transactions, ignoring case")
+	flag.StringVar(&minAmount, "min", "", "the least amount (e.g. -100.00) by which to filter transactions")
+	flag.StringVar(&maxAmount, "max", "", "the greatest amount (e.g. -10.00) by which to filter transactions")
+	flag.StringVar(&from, "from", "", "the first date (YYYY-MM-DD) to include, defaulting to today")
+	flag.StringVar(&to, "to", "", "the last date (YYYY-MM-DD) to include, defaulting to a month from the first")
+	flag.StringVar(&date, "date", "", "the date (YYYY-MM-DD) as of which to fill buckets or report the net worth, defaulting to today")
+	flag.IntVar(&months, "months", 6, "the number of months to forecast")
+	flag.StringVar(&interval, "interval", "monthly", "the balance history interval: daily or monthly")
 
 	flag.Parse()
 
@@ -27,6 +44,46 @@ func main() {
 		return
 	}
 
+	if !cli.IsValidFormat(format) {
+		fmt.Printf("unsupported format: %s\n", format)
+		return
+	}
+
+	// Only parse the dates and filters used by the requested lists and reports, so that those
+	// meant for one don't fail another.
+	var fromDate, toDate, asOfDate time.Time
+	var transactionQuery api.TransactionQuery
+	var err error
+
+	switch {
+	case list == "spending-plan-schedule",
+		report == "forecast",
+		report == "budget",
+		report == "balance-history":
+		fromDate, toDate, err = parseDateRange(from, to)
+		if err != nil {
+			fmt.Printf("invalid date range: %v\n", err)
+			return
+		}
+	}
+
+	switch {
+	case list == "bucket-fills", report == "net-worth":
+		asOfDate, err = parseDate(date)
+		if err != nil {
+			fmt.Printf("invalid date: %v\n", err)
+			return
+		}
+	}
+
+	if list == "transactions" {
+		transactionQuery, err = parseTransactionQuery(from, to, payee, minAmount, maxAmount)
+		if err != nil {
+			fmt.Printf("invalid transaction filter: %v\n", err)
+			return
+		}
+	}
+
 	database, err := api.OpenDocument(moneywellPath)
 	if err != nil {
 		fmt.Printf("failed to open database: %v\n", err)
@@ -41,21 +98,68 @@ func main() {
 
 	switch list {
 	case "account-groups":
-		err = cli.ListAccountGroups(database, verbose)
+		err = cli.ListAccountGroups(database, format, verbose)
 	case "accounts":
-		err = cli.ListAccounts(database, verbose)
+		err = cli.ListAccounts(database, format, verbose)
 	case "bucket-groups":
-		err = cli.ListBucketGroups(database, verbose)
+		err = cli.ListBucketGroups(database, format, verbose)
 	case "buckets":
-		err = cli.ListBuckets(database, verbose)
+		err = cli.ListBuckets(database, format, verbose)
 	case "tags":
-		err = cli.ListTags(database, verbose)
+		err = cli.ListTags(database, format, verbose)
 	case "transactions":
-		err = cli.ListTransactions(database, account, bucket, tag, verbose)
+		err = cli.ListTransactions(database, account, bucket, tag, transactionQuery, format, verbose)
 	case "recurrence-rules":
-		err = cli.ListRecurrenceRules(database, verbose)
+		err = cli.ListRecurrenceRules(database, format, verbose)
 	case "spending-plan":
-		err = cli.ListSpendingPlanEvents(database, bucket, verbose)
+		err = cli.ListSpendingPlanEvents(database, bucket, format, verbose)
+	case "spending-plan-schedule":
+		err = cli.ListSpendingPlanSchedule(database, bucket, fromDate, toDate, format, verbose)
+	case "bucket-fills":
+		err = cli.ListBucketFills(database, asOfDate, format, verbose)
+	case "bucket-fill-audit":
+		err = cli.ListBucketFillAudit(database, format, verbose)
+	case "holdings":
+		err = cli.ListHoldings(database, format, verbose)
+	}
+
+	if err == nil {
+		switch report {
+		case "forecast":
+			err = cli.ReportForecast(database, fromDate, months, format, verbose)
+		case "budget":
+			err = cli.ReportBudget(database, fromDate, toDate, format, verbose)
+		case "balance-history":
+			err = cli.ReportBalanceHistory(
+				database,
+				account,
+				bucket,
+				fromDate,
+				toDate,
+				interval,
+				format,
+				verbose,
+			)
+		case "net-worth":
+			err = cli.ReportNetWorth(database, asOfDate, format, verbose)
+		}
+	}
+
+	if err == nil {
+		switch export {
+		case "ledger":
+			err = cli.ExportLedger(database)
+		case "beancount":
+			err = cli.ExportBeancount(database)
+		case "qif":
+			err = cli.ExportQIF(database, account)
+		case "ofx":
+			err = cli.ExportOFX(database, account, getToday())
+		}
+	}
+
+	if err == nil && checkImport != "" {
+		err = cli.CheckImport(database, checkImport, importConfig, account, format, verbose)
 	}
 
 	if err != nil {
@@ -63,3 +167,107 @@ func main() {
 		return
 	}
 }
+
+// parseDateRange parses the given dates, defaulting to the month starting today or, if only the
+// last date is given, to the month ending on that date.
+func parseDateRange(from, to string) (time.Time, time.Time, error) {
+	var fromDate, toDate time.Time
+	var err error
+
+	if to != "" {
+		toDate, err = time.Parse("2006-01-02", to)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if from != "" {
+		fromDate, err = time.Parse("2006-01-02", from)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	} else if to != "" {
+		fromDate = toDate.AddDate(0, -1, 1)
+	} else {
+		fromDate = getToday()
+	}
+
+	if to == "" {
+		toDate = fromDate.AddDate(0, 1, -1)
+	}
+
+	if toDate.Before(fromDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s is before %s", to, from)
+	}
+
+	return fromDate, toDate, nil
+}
+
+// parseDate parses the given date, defaulting to today.
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return getToday(), nil
+	}
+
+	return time.Parse("2006-01-02", date)
+}
+
+// getToday returns the start of today, as a date in UTC like those of a MoneyWell document.
+func getToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseTransactionQuery parses the given filters into a query for transactions. Unlike the other
+// lists and reports, transactions are only restricted to the dates explicitly given.
+func parseTransactionQuery(from, to, payee, minAmount, maxAmount string) (api.TransactionQuery, error) {
+	query := api.TransactionQuery{
+		Payee: payee,
+	}
+
+	var err error
+	if from != "" {
+		query.From, err = time.Parse("2006-01-02", from)
+		if err != nil {
+			return api.TransactionQuery{}, err
+		}
+	}
+
+	if to != "" {
+		query.To, err = time.Parse("2006-01-02", to)
+		if err != nil {
+			return api.TransactionQuery{}, err
+		}
+	}
+
+	query.MinAmount, err = parseAmount(minAmount)
+	if err != nil {
+		return api.TransactionQuery{}, err
+	}
+
+	query.MaxAmount, err = parseAmount(maxAmount)
+	if err != nil {
+		return api.TransactionQuery{}, err
+	}
+
+	if query.MinAmount != nil && query.MaxAmount != nil && *query.MinAmount > *query.MaxAmount {
+		return api.TransactionQuery{}, fmt.Errorf("%s is more than %s", minAmount, maxAmount)
+	}
+
+	return query, nil
+}
+
+// parseAmount parses a decimal amount, such as -12.50, as a number of cents, or nil if no amount
+// is given.
+func parseAmount(amount string) (*int64, error) {
+	if amount == "" {
+		return nil, nil
+	}
+
+	cents, err := money.ParseAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cents, nil
+}
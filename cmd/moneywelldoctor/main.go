@@ -3,20 +3,172 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
 	"github.com/lieut-data/go-moneywell/internal/doctor"
 )
 
+const (
+	// exitProblems is the exit status when the document was diagnosed with problems.
+	exitProblems = 1
+	// exitFailure is the exit status when the document could not be diagnosed.
+	exitFailure = 2
+)
+
+// problemLargeUntagged identifies a transaction over -untagged-limit that is not tagged. The
+// sample check reporting it is disabled unless enabled by -enable or -config.
+const problemLargeUntagged = check.ProblemCustom
+
 func main() {
+	var bisect, plan, fix bool
+	var format, config, enable, disable, untaggedLimit, out string
+	flag.BoolVar(&bisect, "bisect", false, "find the first date the accounts and buckets diverged")
+	flag.BoolVar(&plan, "plan", false, "suggest a fix for each problem found")
+	flag.BoolVar(&fix, "fix", false, "write a copy of the document with the problems fixed to -out")
+	flag.StringVar(&out, "out", "", "the path to which -fix writes the fixed copy of the document")
+	flag.StringVar(&format, "format", doctor.FormatText, "the output format: text or json")
+	flag.StringVar(&config, "config", "", "the path to a JSON file enabling or disabling problems")
+	flag.StringVar(&enable, "enable", "", "a comma-separated list of problems to enable")
+	flag.StringVar(&disable, "disable", "", "a comma-separated list of problems to disable")
+	flag.StringVar(&untaggedLimit, "untagged-limit", "500.00", "the amount over which a transaction must be tagged, if problem 1000 is enabled")
+
 	flag.Parse()
 
 	if flag.NArg() == 0 {
 		fmt.Println("required: path to moneywell document")
+		os.Exit(exitFailure)
+	}
+
+	registry, err := newRegistry(config, enable, disable, untaggedLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	options := doctor.Options{
+		Format:   format,
+		Registry: registry,
+	}
+
+	if bisect {
+		divergences, err := doctor.Bisect(flag.Arg(0), options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bisect failed: %v\n", err)
+			os.Exit(exitFailure)
+		}
+
+		if len(divergences) > 0 {
+			os.Exit(exitProblems)
+		}
 		return
 	}
 
-	err := doctor.Diagnose(flag.Arg(0))
+	if fix {
+		if out == "" {
+			fmt.Fprintln(os.Stderr, "required: -out path to the fixed copy of the document")
+			os.Exit(exitFailure)
+		}
+
+		repair, err := doctor.ApplyFixes(flag.Arg(0), out, options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fix failed: %v\n", err)
+			os.Exit(exitFailure)
+		}
+
+		if repair.Diagnosis.HasProblems() {
+			os.Exit(exitProblems)
+		}
+		return
+	}
+
+	if plan {
+		fixes, err := doctor.Plan(flag.Arg(0), options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "plan failed: %v\n", err)
+			os.Exit(exitFailure)
+		}
+
+		if len(fixes) > 0 {
+			os.Exit(exitProblems)
+		}
+		return
+	}
+
+	diagnosis, err := doctor.Diagnose(flag.Arg(0), options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "do failed: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	if diagnosis.HasProblems() {
+		os.Exit(exitProblems)
+	}
+}
+
+// newRegistry creates a registry of the built-in checks and the disabled sample check for large
+// untagged transactions, disabling or enabling problems per the given config file, and then
+// enabling and disabling the given comma-separated problems.
+func newRegistry(config, enable, disable, untaggedLimit string) (*check.Registry, error) {
+	registry := doctor.NewDefaultRegistry()
+
+	limit, err := money.ParseAmount(untaggedLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid untagged limit: %v", err)
+	}
+	registry.Register(check.NewLargeUntaggedCheck(problemLargeUntagged, money.Money{Amount: limit}))
+	if err := registry.Disable(problemLargeUntagged); err != nil {
+		return nil, err
+	}
+
+	if config != "" {
+		loadedConfig, err := check.LoadConfig(config)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := registry.Configure(loadedConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	problems, err := parseProblems(enable)
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.Enable(problems...); err != nil {
+		return nil, err
+	}
+
+	problems, err = parseProblems(disable)
 	if err != nil {
-		fmt.Printf("do failed: %v\n", err)
+		return nil, err
+	}
+	if err := registry.Disable(problems...); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// parseProblems parses the given comma-separated problems.
+func parseProblems(problems string) ([]int, error) {
+	if problems == "" {
+		return nil, nil
+	}
+
+	parsedProblems := []int{}
+	for _, field := range strings.Split(problems, ",") {
+		problem, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid problem %q", field)
+		}
+
+		parsedProblems = append(parsedProblems, problem)
 	}
+
+	return parsedProblems, nil
 }
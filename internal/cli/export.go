@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/internal/export"
+)
+
+func ExportLedger(database *sql.DB) error {
+	document, err := api.LoadDocument(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to load document")
+	}
+
+	return export.WriteLedger(os.Stdout, document)
+}
+
+func ExportBeancount(database *sql.DB) error {
+	document, err := api.LoadDocument(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to load document")
+	}
+
+	return export.WriteBeancount(os.Stdout, document)
+}
+
+func ExportQIF(database *sql.DB, accountName string) error {
+	document, err := api.LoadDocument(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to load document")
+	}
+
+	account, ok := document.FindAccount(accountName)
+	if !ok {
+		return errors.Errorf("failed to find account: %s", accountName)
+	}
+
+	return export.WriteQIF(os.Stdout, document, account)
+}
+
+func ExportOFX(database *sql.DB, accountName string, now time.Time) error {
+	document, err := api.LoadDocument(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to load document")
+	}
+
+	account, ok := document.FindAccount(accountName)
+	if !ok {
+		return errors.Errorf("failed to find account: %s", accountName)
+	}
+
+	return export.WriteOFX(os.Stdout, document, account, now)
+}
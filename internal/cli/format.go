@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+const (
+	// FormatTable prints human-readable, tab-separated text. Its layout is subject to change.
+	FormatTable = "table"
+	// FormatJSON prints a JSON array of records whose shape is documented in the README.
+	FormatJSON = "json"
+	// FormatCSV prints a header row followed by one row per record.
+	FormatCSV = "csv"
+)
+
+// IsValidFormat determines if the given output format is supported.
+func IsValidFormat(format string) bool {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV:
+		return true
+	}
+
+	return false
+}
+
+// recordWriter accumulates the records of a list, writing them all at once as JSON or CSV.
+type recordWriter struct {
+	format  string
+	header  []string
+	records []interface{}
+	rows    [][]string
+}
+
+func newRecordWriter(format string, header ...string) *recordWriter {
+	return &recordWriter{
+		format:  format,
+		header:  header,
+		records: []interface{}{},
+	}
+}
+
+// add records a single JSON record and its corresponding CSV row.
+func (w *recordWriter) add(record interface{}, row ...string) {
+	w.addRecord(record)
+	w.addRow(row...)
+}
+
+func (w *recordWriter) addRecord(record interface{}) {
+	w.records = append(w.records, record)
+}
+
+func (w *recordWriter) addRow(row ...string) {
+	w.rows = append(w.rows, row)
+}
+
+func (w *recordWriter) flush() error {
+	switch w.format {
+	case FormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(w.records); err != nil {
+			return errors.Wrap(err, "failed to encode json")
+		}
+
+	case FormatCSV:
+		writer := csv.NewWriter(os.Stdout)
+		if err := writer.Write(w.header); err != nil {
+			return errors.Wrap(err, "failed to write csv header")
+		}
+		if err := writer.WriteAll(w.rows); err != nil {
+			return errors.Wrap(err, "failed to write csv")
+		}
+
+	default:
+		return errors.Errorf("unsupported format: %s", w.format)
+	}
+
+	return nil
+}
+
+// formatDate formats a date as YYYY-MM-DD, or the empty string if unset.
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.Format("2006-01-02")
+}
+
+// formatAmount formats the amount of money as an integer number of cents.
+func formatAmount(amount money.Money) string {
+	return strconv.FormatInt(amount.Amount, 10)
+}
+
+func formatInt(value int64) string {
+	return strconv.FormatInt(value, 10)
+}
+
+// formatFloat formats a decimal, such as a number of units or a share price, without rounding.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatBool(value bool) string {
+	return strconv.FormatBool(value)
+}
+
+func describeBucketType(bucketType int64) string {
+	switch bucketType {
+	case api.BucketTypeIncome:
+		return "income"
+	case api.BucketTypeExpense:
+		return "expense"
+	}
+
+	return ""
+}
+
+func describeTransactionStatus(status int) string {
+	switch status {
+	case api.TransactionStatusVoided:
+		return "voided"
+	case api.TransactionStatusReconciled:
+		return "reconciled"
+	case api.TransactionStatusCleared:
+		return "cleared"
+	case api.TransactionStatusOpen:
+		return "open"
+	case api.TransactionStatusPending:
+		return "pending"
+	}
+
+	return ""
+}
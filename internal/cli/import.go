@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/internal/csvimport"
+)
+
+const (
+	importStatusNew     = "new"
+	importStatusMatched = "matched"
+	importStatusMissing = "missing"
+)
+
+// CheckImport matches the rows of the given bank statement against the existing transactions
+// of the given account, without changing the document.
+func CheckImport(
+	database *sql.DB,
+	statementPath string,
+	configPath string,
+	accountName string,
+	format string,
+	verbose bool,
+) error {
+	config := csvimport.NewConfig()
+	if configPath != "" {
+		var err error
+		config, err = csvimport.LoadConfig(configPath)
+		if err != nil {
+			return errors.Wrap(err, "failed to load import config")
+		}
+	}
+
+	accounts, err := api.GetAccounts(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch accounts")
+	}
+
+	var account api.Account
+	found := false
+	for _, candidate := range accounts {
+		if candidate.Name == accountName {
+			account = candidate
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("failed to find account: %s", accountName)
+	}
+
+	transactions, err := api.GetTransactions(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transactions")
+	}
+
+	file, err := os.Open(statementPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", statementPath)
+	}
+	defer file.Close()
+
+	rows, err := csvimport.ReadRows(file, config, account.CurrencyCode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", statementPath)
+	}
+
+	result := csvimport.GetResult(rows, account, transactions, config)
+
+	records := []importRecord{}
+	for _, row := range result.New {
+		records = append(records, importRecord{
+			Status: importStatusNew,
+			Line:   row.Line,
+			Date:   formatDate(row.Date),
+			Payee:  row.Payee,
+			Memo:   row.Memo,
+			Amount: row.Amount,
+		})
+	}
+	for _, match := range result.Matched {
+		records = append(records, importRecord{
+			Status:           importStatusMatched,
+			Line:             match.Row.Line,
+			Date:             formatDate(match.Row.Date),
+			Payee:            match.Row.Payee,
+			Memo:             match.Row.Memo,
+			Amount:           match.Row.Amount,
+			TransactionID:    match.Transaction.PrimaryKey,
+			TransactionDate:  formatDate(match.Transaction.Date),
+			TransactionPayee: match.Transaction.Payee,
+			Similarity:       match.Similarity,
+		})
+	}
+	for _, transaction := range result.Missing {
+		records = append(records, importRecord{
+			Status:           importStatusMissing,
+			Amount:           transaction.Amount,
+			TransactionID:    transaction.PrimaryKey,
+			TransactionDate:  formatDate(transaction.Date),
+			TransactionPayee: transaction.Payee,
+		})
+	}
+
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"status",
+			"line",
+			"date",
+			"payee",
+			"memo",
+			"currency",
+			"amount",
+			"transaction_id",
+			"transaction_date",
+			"transaction_payee",
+			"similarity",
+		)
+		for _, record := range records {
+			writer.add(
+				record,
+				record.Status,
+				formatInt(int64(record.Line)),
+				record.Date,
+				record.Payee,
+				record.Memo,
+				record.Amount.Currency,
+				formatAmount(record.Amount),
+				formatInt(record.TransactionID),
+				record.TransactionDate,
+				record.TransactionPayee,
+				formatFloat(record.Similarity),
+			)
+		}
+
+		return writer.flush()
+	}
+
+	for _, record := range records {
+		primaryKey := ""
+		if verbose && record.TransactionID != 0 {
+			primaryKey = fmt.Sprintf(" [%d]", record.TransactionID)
+		}
+
+		switch record.Status {
+		case importStatusNew:
+			fmt.Printf("NEW: line %d: %s %s %s\n", record.Line, record.Date, record.Payee, record.Amount)
+		case importStatusMatched:
+			fmt.Printf(
+				"MATCHED: line %d: %s %s %s matches %s %s%s\n",
+				record.Line,
+				record.Date,
+				record.Payee,
+				record.Amount,
+				record.TransactionDate,
+				record.TransactionPayee,
+				primaryKey,
+			)
+		case importStatusMissing:
+			fmt.Printf(
+				"MISSING: %s %s %s%s\n",
+				record.TransactionDate,
+				record.TransactionPayee,
+				record.Amount,
+				primaryKey,
+			)
+		}
+	}
+
+	return nil
+}
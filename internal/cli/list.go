@@ -1,23 +1,40 @@
 package cli
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func ListAccountGroups(database *sql.DB, verbose bool) error {
+func ListAccountGroups(database *sql.DB, format string, verbose bool) error {
 	accountGroups, err := api.GetAccountGroups(database)
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch account groups")
 	}
 
+	if format != FormatTable {
+		writer := newRecordWriter(format, "id", "name")
+		for _, accountGroup := range accountGroups {
+			writer.add(
+				accountGroupRecord{ID: accountGroup.PrimaryKey, Name: accountGroup.Name},
+				formatInt(accountGroup.PrimaryKey),
+				accountGroup.Name,
+			)
+		}
+
+		return writer.flush()
+	}
+
 	for _, accountGroup := range accountGroups {
 		primaryKey := ""
 		if verbose {
@@ -30,32 +47,61 @@ func ListAccountGroups(database *sql.DB, verbose bool) error {
 	return nil
 }
 
-func ListAccounts(database *sql.DB, verbose bool) error {
-	accountGroups, err := api.GetAccountGroups(database)
+func ListAccounts(database *sql.DB, format string, verbose bool) error {
+	document, err := api.LoadDocument(database)
 	if err != nil {
-		return errors.Wrap(err, "failed to fetch account groups")
-	}
-
-	mappedAccountGroups := make(map[int64]api.AccountGroup, len(accountGroups))
-	for _, accountGroup := range accountGroups {
-		mappedAccountGroups[accountGroup.PrimaryKey] = accountGroup
+		return errors.Wrap(err, "failed to load document")
 	}
 
-	accounts, err := api.GetAccounts(database)
-	if err != nil {
-		return errors.Wrap(err, "failed to fetch accounts")
-	}
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"id",
+			"name",
+			"account_group_id",
+			"account_group",
+			"currency",
+			"include_in_cash_flow",
+			"is_bucket_optional",
+			"balance",
+		)
+		for _, account := range document.Accounts {
+			balance := api.GetAccountBalance(
+				account,
+				document.GetAccountTransactions(account.PrimaryKey),
+			)
+			accountGroup, _ := document.GetAccountGroup(account.AccountGroup)
+
+			writer.add(
+				accountRecord{
+					ID:                account.PrimaryKey,
+					Name:              account.Name,
+					AccountGroupID:    account.AccountGroup,
+					AccountGroup:      accountGroup.Name,
+					Currency:          account.CurrencyCode,
+					IncludeInCashFlow: account.IncludeInCashFlow,
+					IsBucketOptional:  account.IsBucketOptional,
+					Balance:           balance,
+				},
+				formatInt(account.PrimaryKey),
+				account.Name,
+				formatInt(account.AccountGroup),
+				accountGroup.Name,
+				account.CurrencyCode,
+				formatBool(account.IncludeInCashFlow),
+				formatBool(account.IsBucketOptional),
+				formatAmount(balance),
+			)
+		}
 
-	transactions, err := api.GetTransactions(database)
-	if err != nil {
-		return errors.Wrap(err, "failed to get transactions")
+		return writer.flush()
 	}
 
 	var lastAccountGroup int64
-	for _, account := range accounts {
+	for _, account := range document.Accounts {
 		if lastAccountGroup == 0 || lastAccountGroup != account.AccountGroup {
 			if account.AccountGroup > 0 {
-				accountGroup := mappedAccountGroups[account.AccountGroup]
+				accountGroup, _ := document.GetAccountGroup(account.AccountGroup)
 				if !verbose {
 					fmt.Printf("%s\n", accountGroup.Name)
 				} else {
@@ -69,7 +115,10 @@ func ListAccounts(database *sql.DB, verbose bool) error {
 			lastAccountGroup = account.AccountGroup
 		}
 
-		balance := api.GetAccountBalance(account, transactions)
+		balance := api.GetAccountBalance(
+			account,
+			document.GetAccountTransactions(account.PrimaryKey),
+		)
 
 		var indent string
 		if account.AccountGroup > 0 {
@@ -87,12 +136,30 @@ func ListAccounts(database *sql.DB, verbose bool) error {
 	return nil
 }
 
-func ListBucketGroups(database *sql.DB, verbose bool) error {
+func ListBucketGroups(database *sql.DB, format string, verbose bool) error {
 	bucketGroups, err := api.GetBucketGroups(database)
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch bucket groups")
 	}
 
+	if format != FormatTable {
+		writer := newRecordWriter(format, "id", "name", "type")
+		for _, bucketGroup := range bucketGroups {
+			writer.add(
+				bucketGroupRecord{
+					ID:   bucketGroup.PrimaryKey,
+					Name: bucketGroup.Name,
+					Type: describeBucketType(bucketGroup.Type),
+				},
+				formatInt(bucketGroup.PrimaryKey),
+				bucketGroup.Name,
+				describeBucketType(bucketGroup.Type),
+			)
+		}
+
+		return writer.flush()
+	}
+
 	var lastBucketType int64
 	for _, bucketGroup := range bucketGroups {
 		if lastBucketType == 0 || lastBucketType != bucketGroup.Type {
@@ -115,7 +182,7 @@ func ListBucketGroups(database *sql.DB, verbose bool) error {
 	return nil
 }
 
-func ListBuckets(database *sql.DB, verbose bool) error {
+func ListBuckets(database *sql.DB, format string, verbose bool) error {
 	bucketGroups, err := api.GetBucketGroups(database)
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch bucket groups")
@@ -146,6 +213,58 @@ func ListBuckets(database *sql.DB, verbose bool) error {
 		return errors.Wrap(err, "failed to get bucket transfers")
 	}
 
+	balances := make(map[int64]money.Money, len(buckets))
+	for _, bucket := range buckets {
+		events, err := api.GetBucketEvents(bucket, transactions, bucketTransfers)
+		if err != nil {
+			return errors.Wrap(err, "failed to get bucket events")
+		}
+
+		balance, err := api.GetBucketBalance(bucket, events, settings)
+		if err != nil {
+			return errors.Wrap(err, "failed to get bucket balance")
+		}
+
+		balances[bucket.PrimaryKey] = balance
+	}
+
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"id",
+			"name",
+			"type",
+			"bucket_group_id",
+			"bucket_group",
+			"currency",
+			"balance",
+		)
+		for _, bucket := range buckets {
+			balance := balances[bucket.PrimaryKey]
+			bucketGroup := mappedBucketGroups[bucket.BucketGroup]
+
+			writer.add(
+				bucketRecord{
+					ID:            bucket.PrimaryKey,
+					Name:          bucket.Name,
+					Type:          describeBucketType(bucket.Type),
+					BucketGroupID: bucket.BucketGroup,
+					BucketGroup:   bucketGroup.Name,
+					Balance:       balance,
+				},
+				formatInt(bucket.PrimaryKey),
+				bucket.Name,
+				describeBucketType(bucket.Type),
+				formatInt(bucket.BucketGroup),
+				bucketGroup.Name,
+				balance.Currency,
+				formatAmount(balance),
+			)
+		}
+
+		return writer.flush()
+	}
+
 	var lastBucketGroup, lastBucketGroupType int64
 	for _, bucket := range buckets {
 		if lastBucketGroup == 0 || lastBucketGroup != bucket.BucketGroup {
@@ -174,15 +293,7 @@ func ListBuckets(database *sql.DB, verbose bool) error {
 			lastBucketGroup = bucket.BucketGroup
 		}
 
-		events, err := api.GetBucketEvents(bucket, transactions, bucketTransfers)
-		if err != nil {
-			return errors.Wrap(err, "failed to get bucket events")
-		}
-
-		balance, err := api.GetBucketBalance(bucket, events, settings)
-		if err != nil {
-			return errors.Wrap(err, "failed to get bucket balance")
-		}
+		balance := balances[bucket.PrimaryKey]
 
 		var indent string
 		if bucket.BucketGroup > 0 {
@@ -200,12 +311,25 @@ func ListBuckets(database *sql.DB, verbose bool) error {
 	return nil
 }
 
-func ListTags(database *sql.DB, verbose bool) error {
+func ListTags(database *sql.DB, format string, verbose bool) error {
 	tags, err := api.GetTags(database)
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch tags")
 	}
 
+	if format != FormatTable {
+		writer := newRecordWriter(format, "id", "name")
+		for _, tag := range tags {
+			writer.add(
+				tagRecord{ID: tag.PrimaryKey, Name: tag.Name},
+				formatInt(tag.PrimaryKey),
+				tag.Name,
+			)
+		}
+
+		return writer.flush()
+	}
+
 	for _, tag := range tags {
 		primaryKey := ""
 		if verbose {
@@ -223,13 +347,10 @@ func ListTransactions(
 	accountFilter,
 	bucketFilter,
 	tagFilter string,
+	query api.TransactionQuery,
+	format string,
 	verbose bool,
 ) error {
-	transactions, err := api.GetTransactions(database)
-	if err != nil {
-		return errors.Wrap(err, "failed to fetch transactions")
-	}
-
 	accountsMap, err := api.GetAccountsMap(database)
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch accounts map")
@@ -250,6 +371,66 @@ func ListTransactions(
 		return errors.Wrap(err, "failed to fetch transaction tag map")
 	}
 
+	// Filter by name within the query itself, rather than fetching every transaction.
+	if len(accountFilter) > 0 {
+		for _, account := range accountsMap {
+			if account.Name == accountFilter {
+				query.Accounts = append(query.Accounts, account.PrimaryKey)
+			}
+		}
+		if len(query.Accounts) == 0 {
+			return errors.Errorf("failed to find account: %s", accountFilter)
+		}
+	}
+
+	if len(bucketFilter) > 0 {
+		for _, bucket := range bucketsMap {
+			if bucket.Name == bucketFilter {
+				query.Buckets = append(query.Buckets, bucket.PrimaryKey)
+			}
+		}
+		if len(query.Buckets) == 0 {
+			return errors.Errorf("failed to find bucket: %s", bucketFilter)
+		}
+	}
+
+	if len(tagFilter) > 0 {
+		for _, tag := range tagsMap {
+			if tag.Name == tagFilter {
+				query.Tags = append(query.Tags, tag.PrimaryKey)
+			}
+		}
+		if len(query.Tags) == 0 {
+			return errors.Errorf("failed to find tag: %s", tagFilter)
+		}
+	}
+
+	transactions, err := api.QueryTransactions(context.Background(), database, query)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch transactions")
+	}
+
+	writer := newRecordWriter(
+		format,
+		"id",
+		"date",
+		"payee",
+		"memo",
+		"account_id",
+		"account",
+		"bucket_id",
+		"bucket",
+		"transfer_account_id",
+		"transfer_account",
+		"split_parent_id",
+		"is_split",
+		"is_bucket_optional",
+		"status",
+		"tags",
+		"currency",
+		"amount",
+	)
+
 	for _, transaction := range transactions {
 		primaryKey := ""
 		if verbose {
@@ -257,28 +438,54 @@ func ListTransactions(
 		}
 
 		bucket := bucketsMap[transaction.Bucket]
-		if len(bucketFilter) > 0 && bucket.Name != bucketFilter {
-			continue
-		}
-
 		account := accountsMap[transaction.Account]
-		if len(accountFilter) > 0 && account.Name != accountFilter {
-			continue
-		}
-
+		transferAccount := accountsMap[transaction.TransferAccount]
 		transactionTags := transactionTagMap[transaction.PrimaryKey]
-		if len(tagFilter) > 0 {
-			found := false
+
+		if format != FormatTable {
+			tagNames := make([]string, 0, len(transactionTags))
 			for _, tag := range transactionTags {
-				if tagFilter == tagsMap[tag].Name {
-					found = true
-					continue
-				}
+				tagNames = append(tagNames, tagsMap[tag].Name)
 			}
 
-			if !found {
-				continue
-			}
+			writer.add(
+				transactionRecord{
+					ID:                transaction.PrimaryKey,
+					Date:              formatDate(transaction.Date),
+					Payee:             transaction.Payee,
+					Memo:              transaction.Memo,
+					AccountID:         transaction.Account,
+					Account:           account.Name,
+					BucketID:          transaction.Bucket,
+					Bucket:            bucket.Name,
+					TransferAccountID: transaction.TransferAccount,
+					TransferAccount:   transferAccount.Name,
+					SplitParentID:     transaction.SplitParent,
+					IsSplit:           transaction.IsSplit,
+					IsBucketOptional:  transaction.IsBucketOptional,
+					Status:            describeTransactionStatus(transaction.Status),
+					Tags:              tagNames,
+					Amount:            transaction.Amount,
+				},
+				formatInt(transaction.PrimaryKey),
+				formatDate(transaction.Date),
+				transaction.Payee,
+				transaction.Memo,
+				formatInt(transaction.Account),
+				account.Name,
+				formatInt(transaction.Bucket),
+				bucket.Name,
+				formatInt(transaction.TransferAccount),
+				transferAccount.Name,
+				formatInt(transaction.SplitParent),
+				formatBool(transaction.IsSplit),
+				formatBool(transaction.IsBucketOptional),
+				describeTransactionStatus(transaction.Status),
+				strings.Join(tagNames, ","),
+				transaction.Amount.Currency,
+				formatAmount(transaction.Amount),
+			)
+			continue
 		}
 
 		memo := ""
@@ -302,13 +509,13 @@ func ListTransactions(
 				accountName = fmt.Sprintf(
 					"%s from %s",
 					account.Name,
-					accountsMap[transaction.TransferAccount].Name,
+					transferAccount.Name,
 				)
 			case api.TransactionTypeWithdrawal:
 				accountName = fmt.Sprintf(
 					"%s to %s",
 					account.Name,
-					accountsMap[transaction.TransferAccount].Name,
+					transferAccount.Name,
 				)
 			}
 		}
@@ -325,11 +532,16 @@ func ListTransactions(
 		)
 	}
 
+	if format != FormatTable {
+		return writer.flush()
+	}
+
 	return nil
 }
 
 func ListRecurrenceRules(
 	database *sql.DB,
+	format string,
 	verbose bool,
 ) error {
 	recurrenceRules, err := api.GetRecurrenceRules(database)
@@ -339,6 +551,20 @@ func ListRecurrenceRules(
 
 	sort.Sort(api.RecurrenceRuleSort(recurrenceRules))
 
+	if format != FormatTable {
+		writer := newRecordWriter(format, "id", "description")
+		for _, recurrenceRule := range recurrenceRules {
+			description := api.DescribeRecurrenceRule(recurrenceRule)
+			writer.add(
+				recurrenceRuleRecord{ID: recurrenceRule.PrimaryKey, Description: description},
+				formatInt(recurrenceRule.PrimaryKey),
+				description,
+			)
+		}
+
+		return writer.flush()
+	}
+
 	for _, recurrenceRule := range recurrenceRules {
 		primaryKey := ""
 		if verbose {
@@ -354,6 +580,7 @@ func ListRecurrenceRules(
 func ListSpendingPlanEvents(
 	database *sql.DB,
 	bucketFilter string,
+	format string,
 	verbose bool,
 ) error {
 	spendingPlanEvents, err := api.GetSpendingPlan(database)
@@ -381,6 +608,62 @@ func ListSpendingPlanEvents(
 		mappedBucketGroups[bucketGroup.PrimaryKey] = bucketGroup
 	}
 
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"id",
+			"name",
+			"date",
+			"bucket_id",
+			"bucket",
+			"currency",
+			"amount",
+			"recurrence_rule_id",
+			"recurrence",
+			"fill_recurrence_rule_id",
+			"fill_recurrence",
+		)
+		for _, event := range spendingPlanEvents {
+			bucket := bucketsMap[event.Bucket]
+			if len(bucketFilter) > 0 && bucket.Name != bucketFilter {
+				continue
+			}
+
+			recurrence := api.DescribeRecurrenceRule(recurrenceRulesMap[event.RecurrenceRule])
+			fillRecurrence := api.DescribeFillRecurrenceRule(
+				recurrenceRulesMap[event.FillRecurrenceRule],
+			)
+
+			writer.add(
+				spendingPlanRecord{
+					ID:                   event.PrimaryKey,
+					Name:                 event.Name,
+					Date:                 formatDate(event.Date),
+					BucketID:             event.Bucket,
+					Bucket:               bucket.Name,
+					Amount:               event.Amount,
+					RecurrenceRuleID:     event.RecurrenceRule,
+					Recurrence:           recurrence,
+					FillRecurrenceRuleID: event.FillRecurrenceRule,
+					FillRecurrence:       fillRecurrence,
+				},
+				formatInt(event.PrimaryKey),
+				event.Name,
+				formatDate(event.Date),
+				formatInt(event.Bucket),
+				bucket.Name,
+				event.Amount.Currency,
+				formatAmount(event.Amount),
+				formatInt(event.RecurrenceRule),
+				recurrence,
+				formatInt(event.FillRecurrenceRule),
+				fillRecurrence,
+			)
+		}
+
+		return writer.flush()
+	}
+
 	bucketGroupTypes := []int64{api.BucketGroupTypeIncome, api.BucketGroupTypeExpense}
 	for _, bucketGroupType := range bucketGroupTypes {
 		headerPrinted := false
@@ -429,3 +712,494 @@ func ListSpendingPlanEvents(
 
 	return nil
 }
+
+func ListSpendingPlanSchedule(
+	database *sql.DB,
+	bucketFilter string,
+	from time.Time,
+	to time.Time,
+	format string,
+	verbose bool,
+) error {
+	spendingPlanEvents, err := api.GetSpendingPlan(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch spending plan")
+	}
+
+	spendingPlanEventsMap := make(map[int64]api.SpendingPlan, len(spendingPlanEvents))
+	for _, event := range spendingPlanEvents {
+		spendingPlanEventsMap[event.PrimaryKey] = event
+	}
+
+	recurrenceRulesMap, err := api.GetRecurrenceRulesMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch recurrence rules")
+	}
+
+	bucketsMap, err := api.GetBucketsMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets map")
+	}
+
+	schedule := api.GetSpendingPlanSchedule(
+		spendingPlanEvents,
+		recurrenceRulesMap,
+		bucketsMap,
+		from,
+		to,
+	)
+
+	writer := newRecordWriter(
+		format,
+		"date",
+		"type",
+		"spending_plan_id",
+		"spending_plan",
+		"bucket_id",
+		"bucket",
+		"currency",
+		"amount",
+	)
+
+	for _, occurrence := range schedule {
+		bucket := bucketsMap[occurrence.Bucket]
+		if len(bucketFilter) > 0 && bucket.Name != bucketFilter {
+			continue
+		}
+
+		if format != FormatTable {
+			occurrenceType := "event"
+			if occurrence.Type == api.SpendingPlanOccurrenceTypeFill {
+				occurrenceType = "fill"
+			}
+			spendingPlanName := spendingPlanEventsMap[occurrence.SpendingPlan].Name
+
+			writer.add(
+				spendingPlanOccurrenceRecord{
+					Date:           formatDate(occurrence.Date),
+					Type:           occurrenceType,
+					SpendingPlanID: occurrence.SpendingPlan,
+					SpendingPlan:   spendingPlanName,
+					BucketID:       occurrence.Bucket,
+					Bucket:         bucket.Name,
+					Amount:         occurrence.Amount,
+				},
+				formatDate(occurrence.Date),
+				occurrenceType,
+				formatInt(occurrence.SpendingPlan),
+				spendingPlanName,
+				formatInt(occurrence.Bucket),
+				bucket.Name,
+				occurrence.Amount.Currency,
+				formatAmount(occurrence.Amount),
+			)
+			continue
+		}
+
+		primaryKey := ""
+		if verbose {
+			primaryKey = fmt.Sprintf(" [%d]", occurrence.SpendingPlan)
+		}
+
+		occurrenceType := "Event"
+		if occurrence.Type == api.SpendingPlanOccurrenceTypeFill {
+			occurrenceType = "Fill"
+		}
+
+		fmt.Printf(
+			"%s\t%s\t%s\t%s\t%s%s\n",
+			occurrence.Date.Format("Jan 2, 2006"),
+			occurrenceType,
+			spendingPlanEventsMap[occurrence.SpendingPlan].Name,
+			bucket.Name,
+			occurrence.Amount,
+			primaryKey,
+		)
+	}
+
+	if format != FormatTable {
+		return writer.flush()
+	}
+
+	return nil
+}
+
+func ListBucketFills(database *sql.DB, date time.Time, format string, verbose bool) error {
+	settings, err := api.GetSettings(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get settings")
+	}
+
+	buckets, err := api.GetBuckets(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets")
+	}
+
+	bucketsMap, err := api.GetBucketsMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets map")
+	}
+
+	spendingPlanEvents, err := api.GetSpendingPlan(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch spending plan")
+	}
+
+	recurrenceRulesMap, err := api.GetRecurrenceRulesMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch recurrence rules")
+	}
+
+	transactions, err := api.GetTransactions(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transactions")
+	}
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get bucket transfers")
+	}
+
+	bucketFills, err := api.GetBucketFills(
+		date,
+		settings,
+		buckets,
+		spendingPlanEvents,
+		recurrenceRulesMap,
+		transactions,
+		bucketTransfers,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to get bucket fills")
+	}
+
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"bucket_id",
+			"bucket",
+			"currency",
+			"balance",
+			"planned",
+			"amount",
+			"is_short",
+		)
+		for _, bucketFill := range bucketFills {
+			bucketName := bucketsMap[bucketFill.Bucket].Name
+			writer.add(
+				bucketFillRecord{
+					BucketID: bucketFill.Bucket,
+					Bucket:   bucketName,
+					Balance:  bucketFill.Balance,
+					Planned:  bucketFill.Planned,
+					Amount:   bucketFill.Amount,
+					IsShort:  bucketFill.IsShort(),
+				},
+				formatInt(bucketFill.Bucket),
+				bucketName,
+				bucketFill.Planned.Currency,
+				formatAmount(bucketFill.Balance),
+				formatAmount(bucketFill.Planned),
+				formatAmount(bucketFill.Amount),
+				formatBool(bucketFill.IsShort()),
+			)
+		}
+
+		return writer.flush()
+	}
+
+	for _, bucketFill := range bucketFills {
+		primaryKey := ""
+		if verbose {
+			primaryKey = fmt.Sprintf(" [%d]", bucketFill.Bucket)
+		}
+
+		short := ""
+		if bucketFill.IsShort() {
+			short = fmt.Sprintf(
+				" (short %s)",
+				bucketFill.Planned.Add(bucketFill.Amount.Multiply(-1)),
+			)
+		}
+
+		fmt.Printf(
+			"%s\t%s\t%s\t%s%s%s\n",
+			bucketsMap[bucketFill.Bucket].Name,
+			bucketFill.Balance,
+			bucketFill.Planned,
+			bucketFill.Amount,
+			short,
+			primaryKey,
+		)
+	}
+
+	return nil
+}
+
+func ListBucketFillAudit(database *sql.DB, format string, verbose bool) error {
+	settings, err := api.GetSettings(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get settings")
+	}
+
+	buckets, err := api.GetBuckets(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets")
+	}
+
+	bucketsMap, err := api.GetBucketsMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets map")
+	}
+
+	spendingPlanEvents, err := api.GetSpendingPlan(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch spending plan")
+	}
+
+	recurrenceRulesMap, err := api.GetRecurrenceRulesMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch recurrence rules")
+	}
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get bucket transfers")
+	}
+
+	audits := api.AuditBucketFills(
+		settings,
+		buckets,
+		spendingPlanEvents,
+		recurrenceRulesMap,
+		bucketTransfers,
+	)
+
+	// Unlike the table, which hides matching fills unless verbose, the machine-readable formats
+	// always include every audit.
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"date",
+			"bucket_id",
+			"bucket",
+			"currency",
+			"expected",
+			"actual",
+			"is_match",
+		)
+		for _, audit := range audits {
+			bucketName := bucketsMap[audit.Bucket].Name
+			writer.add(
+				bucketFillAuditRecord{
+					Date:     formatDate(audit.Date),
+					BucketID: audit.Bucket,
+					Bucket:   bucketName,
+					Expected: audit.Expected,
+					Actual:   audit.Actual,
+					IsMatch:  audit.IsMatch(),
+				},
+				formatDate(audit.Date),
+				formatInt(audit.Bucket),
+				bucketName,
+				audit.Expected.Currency,
+				formatAmount(audit.Expected),
+				formatAmount(audit.Actual),
+				formatBool(audit.IsMatch()),
+			)
+		}
+
+		return writer.flush()
+	}
+
+	for _, audit := range audits {
+		if audit.IsMatch() && !verbose {
+			continue
+		}
+
+		primaryKey := ""
+		if verbose {
+			primaryKey = fmt.Sprintf(" [%d]", audit.Bucket)
+		}
+
+		status := "MISMATCH"
+		if audit.IsMatch() {
+			status = "OK"
+		}
+
+		fmt.Printf(
+			"%s\t%s\t%s\t%s\t%s%s\n",
+			audit.Date.Format("Jan 2, 2006"),
+			bucketsMap[audit.Bucket].Name,
+			audit.Expected,
+			audit.Actual,
+			status,
+			primaryKey,
+		)
+	}
+
+	return nil
+}
+
+func ListHoldings(database *sql.DB, format string, verbose bool) error {
+	accounts, err := api.GetAccounts(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch accounts")
+	}
+
+	securities, err := api.GetInvestmentSecuritiesMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch investment securities")
+	}
+
+	holdings, err := api.GetInvestmentHoldings(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch investment holdings")
+	}
+
+	accountHoldings := make(map[int64][]api.InvestmentHolding)
+	for _, holding := range holdings {
+		accountHoldings[holding.Account] = append(accountHoldings[holding.Account], holding)
+	}
+
+	// Value each holding at its security's current price, falling back to the price last
+	// recorded against the holding itself if the security is unknown.
+	type position struct {
+		security    api.InvestmentSecurity
+		name        string
+		price       float64
+		priceDate   time.Time
+		marketValue money.Money
+	}
+	getPosition := func(holding api.InvestmentHolding) position {
+		security, ok := securities[holding.Security]
+		if !ok {
+			return position{
+				name:        holding.Name,
+				price:       holding.UnitPrice,
+				priceDate:   holding.UnitPriceDate,
+				marketValue: holding.MarketValue,
+			}
+		}
+
+		name := security.Name
+		if security.Symbol != "" {
+			name = fmt.Sprintf("%s (%s)", security.Name, security.Symbol)
+		}
+
+		return position{
+			security:    security,
+			name:        name,
+			price:       security.CurrentPrice,
+			priceDate:   security.CurrentPriceDate,
+			marketValue: holding.GetMarketValue(security),
+		}
+	}
+
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"id",
+			"account_id",
+			"account",
+			"security_id",
+			"symbol",
+			"name",
+			"units",
+			"price",
+			"price_date",
+			"currency",
+			"market_value",
+		)
+		for _, account := range accounts {
+			for _, holding := range accountHoldings[account.PrimaryKey] {
+				position := getPosition(holding)
+
+				name := position.security.Name
+				if name == "" {
+					name = holding.Name
+				}
+
+				writer.add(
+					holdingRecord{
+						ID:          holding.PrimaryKey,
+						AccountID:   account.PrimaryKey,
+						Account:     account.Name,
+						SecurityID:  holding.Security,
+						Symbol:      position.security.Symbol,
+						Name:        name,
+						Units:       holding.Units,
+						Price:       position.price,
+						PriceDate:   formatDate(position.priceDate),
+						MarketValue: position.marketValue,
+					},
+					formatInt(holding.PrimaryKey),
+					formatInt(account.PrimaryKey),
+					account.Name,
+					formatInt(holding.Security),
+					position.security.Symbol,
+					name,
+					formatFloat(holding.Units),
+					formatFloat(position.price),
+					formatDate(position.priceDate),
+					position.marketValue.Currency,
+					formatAmount(position.marketValue),
+				)
+			}
+		}
+
+		return writer.flush()
+	}
+
+	for _, account := range accounts {
+		if len(accountHoldings[account.PrimaryKey]) == 0 {
+			continue
+		}
+
+		primaryKey := ""
+		if verbose {
+			primaryKey = fmt.Sprintf(" [%d]", account.PrimaryKey)
+		}
+		fmt.Printf("%s%s\n", account.Name, primaryKey)
+
+		// Securities may be priced in a currency other than that of the account, so total each
+		// currency separately.
+		totals := []money.Money{}
+		for _, holding := range accountHoldings[account.PrimaryKey] {
+			position := getPosition(holding)
+
+			found := false
+			for i := range totals {
+				if totals[i].Currency == position.marketValue.Currency {
+					totals[i] = totals[i].Add(position.marketValue)
+					found = true
+					break
+				}
+			}
+			if !found {
+				totals = append(totals, position.marketValue)
+			}
+
+			primaryKey := ""
+			if verbose {
+				primaryKey = fmt.Sprintf(" [%d]", holding.PrimaryKey)
+			}
+
+			fmt.Printf(
+				"    %s: %s units at %s on %s (%s)%s\n",
+				position.name,
+				formatFloat(holding.Units),
+				formatFloat(position.price),
+				formatDate(position.priceDate),
+				position.marketValue,
+				primaryKey,
+			)
+		}
+
+		for _, total := range totals {
+			fmt.Printf("    Total (%s)\n", total)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// The records below define the stable JSON shape of each list. Dates are formatted as
+// YYYY-MM-DD, amounts as an object with an integer number of cents and the currency, and
+// references to other entities include both the primary key and the name.
+
+type accountGroupRecord struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type accountRecord struct {
+	ID                int64       `json:"id"`
+	Name              string      `json:"name"`
+	AccountGroupID    int64       `json:"account_group_id"`
+	AccountGroup      string      `json:"account_group"`
+	Currency          string      `json:"currency"`
+	IncludeInCashFlow bool        `json:"include_in_cash_flow"`
+	IsBucketOptional  bool        `json:"is_bucket_optional"`
+	Balance           money.Money `json:"balance"`
+}
+
+type bucketGroupRecord struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type bucketRecord struct {
+	ID            int64       `json:"id"`
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	BucketGroupID int64       `json:"bucket_group_id"`
+	BucketGroup   string      `json:"bucket_group"`
+	Balance       money.Money `json:"balance"`
+}
+
+type tagRecord struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type transactionRecord struct {
+	ID                int64       `json:"id"`
+	Date              string      `json:"date"`
+	Payee             string      `json:"payee"`
+	Memo              string      `json:"memo"`
+	AccountID         int64       `json:"account_id"`
+	Account           string      `json:"account"`
+	BucketID          int64       `json:"bucket_id"`
+	Bucket            string      `json:"bucket"`
+	TransferAccountID int64       `json:"transfer_account_id"`
+	TransferAccount   string      `json:"transfer_account"`
+	SplitParentID     int64       `json:"split_parent_id"`
+	IsSplit           bool        `json:"is_split"`
+	IsBucketOptional  bool        `json:"is_bucket_optional"`
+	Status            string      `json:"status"`
+	Tags              []string    `json:"tags"`
+	Amount            money.Money `json:"amount"`
+}
+
+type recurrenceRuleRecord struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+}
+
+type spendingPlanRecord struct {
+	ID                   int64       `json:"id"`
+	Name                 string      `json:"name"`
+	Date                 string      `json:"date"`
+	BucketID             int64       `json:"bucket_id"`
+	Bucket               string      `json:"bucket"`
+	Amount               money.Money `json:"amount"`
+	RecurrenceRuleID     int64       `json:"recurrence_rule_id"`
+	Recurrence           string      `json:"recurrence"`
+	FillRecurrenceRuleID int64       `json:"fill_recurrence_rule_id"`
+	FillRecurrence       string      `json:"fill_recurrence"`
+}
+
+type spendingPlanOccurrenceRecord struct {
+	Date           string      `json:"date"`
+	Type           string      `json:"type"`
+	SpendingPlanID int64       `json:"spending_plan_id"`
+	SpendingPlan   string      `json:"spending_plan"`
+	BucketID       int64       `json:"bucket_id"`
+	Bucket         string      `json:"bucket"`
+	Amount         money.Money `json:"amount"`
+}
+
+type bucketFillRecord struct {
+	BucketID int64       `json:"bucket_id"`
+	Bucket   string      `json:"bucket"`
+	Balance  money.Money `json:"balance"`
+	Planned  money.Money `json:"planned"`
+	Amount   money.Money `json:"amount"`
+	IsShort  bool        `json:"is_short"`
+}
+
+type bucketFillAuditRecord struct {
+	Date     string      `json:"date"`
+	BucketID int64       `json:"bucket_id"`
+	Bucket   string      `json:"bucket"`
+	Expected money.Money `json:"expected"`
+	Actual   money.Money `json:"actual"`
+	IsMatch  bool        `json:"is_match"`
+}
+
+type holdingRecord struct {
+	ID          int64       `json:"id"`
+	AccountID   int64       `json:"account_id"`
+	Account     string      `json:"account"`
+	SecurityID  int64       `json:"security_id"`
+	Symbol      string      `json:"symbol"`
+	Name        string      `json:"name"`
+	Units       float64     `json:"units"`
+	Price       float64     `json:"price"`
+	PriceDate   string      `json:"price_date"`
+	MarketValue money.Money `json:"market_value"`
+}
+
+type balanceRecord struct {
+	Date    string      `json:"date"`
+	Type    string      `json:"type"`
+	ID      int64       `json:"id"`
+	Name    string      `json:"name"`
+	Balance money.Money `json:"balance"`
+}
+
+type forecastDayRecord struct {
+	Date       string      `json:"date"`
+	Change     money.Money `json:"change"`
+	Balance    money.Money `json:"balance"`
+	IsNegative bool        `json:"is_negative"`
+}
+
+type forecastRecord struct {
+	Currency        string              `json:"currency"`
+	From            string              `json:"from"`
+	To              string              `json:"to"`
+	StartingBalance money.Money         `json:"starting_balance"`
+	Lowest          forecastDayRecord   `json:"lowest"`
+	Days            []forecastDayRecord `json:"days"`
+}
+
+type netWorthGroupRecord struct {
+	AccountGroupID int64       `json:"account_group_id"`
+	AccountGroup   string      `json:"account_group"`
+	Assets         money.Money `json:"assets"`
+	Liabilities    money.Money `json:"liabilities"`
+	NetWorth       money.Money `json:"net_worth"`
+}
+
+type netWorthHistoryRecord struct {
+	Date        string      `json:"date"`
+	Assets      money.Money `json:"assets"`
+	Liabilities money.Money `json:"liabilities"`
+	NetWorth    money.Money `json:"net_worth"`
+}
+
+type netWorthRecord struct {
+	Currency    string                  `json:"currency"`
+	Date        string                  `json:"date"`
+	Assets      money.Money             `json:"assets"`
+	Liabilities money.Money             `json:"liabilities"`
+	NetWorth    money.Money             `json:"net_worth"`
+	Groups      []netWorthGroupRecord   `json:"groups"`
+	History     []netWorthHistoryRecord `json:"history"`
+}
+
+type budgetLineRecord struct {
+	BucketID int64       `json:"bucket_id"`
+	Bucket   string      `json:"bucket"`
+	Planned  money.Money `json:"planned"`
+	Actual   money.Money `json:"actual"`
+	Variance money.Money `json:"variance"`
+}
+
+type budgetGroupRecord struct {
+	BucketGroupID int64              `json:"bucket_group_id"`
+	BucketGroup   string             `json:"bucket_group"`
+	Currency      string             `json:"currency"`
+	Planned       money.Money        `json:"planned"`
+	Actual        money.Money        `json:"actual"`
+	Variance      money.Money        `json:"variance"`
+	Buckets       []budgetLineRecord `json:"buckets"`
+}
+
+type budgetMonthRecord struct {
+	Month  string              `json:"month"`
+	From   string              `json:"from"`
+	To     string              `json:"to"`
+	Groups []budgetGroupRecord `json:"groups"`
+}
+
+type importRecord struct {
+	Status           string      `json:"status"`
+	Line             int         `json:"line"`
+	Date             string      `json:"date"`
+	Payee            string      `json:"payee"`
+	Memo             string      `json:"memo"`
+	Amount           money.Money `json:"amount"`
+	TransactionID    int64       `json:"transaction_id"`
+	TransactionDate  string      `json:"transaction_date"`
+	TransactionPayee string      `json:"transaction_payee"`
+	Similarity       float64     `json:"similarity"`
+}
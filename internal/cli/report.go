@@ -0,0 +1,569 @@
+package cli
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/internal/report"
+)
+
+func ReportForecast(database *sql.DB, from time.Time, months int, format string, verbose bool) error {
+	if months <= 0 {
+		return errors.Errorf("invalid number of months: %d", months)
+	}
+
+	accounts, err := api.GetAccounts(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch accounts")
+	}
+
+	transactions, err := api.GetTransactions(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transactions")
+	}
+
+	bucketsMap, err := api.GetBucketsMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets map")
+	}
+
+	spendingPlanEvents, err := api.GetSpendingPlan(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch spending plan")
+	}
+
+	recurrenceRulesMap, err := api.GetRecurrenceRulesMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch recurrence rules")
+	}
+
+	forecasts := report.GetForecast(
+		from,
+		from.AddDate(0, months, -1),
+		accounts,
+		transactions,
+		bucketsMap,
+		spendingPlanEvents,
+		recurrenceRulesMap,
+	)
+
+	if format != FormatTable {
+		writer := newRecordWriter(format, "currency", "date", "change", "balance", "is_negative")
+		for _, forecast := range forecasts {
+			record := forecastRecord{
+				Currency:        forecast.Currency,
+				From:            formatDate(forecast.From),
+				To:              formatDate(forecast.To),
+				StartingBalance: forecast.StartingBalance,
+				Lowest:          newForecastDayRecord(forecast.Lowest),
+				Days:            make([]forecastDayRecord, 0, len(forecast.Days)),
+			}
+
+			for _, day := range forecast.Days {
+				record.Days = append(record.Days, newForecastDayRecord(day))
+				writer.addRow(
+					forecast.Currency,
+					formatDate(day.Date),
+					formatAmount(day.Change),
+					formatAmount(day.Balance),
+					formatBool(day.IsNegative()),
+				)
+			}
+
+			writer.addRecord(record)
+		}
+
+		return writer.flush()
+	}
+
+	for _, forecast := range forecasts {
+		fmt.Printf(
+			"%s\t%s\t\t%s\n",
+			forecast.From.Format("2006-01-02"),
+			forecast.Currency,
+			forecast.StartingBalance,
+		)
+
+		for _, day := range forecast.Days {
+			negative := ""
+			if day.IsNegative() {
+				negative = " (negative)"
+			}
+
+			fmt.Printf(
+				"%s\t%s\t%s\t%s%s\n",
+				day.Date.Format("2006-01-02"),
+				forecast.Currency,
+				day.Change,
+				day.Balance,
+				negative,
+			)
+
+			if verbose {
+				for _, event := range day.Events {
+					fmt.Printf(
+						"\t%s\t%s [%d]\n",
+						bucketsMap[event.Bucket].Name,
+						event.Amount,
+						event.SpendingPlan,
+					)
+				}
+			}
+		}
+
+		fmt.Printf(
+			"lowest\t%s\t%s\t%s\n",
+			forecast.Currency,
+			forecast.Lowest.Date.Format("2006-01-02"),
+			forecast.Lowest.Balance,
+		)
+	}
+
+	return nil
+}
+
+func newForecastDayRecord(day report.ForecastDay) forecastDayRecord {
+	return forecastDayRecord{
+		Date:       formatDate(day.Date),
+		Change:     day.Change,
+		Balance:    day.Balance,
+		IsNegative: day.IsNegative(),
+	}
+}
+
+func ReportBudget(database *sql.DB, from time.Time, to time.Time, format string, verbose bool) error {
+	bucketGroupsMap, err := api.GetBucketGroupsMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch bucket groups map")
+	}
+
+	buckets, err := api.GetBuckets(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets")
+	}
+
+	bucketsMap := make(map[int64]api.Bucket, len(buckets))
+	for _, bucket := range buckets {
+		bucketsMap[bucket.PrimaryKey] = bucket
+	}
+
+	transactions, err := api.GetTransactions(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transactions")
+	}
+
+	spendingPlanEvents, err := api.GetSpendingPlan(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch spending plan")
+	}
+
+	recurrenceRulesMap, err := api.GetRecurrenceRulesMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch recurrence rules")
+	}
+
+	budget := report.GetBudget(
+		from,
+		to,
+		buckets,
+		transactions,
+		spendingPlanEvents,
+		recurrenceRulesMap,
+	)
+
+	if format != FormatTable {
+		writer := newRecordWriter(
+			format,
+			"month",
+			"bucket_group_id",
+			"bucket_group",
+			"bucket_id",
+			"bucket",
+			"currency",
+			"planned",
+			"actual",
+			"variance",
+		)
+		for _, month := range budget {
+			record := budgetMonthRecord{
+				Month:  month.Month.Format("2006-01"),
+				From:   formatDate(month.From),
+				To:     formatDate(month.To),
+				Groups: make([]budgetGroupRecord, 0, len(month.Groups)),
+			}
+
+			for _, group := range month.Groups {
+				groupName := bucketGroupsMap[group.BucketGroup].Name
+				groupRecord := budgetGroupRecord{
+					BucketGroupID: group.BucketGroup,
+					BucketGroup:   groupName,
+					Currency:      group.Currency,
+					Planned:       group.Planned,
+					Actual:        group.Actual,
+					Variance:      group.GetVariance(),
+					Buckets:       make([]budgetLineRecord, 0, len(group.Lines)),
+				}
+
+				for _, line := range group.Lines {
+					bucket := bucketsMap[line.Bucket]
+					groupRecord.Buckets = append(groupRecord.Buckets, budgetLineRecord{
+						BucketID: line.Bucket,
+						Bucket:   bucket.Name,
+						Planned:  line.Planned,
+						Actual:   line.Actual,
+						Variance: line.GetVariance(),
+					})
+					writer.addRow(
+						record.Month,
+						formatInt(group.BucketGroup),
+						groupName,
+						formatInt(line.Bucket),
+						bucket.Name,
+						group.Currency,
+						formatAmount(line.Planned),
+						formatAmount(line.Actual),
+						formatAmount(line.GetVariance()),
+					)
+				}
+
+				// Follow the buckets with the group total, identified by an empty bucket.
+				writer.addRow(
+					record.Month,
+					formatInt(group.BucketGroup),
+					groupName,
+					formatInt(0),
+					"",
+					group.Currency,
+					formatAmount(group.Planned),
+					formatAmount(group.Actual),
+					formatAmount(group.GetVariance()),
+				)
+
+				record.Groups = append(record.Groups, groupRecord)
+			}
+
+			writer.addRecord(record)
+		}
+
+		return writer.flush()
+	}
+
+	for _, month := range budget {
+		fmt.Printf("%s\tplanned\tactual\tvariance\n", month.Month.Format("January 2006"))
+
+		for _, group := range month.Groups {
+			groupName := "(no group)"
+			if group.BucketGroup > 0 {
+				groupName = bucketGroupsMap[group.BucketGroup].Name
+			}
+
+			primaryKey := ""
+			if verbose && group.BucketGroup > 0 {
+				primaryKey = fmt.Sprintf(" [%d]", group.BucketGroup)
+			}
+
+			fmt.Printf(
+				"    %s%s\t%s\t%s\t%s\n",
+				groupName,
+				primaryKey,
+				group.Planned,
+				group.Actual,
+				group.GetVariance(),
+			)
+
+			for _, line := range group.Lines {
+				primaryKey := ""
+				if verbose {
+					primaryKey = fmt.Sprintf(" [%d]", line.Bucket)
+				}
+
+				fmt.Printf(
+					"        %s%s\t%s\t%s\t%s\n",
+					bucketsMap[line.Bucket].Name,
+					primaryKey,
+					line.Planned,
+					line.Actual,
+					line.GetVariance(),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// balanceIntervals maps the supported -interval values to the api balance intervals.
+var balanceIntervals = map[string]int{
+	"daily":   api.BalanceIntervalDaily,
+	"monthly": api.BalanceIntervalMonthly,
+}
+
+func ReportBalanceHistory(
+	database *sql.DB,
+	accountFilter,
+	bucketFilter string,
+	from time.Time,
+	to time.Time,
+	interval string,
+	format string,
+	verbose bool,
+) error {
+	balanceInterval, ok := balanceIntervals[interval]
+	if !ok {
+		return errors.Errorf("unsupported interval: %s", interval)
+	}
+
+	settings, err := api.GetSettings(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get settings")
+	}
+
+	accounts, err := api.GetAccounts(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch accounts")
+	}
+
+	buckets, err := api.GetBuckets(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch buckets")
+	}
+
+	transactions, err := api.GetTransactions(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transactions")
+	}
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get bucket transfers")
+	}
+
+	type series struct {
+		seriesType string
+		id         int64
+		name       string
+		history    []api.BalancePoint
+	}
+
+	// Chart every account unless a specific account or bucket was requested.
+	allSeries := []series{}
+	if len(bucketFilter) == 0 {
+		for _, account := range accounts {
+			if len(accountFilter) > 0 && account.Name != accountFilter {
+				continue
+			}
+
+			history, err := api.GetAccountBalanceHistory(
+				account,
+				transactions,
+				from,
+				to,
+				balanceInterval,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get balance history for %s", account.Name)
+			}
+
+			allSeries = append(allSeries, series{"account", account.PrimaryKey, account.Name, history})
+		}
+	}
+
+	if len(bucketFilter) > 0 {
+		for _, bucket := range buckets {
+			if bucket.Name != bucketFilter {
+				continue
+			}
+
+			events, err := api.GetBucketEvents(bucket, transactions, bucketTransfers)
+			if err != nil {
+				return errors.Wrap(err, "failed to get bucket events")
+			}
+
+			history, err := api.GetBucketBalanceHistory(
+				bucket,
+				events,
+				settings,
+				from,
+				to,
+				balanceInterval,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get balance history for %s", bucket.Name)
+			}
+
+			allSeries = append(allSeries, series{"bucket", bucket.PrimaryKey, bucket.Name, history})
+		}
+	}
+
+	if len(allSeries) == 0 {
+		return errors.New("no such account or bucket")
+	}
+
+	if format != FormatTable {
+		writer := newRecordWriter(format, "date", "type", "id", "name", "currency", "balance")
+		for _, series := range allSeries {
+			for _, point := range series.history {
+				writer.add(
+					balanceRecord{
+						Date:    formatDate(point.Date),
+						Type:    series.seriesType,
+						ID:      series.id,
+						Name:    series.name,
+						Balance: point.Balance,
+					},
+					formatDate(point.Date),
+					series.seriesType,
+					formatInt(series.id),
+					series.name,
+					point.Balance.Currency,
+					formatAmount(point.Balance),
+				)
+			}
+		}
+
+		return writer.flush()
+	}
+
+	for _, series := range allSeries {
+		primaryKey := ""
+		if verbose {
+			primaryKey = fmt.Sprintf(" [%d]", series.id)
+		}
+		fmt.Printf("%s%s\n", series.name, primaryKey)
+
+		for _, point := range series.history {
+			fmt.Printf("\t%s\t%s\n", point.Date.Format("2006-01-02"), point.Balance)
+		}
+	}
+
+	return nil
+}
+
+func ReportNetWorth(database *sql.DB, date time.Time, format string, verbose bool) error {
+	accountGroupsMap, err := api.GetAccountGroupsMap(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch account groups map")
+	}
+
+	accounts, err := api.GetAccounts(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch accounts")
+	}
+
+	transactions, err := api.GetTransactions(database)
+	if err != nil {
+		return errors.Wrap(err, "failed to get transactions")
+	}
+
+	netWorths := report.GetNetWorth(date, accounts, transactions)
+	history := report.GetNetWorthHistory(date, accounts, transactions)
+
+	describeAccountGroup := func(accountGroup int64) string {
+		if accountGroup == 0 {
+			return "(no group)"
+		}
+
+		return accountGroupsMap[accountGroup].Name
+	}
+
+	// getSeries returns the month end history in the currency of the given net worth, ending
+	// with the net worth itself.
+	getSeries := func(netWorth report.NetWorth) []report.NetWorth {
+		series := []report.NetWorth{}
+		for _, month := range history {
+			if month.Currency == netWorth.Currency && month.Date.Before(netWorth.Date) {
+				series = append(series, month)
+			}
+		}
+
+		return append(series, netWorth)
+	}
+
+	if format != FormatTable {
+		writer := newRecordWriter(format, "currency", "date", "assets", "liabilities", "net_worth")
+		for _, netWorth := range netWorths {
+			record := netWorthRecord{
+				Currency:    netWorth.Currency,
+				Date:        formatDate(netWorth.Date),
+				Assets:      netWorth.Assets,
+				Liabilities: netWorth.Liabilities,
+				NetWorth:    netWorth.GetTotal(),
+				Groups:      make([]netWorthGroupRecord, 0, len(netWorth.Groups)),
+				History:     []netWorthHistoryRecord{},
+			}
+
+			for _, group := range netWorth.Groups {
+				record.Groups = append(record.Groups, netWorthGroupRecord{
+					AccountGroupID: group.AccountGroup,
+					AccountGroup:   accountGroupsMap[group.AccountGroup].Name,
+					Assets:         group.Assets,
+					Liabilities:    group.Liabilities,
+					NetWorth:       group.GetTotal(),
+				})
+			}
+
+			for _, month := range getSeries(netWorth) {
+				record.History = append(record.History, netWorthHistoryRecord{
+					Date:        formatDate(month.Date),
+					Assets:      month.Assets,
+					Liabilities: month.Liabilities,
+					NetWorth:    month.GetTotal(),
+				})
+				writer.addRow(
+					month.Currency,
+					formatDate(month.Date),
+					formatAmount(month.Assets),
+					formatAmount(month.Liabilities),
+					formatAmount(month.GetTotal()),
+				)
+			}
+
+			writer.addRecord(record)
+		}
+
+		return writer.flush()
+	}
+
+	for _, netWorth := range netWorths {
+		series := getSeries(netWorth)
+		for _, month := range series[:len(series)-1] {
+			fmt.Printf(
+				"%s\t%s\t%s\n",
+				month.Date.Format("2006-01-02"),
+				month.Currency,
+				month.GetTotal(),
+			)
+		}
+
+		fmt.Printf(
+			"%s\t%s\t%s\t(assets %s, liabilities %s)\n",
+			netWorth.Date.Format("2006-01-02"),
+			netWorth.Currency,
+			netWorth.GetTotal(),
+			netWorth.Assets,
+			netWorth.Liabilities,
+		)
+
+		for _, group := range netWorth.Groups {
+			primaryKey := ""
+			if verbose && group.AccountGroup > 0 {
+				primaryKey = fmt.Sprintf(" [%d]", group.AccountGroup)
+			}
+
+			fmt.Printf(
+				"\t%s%s\t%s\t(assets %s, liabilities %s)\n",
+				describeAccountGroup(group.AccountGroup),
+				primaryKey,
+				group.GetTotal(),
+				group.Assets,
+				group.Liabilities,
+			)
+		}
+	}
+
+	return nil
+}
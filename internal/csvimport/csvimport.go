@@ -0,0 +1,324 @@
+// Package csvimport reads bank statements exported as CSV and matches them against the
+// transactions already in a MoneyWell document, without changing the document.
+package csvimport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/match"
+)
+
+const (
+	// DefaultDateFormat is the layout of the dates in a bank statement, unless configured.
+	DefaultDateFormat = "2006-01-02"
+	// DefaultDecimalSeparator separates the whole and fractional parts of the amounts in a bank
+	// statement, unless configured.
+	DefaultDecimalSeparator = "."
+	// DefaultDateWindow is the number of days by which a row and a transaction may differ and
+	// still match, unless configured.
+	DefaultDateWindow = match.DefaultDateWindow
+	// DefaultPayeeThreshold is the minimum payee similarity for a row and a transaction to
+	// match, unless configured.
+	DefaultPayeeThreshold = match.DefaultPayeeThreshold
+)
+
+// Columns names the header of the column holding each field of a bank statement.
+//
+// A statement gives either a single signed amount, or separate debit and credit columns whose
+// values are both positive.
+type Columns struct {
+	Date   string `json:"date"`
+	Amount string `json:"amount"`
+	Debit  string `json:"debit"`
+	Credit string `json:"credit"`
+	Payee  string `json:"payee"`
+	Memo   string `json:"memo"`
+}
+
+// Config describes the layout of a bank statement and how closely its rows must match. It is
+// typically loaded from a JSON file such as:
+//
+//	{"columns": {"date": "Posted", "amount": "Amount", "payee": "Description"}, "date_format": "01/02/2006"}
+type Config struct {
+	Columns    Columns `json:"columns"`
+	DateFormat string  `json:"date_format"`
+	// Delimiter separates the columns, defaulting to a comma.
+	Delimiter string `json:"delimiter"`
+	// DecimalSeparator separates the whole and fractional parts of amounts, either a period or a
+	// comma, defaulting to a period. The other is taken to separate thousands.
+	DecimalSeparator string `json:"decimal_separator"`
+	// DateWindow is the number of days by which a row and a transaction may differ.
+	DateWindow int `json:"date_window"`
+	// PayeeThreshold is the minimum similarity, between 0 and 1, of the payees of a row and a
+	// transaction.
+	PayeeThreshold float64 `json:"payee_threshold"`
+}
+
+// NewConfig creates a config for a statement with date, amount, payee and memo columns.
+func NewConfig() Config {
+	return Config{
+		Columns: Columns{
+			Date:   "date",
+			Amount: "amount",
+			Payee:  "payee",
+			Memo:   "memo",
+		},
+		DateFormat:       DefaultDateFormat,
+		Delimiter:        ",",
+		DecimalSeparator: DefaultDecimalSeparator,
+		DateWindow:       DefaultDateWindow,
+		PayeeThreshold:   DefaultPayeeThreshold,
+	}
+}
+
+// LoadConfig reads a Config from the JSON file at the given path, defaulting any unset fields to
+// those of NewConfig. The columns are only defaulted if none are given, and the date window and
+// payee threshold may be explicitly set to zero.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	// Zero is a meaningful date window or payee threshold, so distinguish those left unset.
+	var thresholds struct {
+		DateWindow     *int     `json:"date_window"`
+		PayeeThreshold *float64 `json:"payee_threshold"`
+	}
+	if err := json.Unmarshal(data, &thresholds); err != nil {
+		return Config{}, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	defaults := NewConfig()
+	if config.Columns == (Columns{}) {
+		config.Columns = defaults.Columns
+	}
+	if config.DateFormat == "" {
+		config.DateFormat = defaults.DateFormat
+	}
+	if config.Delimiter == "" {
+		config.Delimiter = defaults.Delimiter
+	}
+	if config.DecimalSeparator == "" {
+		config.DecimalSeparator = defaults.DecimalSeparator
+	}
+	if thresholds.DateWindow == nil {
+		config.DateWindow = defaults.DateWindow
+	}
+	if thresholds.PayeeThreshold == nil {
+		config.PayeeThreshold = defaults.PayeeThreshold
+	}
+
+	return config, nil
+}
+
+// Row is a single transaction read from a bank statement.
+type Row struct {
+	// Line is the line of the row within the statement, counting the header as line 1, ignoring
+	// blank lines and assuming no field spans more than one line.
+	Line   int
+	Date   time.Time
+	Amount money.Money
+	Payee  string
+	Memo   string
+}
+
+// ReadRows reads the rows of a bank statement with a header row, in the given currency.
+func ReadRows(r io.Reader, config Config, currency string) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if config.Delimiter != "" {
+		delimiter, _ := utf8.DecodeRuneInString(config.Delimiter)
+		reader.Comma = delimiter
+	}
+
+	dateFormat := config.DateFormat
+	if dateFormat == "" {
+		dateFormat = DefaultDateFormat
+	}
+
+	decimalSeparator := config.DecimalSeparator
+	if decimalSeparator == "" {
+		decimalSeparator = DefaultDecimalSeparator
+	}
+	if decimalSeparator != "." && decimalSeparator != "," {
+		return nil, errors.Errorf("invalid decimal separator %s", decimalSeparator)
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []Row{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read header")
+	}
+
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		indexes[strings.TrimSpace(name)] = i
+	}
+
+	var dateIndex, amountIndex, debitIndex, creditIndex, payeeIndex, memoIndex int
+	for _, column := range []struct {
+		name  string
+		index *int
+	}{
+		{config.Columns.Date, &dateIndex},
+		{config.Columns.Amount, &amountIndex},
+		{config.Columns.Debit, &debitIndex},
+		{config.Columns.Credit, &creditIndex},
+		{config.Columns.Payee, &payeeIndex},
+		{config.Columns.Memo, &memoIndex},
+	} {
+		*column.index = -1
+		if column.name == "" {
+			continue
+		}
+
+		index, ok := indexes[column.name]
+		if !ok {
+			return nil, errors.Errorf("failed to find column %s", column.name)
+		}
+		*column.index = index
+	}
+
+	if dateIndex < 0 {
+		return nil, errors.New("missing date column")
+	}
+	if amountIndex < 0 && debitIndex < 0 && creditIndex < 0 {
+		return nil, errors.New("missing amount, debit or credit column")
+	}
+
+	rows := []Row{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to read line %d", line)
+		}
+
+		getField := func(index int) string {
+			if index < 0 || index >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[index])
+		}
+
+		// Skip blank lines, such as those trailing some statements.
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateFormat, getField(dateIndex))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse date on line %d", line)
+		}
+
+		var amount int64
+		if amountIndex >= 0 {
+			amount, err = parseAmount(getField(amountIndex), decimalSeparator)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse amount on line %d", line)
+			}
+		} else {
+			debit, err := parseAmount(getField(debitIndex), decimalSeparator)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse debit on line %d", line)
+			}
+
+			credit, err := parseAmount(getField(creditIndex), decimalSeparator)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse credit on line %d", line)
+			}
+
+			amount = credit - abs(debit)
+		}
+
+		rows = append(rows, Row{
+			Line:   line,
+			Date:   date,
+			Amount: money.Money{Currency: currency, Amount: amount},
+			Payee:  getField(payeeIndex),
+			Memo:   getField(memoIndex),
+		})
+	}
+
+	return rows, nil
+}
+
+// amountPattern matches a decimal amount with an optional sign, a period as its decimal
+// separator and commas between each group of three digits, if any, of its whole part. The
+// amount is otherwise parsed by money.ParseAmount.
+var amountPattern = regexp.MustCompile(`^[-+]?(\d{1,3}(,\d{3})+|\d+)?(\.\d{1,2})?$`)
+
+// parseAmount parses a decimal amount as a number of cents, ignoring any currency symbols and
+// whitespace, and treating an amount in parentheses as negative. An empty amount is zero.
+//
+// Whichever of a period or comma isn't the given decimal separator separates thousands, but only
+// between groups of three digits. Any amount that might have been written with the other decimal
+// separator, such as 12,50 or 1.234 for a decimal separator of a period, is rejected rather than
+// misread.
+func parseAmount(amount string, decimalSeparator string) (int64, error) {
+	negative := false
+	if strings.HasPrefix(amount, "(") && strings.HasSuffix(amount, ")") {
+		negative = true
+		amount = amount[1 : len(amount)-1]
+	}
+
+	normalized := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.Sc, r) || unicode.IsSpace(r):
+			return -1
+		case decimalSeparator == "," && r == ',':
+			return '.'
+		case decimalSeparator == "," && r == '.':
+			return ','
+		}
+
+		return r
+	}, amount)
+	if normalized == "" {
+		return 0, nil
+	}
+
+	if !amountPattern.MatchString(normalized) {
+		return 0, errors.Errorf("invalid amount %s", amount)
+	}
+
+	cents, err := money.ParseAmount(strings.Replace(normalized, ",", "", -1))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if negative {
+		cents = -cents
+	}
+
+	return cents, nil
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
@@ -0,0 +1,166 @@
+package csvimport_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/csvimport"
+)
+
+func TestReadRows(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default columns", func(t *testing.T) {
+		statement := strings.Join([]string{
+			"date,amount,payee,memo",
+			"2017-11-05,-350.00,Grocery Store,Chick peas",
+			"",
+			"2017-11-10,\"1,000\",Work,",
+		}, "\n")
+
+		rows, err := csvimport.ReadRows(strings.NewReader(statement), csvimport.NewConfig(), "CAD")
+		assert.NoError(t, err)
+		assert.Equal(t, []csvimport.Row{
+			{
+				Line:   2,
+				Date:   time.Date(2017, 11, 5, 0, 0, 0, 0, time.UTC),
+				Amount: money.Money{Currency: "CAD", Amount: -35000},
+				Payee:  "Grocery Store",
+				Memo:   "Chick peas",
+			},
+			{
+				Line:   3,
+				Date:   time.Date(2017, 11, 10, 0, 0, 0, 0, time.UTC),
+				Amount: money.Money{Currency: "CAD", Amount: 100000},
+				Payee:  "Work",
+				Memo:   "",
+			},
+		}, rows)
+	})
+
+	t.Run("debit and credit columns", func(t *testing.T) {
+		statement := strings.Join([]string{
+			"Posted;Description;Debit;Credit",
+			"11/05/2017;GROCERY STORE;$350.00;",
+			"11/06/2017;REFUND;;(12.34)",
+			"11/07/2017;INTEREST;;0.01",
+		}, "\n")
+
+		config := csvimport.Config{
+			Columns: csvimport.Columns{
+				Date:   "Posted",
+				Debit:  "Debit",
+				Credit: "Credit",
+				Payee:  "Description",
+			},
+			DateFormat: "01/02/2006",
+			Delimiter:  ";",
+		}
+
+		rows, err := csvimport.ReadRows(strings.NewReader(statement), config, "CAD")
+		assert.NoError(t, err)
+		assert.Len(t, rows, 3)
+		assert.Equal(t, int64(-35000), rows[0].Amount.Amount)
+		assert.Equal(t, int64(-1234), rows[1].Amount.Amount)
+		assert.Equal(t, int64(1), rows[2].Amount.Amount)
+		assert.Equal(t, "GROCERY STORE", rows[0].Payee)
+	})
+
+	t.Run("decimal separator", func(t *testing.T) {
+		statement := strings.Join([]string{
+			"date;amount",
+			"2017-11-05;-1.234,56 €",
+			"2017-11-06;12,5",
+		}, "\n")
+
+		config := csvimport.NewConfig()
+		config.Columns = csvimport.Columns{Date: "date", Amount: "amount"}
+		config.Delimiter = ";"
+		config.DecimalSeparator = ","
+
+		rows, err := csvimport.ReadRows(strings.NewReader(statement), config, "EUR")
+		assert.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, int64(-123456), rows[0].Amount.Amount)
+		assert.Equal(t, int64(1250), rows[1].Amount.Amount)
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		config := csvimport.NewConfig()
+		config.Columns = csvimport.Columns{Date: "date", Amount: "amount"}
+
+		// Amounts that may have been written with a different decimal separator are rejected
+		// rather than misread.
+		amounts := []string{"12,50", "1.234", "1,2345.00", "NaN", "Inf", "1e5", "-", "1.2.3"}
+		for _, amount := range amounts {
+			statement := "date,amount\n2017-11-05,\"" + amount + "\"\n"
+
+			_, err := csvimport.ReadRows(strings.NewReader(statement), config, "CAD")
+			assert.Error(t, err, amount)
+		}
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		statement := "date,value\n2017-11-05,-350.00\n"
+
+		_, err := csvimport.ReadRows(strings.NewReader(statement), csvimport.NewConfig(), "CAD")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		statement := "date,amount\n05/11/2017,-350.00\n"
+
+		config := csvimport.NewConfig()
+		config.Columns = csvimport.Columns{Date: "date", Amount: "amount"}
+
+		_, err := csvimport.ReadRows(strings.NewReader(statement), config, "CAD")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	directory, err := ioutil.TempDir("", "csvimport")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "config.json")
+	err = ioutil.WriteFile(
+		path,
+		[]byte(`{"columns": {"date": "Posted", "amount": "Amount"}, "date_window": 5}`),
+		0600,
+	)
+	assert.NoError(t, err)
+
+	config, err := csvimport.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, csvimport.Config{
+		Columns:          csvimport.Columns{Date: "Posted", Amount: "Amount"},
+		DateFormat:       csvimport.DefaultDateFormat,
+		Delimiter:        ",",
+		DecimalSeparator: csvimport.DefaultDecimalSeparator,
+		DateWindow:       5,
+		PayeeThreshold:   csvimport.DefaultPayeeThreshold,
+	}, config)
+
+	// A date window or payee threshold of zero is kept rather than defaulted.
+	err = ioutil.WriteFile(path, []byte(`{"date_window": 0, "payee_threshold": 0}`), 0600)
+	assert.NoError(t, err)
+
+	config, err = csvimport.LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, config.DateWindow)
+	assert.Equal(t, 0.0, config.PayeeThreshold)
+	assert.Equal(t, csvimport.NewConfig().Columns, config.Columns)
+
+	_, err = csvimport.LoadConfig(filepath.Join(directory, "missing.json"))
+	assert.Error(t, err)
+}
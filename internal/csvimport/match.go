@@ -0,0 +1,159 @@
+package csvimport
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/internal/match"
+)
+
+// Match pairs a row of a bank statement with an existing transaction.
+type Match struct {
+	Row         Row
+	Transaction api.Transaction
+	// Similarity is the similarity of the payees, between 0 and 1.
+	Similarity float64
+}
+
+// Result classifies the rows of a bank statement against the existing transactions of an
+// account.
+type Result struct {
+	// New are the rows not matching any existing transaction, and so would be imported.
+	New []Row
+	// Matched are the rows matching an existing transaction, and so would be duplicates.
+	Matched []Match
+	// Missing are the existing transactions dated within the statement that match no row.
+	Missing []api.Transaction
+}
+
+// GetResult matches the rows of a bank statement against the existing transactions of the
+// given account.
+//
+// A row matches a transaction with the same amount, dated within the configured window of days
+// and whose payee is at least as similar as the configured threshold. Each row and transaction
+// matches at most once, preferring the most similar payees and then the closest dates. Voided
+// and pending transactions, split children and transactions of $0.00 are never matched, nor
+// reported missing. Rows of $0.00, such as balance lines, are likewise ignored.
+func GetResult(
+	rows []Row,
+	account api.Account,
+	transactions []api.Transaction,
+	config Config,
+) Result {
+	candidates := []api.Transaction{}
+	for _, transaction := range transactions {
+		if transaction.Account != account.PrimaryKey || transaction.SplitParent != 0 {
+			continue
+		}
+
+		switch transaction.Status {
+		case api.TransactionStatusVoided:
+			fallthrough
+		case api.TransactionStatusPending:
+			continue
+		}
+
+		if transaction.Amount.IsZero() {
+			continue
+		}
+
+		candidates = append(candidates, transaction)
+	}
+
+	type pair struct {
+		row         int
+		transaction int
+		days        int
+		similarity  float64
+	}
+
+	pairs := []pair{}
+	for i, row := range rows {
+		for j, transaction := range candidates {
+			if row.Amount.Amount != transaction.Amount.Amount {
+				continue
+			}
+
+			days := match.GetDays(row.Date, transaction.Date)
+			if days > config.DateWindow {
+				continue
+			}
+
+			similarity := match.GetPayeeSimilarity(row.Payee, transaction.Payee)
+			if similarity < config.PayeeThreshold {
+				continue
+			}
+
+			pairs = append(pairs, pair{
+				row:         i,
+				transaction: j,
+				days:        days,
+				similarity:  similarity,
+			})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].similarity != pairs[j].similarity {
+			return pairs[i].similarity > pairs[j].similarity
+		}
+
+		return pairs[i].days < pairs[j].days
+	})
+
+	matchedRows := make(map[int]int)
+	matchedTransactions := make(map[int]bool)
+	similarities := make(map[int]float64)
+	for _, pair := range pairs {
+		if _, ok := matchedRows[pair.row]; ok || matchedTransactions[pair.transaction] {
+			continue
+		}
+
+		matchedRows[pair.row] = pair.transaction
+		matchedTransactions[pair.transaction] = true
+		similarities[pair.row] = pair.similarity
+	}
+
+	result := Result{
+		New:     []Row{},
+		Matched: []Match{},
+		Missing: []api.Transaction{},
+	}
+
+	var from, to time.Time
+	for i, row := range rows {
+		if row.Amount.IsZero() {
+			continue
+		}
+
+		if from.IsZero() || row.Date.Before(from) {
+			from = row.Date
+		}
+		if row.Date.After(to) {
+			to = row.Date
+		}
+
+		transaction, ok := matchedRows[i]
+		if !ok {
+			result.New = append(result.New, row)
+			continue
+		}
+
+		result.Matched = append(result.Matched, Match{
+			Row:         row,
+			Transaction: candidates[transaction],
+			Similarity:  similarities[i],
+		})
+	}
+
+	for j, transaction := range candidates {
+		if matchedTransactions[j] || transaction.Date.Before(from) || transaction.Date.After(to) {
+			continue
+		}
+
+		result.Missing = append(result.Missing, transaction)
+	}
+
+	return result
+}
@@ -0,0 +1,100 @@
+package csvimport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/csvimport"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestGetResult(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	accounts, err := api.GetAccountsMap(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	row := func(line int, day int, amount int64, payee string) csvimport.Row {
+		return csvimport.Row{
+			Line:   line,
+			Date:   time.Date(2017, 11, day, 0, 0, 0, 0, time.UTC),
+			Amount: money.Money{Currency: "CAD", Amount: amount},
+			Payee:  payee,
+		}
+	}
+
+	rows := []csvimport.Row{
+		row(2, 1, 100000, "PAYROLL WORK INC"),
+		row(3, 7, -35000, "GROCERY STORE #123 TORONTO"),
+		// Outside the date window of the rent.
+		row(4, 14, -50000, "Rent"),
+		// A dissimilar payee for the split transaction.
+		row(5, 12, -50000, "Hardware"),
+		row(6, 13, -1050, "Bookstore"),
+		// Neither new nor extending the statement.
+		row(7, 30, 0, "Closing Balance"),
+	}
+
+	result := csvimport.GetResult(rows, accounts[1], transactions, csvimport.NewConfig())
+
+	assert.Equal(t, []csvimport.Row{rows[2], rows[3], rows[4]}, result.New)
+
+	assert.Len(t, result.Matched, 2)
+	assert.Equal(t, rows[0], result.Matched[0].Row)
+	assert.EqualValues(t, 2, result.Matched[0].Transaction.PrimaryKey)
+	assert.Equal(t, 1.0, result.Matched[0].Similarity)
+	assert.Equal(t, rows[1], result.Matched[1].Row)
+	assert.EqualValues(t, 4, result.Matched[1].Transaction.PrimaryKey)
+
+	// The voided transaction on 2017-11-25 is outside the statement, and never missing anyway.
+	missing := []int64{}
+	for _, transaction := range result.Missing {
+		missing = append(missing, transaction.PrimaryKey)
+	}
+	assert.Equal(t, []int64{5, 14}, missing)
+}
+
+func TestGetResultPrefersSimilarPayees(t *testing.T) {
+	t.Parallel()
+
+	account := api.Account{PrimaryKey: 1}
+	transaction := func(primaryKey int64, day int, payee string) api.Transaction {
+		return api.Transaction{
+			PrimaryKey: primaryKey,
+			Date:       time.Date(2017, 11, day, 0, 0, 0, 0, time.UTC),
+			Amount:     money.Money{Currency: "CAD", Amount: -1000},
+			Account:    1,
+			Status:     api.TransactionStatusOpen,
+			Payee:      payee,
+		}
+	}
+
+	transactions := []api.Transaction{
+		transaction(1, 10, "Coffee Shop"),
+		transaction(2, 11, "Book Shop"),
+	}
+	rows := []csvimport.Row{{
+		Line:   2,
+		Date:   time.Date(2017, 11, 10, 0, 0, 0, 0, time.UTC),
+		Amount: money.Money{Currency: "CAD", Amount: -1000},
+		Payee:  "BOOK SHOP",
+	}}
+
+	result := csvimport.GetResult(rows, account, transactions, csvimport.NewConfig())
+	assert.Len(t, result.Matched, 1)
+	assert.EqualValues(t, 2, result.Matched[0].Transaction.PrimaryKey)
+	assert.Len(t, result.Missing, 1)
+	assert.EqualValues(t, 1, result.Missing[0].PrimaryKey)
+}
@@ -0,0 +1,317 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// Divergence represents the first date on which the running total of the accounts inside the
+// cash flow no longer matched the running total of all buckets in a single currency.
+type Divergence struct {
+	Currency     string
+	Date         time.Time
+	AccountTotal money.Money
+	BucketTotal  money.Money
+	// IsStartingBalance is true if the bucket starting balances already failed to match the
+	// account balances at the cash flow start date, before replaying any transactions.
+	IsStartingBalance bool
+	// Transactions are the transactions against accounts in the given currency on the date.
+	Transactions []api.Transaction
+}
+
+// GetImbalance returns the amount by which the account total exceeded the bucket total.
+func (d Divergence) GetImbalance() money.Money {
+	return d.AccountTotal.Add(d.BucketTotal.Multiply(-1))
+}
+
+// GetDivergences replays the transactions and bucket transfers in date order from the cash flow
+// start date, returning the first date on which the running account and bucket totals diverged
+// for each currency, sorted by currency. Currencies that never diverge are omitted.
+//
+// The account total starts from the balance of the accounts inside the cash flow as of the cash
+// flow start date, and the bucket total from the bucket starting balances. Voided and pending
+// transactions are ignored, as are split children against accounts, since the split parent
+// already counts towards the account total.
+func GetDivergences(
+	settings api.Settings,
+	accounts []api.Account,
+	buckets []api.Bucket,
+	transactions []api.Transaction,
+	bucketTransfers []api.BucketTransfer,
+) []Divergence {
+	accountsMap := make(map[int64]api.Account, len(accounts))
+	for _, account := range accounts {
+		accountsMap[account.PrimaryKey] = account
+	}
+
+	type change struct {
+		account money.Money
+		bucket  money.Money
+	}
+
+	currencies := make(map[string]bool)
+	accountTotals := make(map[string]money.Money)
+	bucketTotals := make(map[string]money.Money)
+	changes := make(map[string]map[time.Time]*change)
+	transactionsByDate := make(map[string]map[time.Time][]api.Transaction)
+	getChange := func(currency string, date time.Time) *change {
+		currencies[currency] = true
+		if changes[currency] == nil {
+			changes[currency] = make(map[time.Time]*change)
+		}
+		if changes[currency][date] == nil {
+			changes[currency][date] = &change{}
+		}
+
+		return changes[currency][date]
+	}
+
+	for _, bucket := range buckets {
+		currency := bucket.StartingBalance.Currency
+		if currency == "" {
+			currency = bucket.CurrencyCode
+		}
+		if currency == "" && bucket.StartingBalance.IsZero() {
+			continue
+		}
+
+		currencies[currency] = true
+		bucketTotals[currency] = bucketTotals[currency].Add(bucket.StartingBalance)
+	}
+
+	for _, transaction := range transactions {
+		account := accountsMap[transaction.Account]
+		currency := transaction.Amount.Currency
+		if currency == "" {
+			currency = account.CurrencyCode
+		}
+
+		if !transaction.Date.Before(settings.CashFlowStartDate) {
+			if transactionsByDate[currency] == nil {
+				transactionsByDate[currency] = make(map[time.Time][]api.Transaction)
+			}
+			transactionsByDate[currency][transaction.Date] = append(
+				transactionsByDate[currency][transaction.Date],
+				transaction,
+			)
+		}
+
+		switch transaction.Status {
+		case api.TransactionStatusVoided:
+			fallthrough
+		case api.TransactionStatusPending:
+			continue
+		}
+
+		countsTowardsAccount := account.IncludeInCashFlow && transaction.SplitParent == 0
+		if transaction.Date.Before(settings.CashFlowStartDate) {
+			if countsTowardsAccount {
+				currencies[currency] = true
+				accountTotals[currency] = accountTotals[currency].Add(transaction.Amount)
+			}
+			continue
+		}
+
+		if countsTowardsAccount {
+			dayChange := getChange(currency, transaction.Date)
+			dayChange.account = dayChange.account.Add(transaction.Amount)
+		}
+		if transaction.Bucket != 0 {
+			dayChange := getChange(currency, transaction.Date)
+			dayChange.bucket = dayChange.bucket.Add(transaction.Amount)
+		}
+	}
+
+	for _, bucketTransfer := range bucketTransfers {
+		if bucketTransfer.Date.Before(settings.CashFlowStartDate) {
+			continue
+		}
+
+		dayChange := getChange(bucketTransfer.Amount.Currency, bucketTransfer.Date)
+		dayChange.bucket = dayChange.bucket.Add(bucketTransfer.Amount)
+	}
+
+	sortedCurrencies := make([]string, 0, len(currencies))
+	for currency := range currencies {
+		sortedCurrencies = append(sortedCurrencies, currency)
+	}
+	sort.Strings(sortedCurrencies)
+
+	divergences := []Divergence{}
+	for _, currency := range sortedCurrencies {
+		accountTotal := accountTotals[currency].Add(money.Money{Currency: currency})
+		bucketTotal := bucketTotals[currency].Add(money.Money{Currency: currency})
+
+		if accountTotal.Amount != bucketTotal.Amount {
+			divergences = append(divergences, Divergence{
+				Currency:          currency,
+				Date:              settings.CashFlowStartDate,
+				AccountTotal:      accountTotal,
+				BucketTotal:       bucketTotal,
+				IsStartingBalance: true,
+				Transactions:      []api.Transaction{},
+			})
+			continue
+		}
+
+		dates := make([]time.Time, 0, len(changes[currency]))
+		for date := range changes[currency] {
+			dates = append(dates, date)
+		}
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+		for _, date := range dates {
+			accountTotal = accountTotal.Add(changes[currency][date].account)
+			bucketTotal = bucketTotal.Add(changes[currency][date].bucket)
+
+			if accountTotal.Amount != bucketTotal.Amount {
+				dateTransactions := transactionsByDate[currency][date]
+				if dateTransactions == nil {
+					dateTransactions = []api.Transaction{}
+				}
+
+				divergences = append(divergences, Divergence{
+					Currency:     currency,
+					Date:         date,
+					AccountTotal: accountTotal,
+					BucketTotal:  bucketTotal,
+					Transactions: dateTransactions,
+				})
+				break
+			}
+		}
+	}
+
+	return divergences
+}
+
+type transactionRecord struct {
+	Transaction int64       `json:"transaction_id"`
+	AccountID   int64       `json:"account_id"`
+	Account     string      `json:"account"`
+	Date        string      `json:"date"`
+	Amount      money.Money `json:"amount"`
+	Payee       string      `json:"payee"`
+	Memo        string      `json:"memo"`
+}
+
+// divergenceRecord is the stable JSON shape of a Divergence.
+type divergenceRecord struct {
+	Currency          string              `json:"currency"`
+	Date              string              `json:"date"`
+	AccountTotal      money.Money         `json:"account_total"`
+	BucketTotal       money.Money         `json:"bucket_total"`
+	Imbalance         money.Money         `json:"imbalance"`
+	IsStartingBalance bool                `json:"is_starting_balance"`
+	Transactions      []transactionRecord `json:"transactions"`
+}
+
+type bisectionRecord struct {
+	Divergences []divergenceRecord `json:"divergences"`
+}
+
+// Bisect replays the given MoneyWell document to find, printing and returning the first date
+// on which the accounts and buckets diverged.
+func Bisect(moneywellPath string, options Options) ([]Divergence, error) {
+	format, err := getFormat(options)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	document, err := loadDocument(moneywellPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	accountsMap := make(map[int64]api.Account, len(document.Accounts))
+	for _, account := range document.Accounts {
+		accountsMap[account.PrimaryKey] = account
+	}
+
+	divergences := GetDivergences(
+		document.Settings,
+		document.Accounts,
+		document.Buckets,
+		document.Transactions,
+		document.BucketTransfers,
+	)
+
+	switch format {
+	case FormatJSON:
+		record := bisectionRecord{
+			Divergences: make([]divergenceRecord, 0, len(divergences)),
+		}
+		for _, divergence := range divergences {
+			transactions := make([]transactionRecord, 0, len(divergence.Transactions))
+			for _, transaction := range divergence.Transactions {
+				transactions = append(transactions, transactionRecord{
+					Transaction: transaction.PrimaryKey,
+					AccountID:   transaction.Account,
+					Account:     accountsMap[transaction.Account].Name,
+					Date:        transaction.Date.Format("2006-01-02"),
+					Amount:      transaction.Amount,
+					Payee:       transaction.Payee,
+					Memo:        transaction.Memo,
+				})
+			}
+
+			record.Divergences = append(record.Divergences, divergenceRecord{
+				Currency:          divergence.Currency,
+				Date:              divergence.Date.Format("2006-01-02"),
+				AccountTotal:      divergence.AccountTotal,
+				BucketTotal:       divergence.BucketTotal,
+				Imbalance:         divergence.GetImbalance(),
+				IsStartingBalance: divergence.IsStartingBalance,
+				Transactions:      transactions,
+			})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(record); err != nil {
+			return nil, errors.Wrap(err, "failed to encode json")
+		}
+
+	default:
+		for _, divergence := range divergences {
+			if divergence.IsStartingBalance {
+				fmt.Printf(
+					"ERROR: accounts in the cash flow total %s but bucket starting balances total %s at the cash flow start date %s (off by %s)\n",
+					divergence.AccountTotal,
+					divergence.BucketTotal,
+					divergence.Date.Format("2006-01-02"),
+					divergence.GetImbalance(),
+				)
+				continue
+			}
+
+			fmt.Printf(
+				"ERROR: accounts in the cash flow first diverged from buckets on %s, totalling %s against %s (off by %s)\n",
+				divergence.Date.Format("2006-01-02"),
+				divergence.AccountTotal,
+				divergence.BucketTotal,
+				divergence.GetImbalance(),
+			)
+			for _, transaction := range divergence.Transactions {
+				fmt.Printf(
+					"    %s\n",
+					describeTransaction(
+						describeTransactionKind(transaction),
+						accountsMap[transaction.Account],
+						transaction,
+					),
+				)
+			}
+		}
+	}
+
+	return divergences, nil
+}
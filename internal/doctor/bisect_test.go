@@ -0,0 +1,104 @@
+package doctor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/doctor"
+)
+
+func TestGetDivergences(t *testing.T) {
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	settings, err := api.GetSettings(database)
+	assert.NoError(t, err)
+
+	accounts, err := api.GetAccounts(database)
+	assert.NoError(t, err)
+
+	buckets, err := api.GetBuckets(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	bucketTransfers, err := api.GetBucketTransfers(database)
+	assert.NoError(t, err)
+
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+
+	t.Run("diverged after replaying transactions", func(t *testing.T) {
+		divergences := doctor.GetDivergences(
+			settings,
+			accounts,
+			buckets,
+			transactions,
+			bucketTransfers,
+		)
+		assert.Len(t, divergences, 1)
+
+		divergence := divergences[0]
+		assert.Equal(t, "CAD", divergence.Currency)
+		assert.Equal(t, time.Date(2017, 11, 19, 0, 0, 0, 0, time.UTC), divergence.Date)
+		assert.Equal(t, cad(-23002), divergence.AccountTotal)
+		assert.Equal(t, cad(-27500), divergence.BucketTotal)
+		assert.Equal(t, cad(4498), divergence.GetImbalance())
+		assert.False(t, divergence.IsStartingBalance)
+
+		transactionPrimaryKeys := []int64{}
+		for _, transaction := range divergence.Transactions {
+			transactionPrimaryKeys = append(transactionPrimaryKeys, transaction.PrimaryKey)
+		}
+		assert.Equal(
+			t,
+			[]int64{3, 4, 6, 9, 10, 11, 15, 25, 27, 13, 20, 17, 28, 19, 21},
+			transactionPrimaryKeys,
+		)
+	})
+
+	t.Run("diverged at the cash flow start date", func(t *testing.T) {
+		mismatchedBuckets := append([]api.Bucket{}, buckets...)
+		mismatchedBuckets[0].StartingBalance = cad(100)
+
+		divergences := doctor.GetDivergences(
+			settings,
+			accounts,
+			mismatchedBuckets,
+			transactions,
+			bucketTransfers,
+		)
+		assert.Len(t, divergences, 1)
+
+		divergence := divergences[0]
+		assert.Equal(t, settings.CashFlowStartDate, divergence.Date)
+		assert.Equal(t, cad(-100), divergence.GetImbalance())
+		assert.True(t, divergence.IsStartingBalance)
+		assert.Empty(t, divergence.Transactions)
+	})
+
+	t.Run("never diverged", func(t *testing.T) {
+		var balancedTransactions []api.Transaction
+		for _, transaction := range transactions {
+			if transaction.Date.Before(settings.CashFlowStartDate) {
+				balancedTransactions = append(balancedTransactions, transaction)
+			}
+		}
+
+		divergences := doctor.GetDivergences(
+			settings,
+			accounts,
+			buckets,
+			balancedTransactions,
+			nil,
+		)
+		assert.Empty(t, divergences)
+	})
+}
@@ -0,0 +1,37 @@
+package doctor
+
+import (
+	"github.com/lieut-data/go-moneywell/api/check"
+)
+
+// NewDefaultRegistry creates a registry with the built-in checks, all enabled.
+func NewDefaultRegistry() *check.Registry {
+	return check.NewRegistry(
+		check.New(
+			[]int{ProblemSplitParentAssignedBucket, ProblemNotFullySplit},
+			checkSplitTransaction,
+		),
+		check.New(
+			[]int{
+				ProblemTransferInsideCashFlowAssignedBucket,
+				ProblemTransferOutsideCashFlowAssignedBucket,
+				ProblemTransferOutOfCashFlowMissingBucket,
+				ProblemTransferFromCashFlowAssignedBucket,
+			},
+			checkTransferTransaction,
+		),
+		check.New(
+			[]int{ProblemBucketOptionalInsideCashFlow},
+			checkBucketOptionalTransaction,
+		),
+		check.New(
+			[]int{ProblemMissingBucketInsideCashFlow},
+			checkMissingBucketTransaction,
+		),
+		check.New(
+			[]int{ProblemBucketOutsideCashFlow},
+			checkInvalidBucketTransaction,
+		),
+		newDuplicateCheck(),
+	)
+}
@@ -0,0 +1,79 @@
+package doctor_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/internal/doctor"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	getProblems := func(registry *check.Registry) []int {
+		problematicTransactions, err := registry.GetProblematicTransactions(document)
+		assert.NoError(t, err)
+
+		problems := []int{}
+		for _, problematicTransaction := range problematicTransactions {
+			problems = append(problems, problematicTransaction.Problem)
+		}
+
+		return problems
+	}
+
+	t.Run("default registry matches built-in checks", func(t *testing.T) {
+		registry := doctor.NewDefaultRegistry()
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, registry.GetProblems())
+
+		expectedProblematicTransactions, err := doctor.GetProblematicTransactions(document)
+		assert.NoError(t, err)
+
+		problematicTransactions, err := registry.GetProblematicTransactions(document)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedProblematicTransactions, problematicTransactions)
+	})
+
+	t.Run("disable and enable problems", func(t *testing.T) {
+		registry := doctor.NewDefaultRegistry()
+
+		assert.NoError(t, registry.Disable(
+			doctor.ProblemBucketOptionalInsideCashFlow,
+			doctor.ProblemTransferOutsideCashFlowAssignedBucket,
+		))
+		assert.False(t, registry.IsEnabled(doctor.ProblemBucketOptionalInsideCashFlow))
+		assert.Equal(t, []int{1, 3, 8, 8, 5, 6, 9}, getProblems(registry))
+
+		assert.NoError(t, registry.Enable(doctor.ProblemBucketOptionalInsideCashFlow))
+		assert.True(t, registry.IsEnabled(doctor.ProblemBucketOptionalInsideCashFlow))
+		assert.Equal(t, []int{1, 3, 7, 8, 8, 5, 6, 9}, getProblems(registry))
+	})
+
+	t.Run("disable unknown problem", func(t *testing.T) {
+		registry := doctor.NewDefaultRegistry()
+		assert.Error(t, registry.Disable(check.ProblemCustom))
+	})
+
+	t.Run("configure from file", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "doctor.json")
+		assert.NoError(t, ioutil.WriteFile(configPath, []byte(`{"disable": [1, 3, 5, 6]}`), 0600))
+
+		config, err := check.LoadConfig(configPath)
+		assert.NoError(t, err)
+		assert.Equal(t, check.Config{Disable: []int{1, 3, 5, 6}}, config)
+
+		registry := doctor.NewDefaultRegistry()
+		assert.NoError(t, registry.Configure(config))
+		assert.Equal(t, []int{7, 8, 8, 4, 9}, getProblems(registry))
+	})
+}
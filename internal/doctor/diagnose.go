@@ -1,50 +1,350 @@
 package doctor
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 
 	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// Diagnose analyzes the given MoneyWell document for potential issues.
-func Diagnose(moneywellPath string) error {
-	database, err := api.OpenDocument(moneywellPath)
+const (
+	// FormatText prints one line per problem, prefixed by its severity.
+	FormatText = "text"
+	// FormatJSON prints a single JSON object listing the problems.
+	FormatJSON = "json"
+)
+
+// Options configures how a MoneyWell document is diagnosed.
+type Options struct {
+	// Format is the output format, defaulting to FormatText.
+	Format string
+	// Registry is the set of checks to run, defaulting to the built-in checks.
+	Registry *check.Registry
+}
+
+// problemRecord is the stable JSON shape of a check.ProblematicTransaction.
+type problemRecord struct {
+	Problem     int         `json:"problem"`
+	Severity    string      `json:"severity"`
+	Transaction int64       `json:"transaction_id"`
+	AccountID   int64       `json:"account_id"`
+	Account     string      `json:"account"`
+	Date        string      `json:"date"`
+	Amount      money.Money `json:"amount"`
+	Payee       string      `json:"payee"`
+	Memo        string      `json:"memo"`
+	Related     []int64     `json:"related_ids"`
+	Description string      `json:"description"`
+}
+
+type contributionRecord struct {
+	Transaction int64       `json:"transaction_id"`
+	Amount      money.Money `json:"amount"`
+}
+
+// reconciliationRecord is the stable JSON shape of a Reconciliation.
+type reconciliationRecord struct {
+	Currency      string               `json:"currency"`
+	AccountTotal  money.Money          `json:"account_total"`
+	BucketTotal   money.Money          `json:"bucket_total"`
+	Imbalance     money.Money          `json:"imbalance"`
+	Explained     money.Money          `json:"explained"`
+	Unexplained   money.Money          `json:"unexplained"`
+	IsBalanced    bool                 `json:"is_balanced"`
+	IsExplained   bool                 `json:"is_explained"`
+	Contributions []contributionRecord `json:"contributions"`
+}
+
+// statementRecord is the stable JSON shape of an UnbalancedStatement.
+type statementRecord struct {
+	Statement         int64       `json:"statement_id"`
+	AccountID         int64       `json:"account_id"`
+	Account           string      `json:"account"`
+	StartingDate      string      `json:"starting_date"`
+	EndingDate        string      `json:"ending_date"`
+	StartingBalance   money.Money `json:"starting_balance"`
+	EndingBalance     money.Money `json:"ending_balance"`
+	ReconciledBalance money.Money `json:"reconciled_balance"`
+	Imbalance         money.Money `json:"imbalance"`
+}
+
+type diagnosisRecord struct {
+	Problems        []problemRecord        `json:"problems"`
+	Reconciliations []reconciliationRecord `json:"reconciliations"`
+	Statements      []statementRecord      `json:"statements"`
+}
+
+// Diagnosis represents the problems found in a MoneyWell document.
+type Diagnosis struct {
+	ProblematicTransactions []check.ProblematicTransaction
+	Reconciliations         []Reconciliation
+	UnbalancedStatements    []UnbalancedStatement
+}
+
+// HasProblems determines if any problematic transactions or unbalanced statements were found, or
+// if the accounts and buckets are out of balance.
+func (d Diagnosis) HasProblems() bool {
+	if len(d.ProblematicTransactions) > 0 || len(d.UnbalancedStatements) > 0 {
+		return true
+	}
+
+	for _, reconciliation := range d.Reconciliations {
+		if !reconciliation.IsBalanced() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Diagnose analyzes the given MoneyWell document for potential issues, printing and returning
+// the diagnosis.
+func Diagnose(moneywellPath string, options Options) (Diagnosis, error) {
+	format, err := getFormat(options)
 	if err != nil {
-		return errors.Wrapf(err, "failed to open %s", moneywellPath)
+		return Diagnosis{}, errors.WithStack(err)
 	}
-	defer database.Close()
 
-	settings, err := api.GetSettings(database)
+	document, err := loadDocument(moneywellPath)
 	if err != nil {
-		return errors.Wrap(err, "failed to get settings")
+		return Diagnosis{}, errors.WithStack(err)
 	}
 
-	accounts, err := api.GetAccounts(database)
+	diagnosis, err := getDiagnosis(document, options.Registry)
 	if err != nil {
-		return errors.Wrap(err, "failed to get accounts")
+		return Diagnosis{}, errors.WithStack(err)
+	}
+
+	switch format {
+	case FormatJSON:
+		err = printDiagnosisJSON(document.Accounts, document.Transactions, diagnosis)
+		if err != nil {
+			return Diagnosis{}, errors.WithStack(err)
+		}
+
+	default:
+		printDiagnosisText(document.Accounts, diagnosis)
 	}
 
-	transactions, err := api.GetTransactions(database)
+	return diagnosis, nil
+}
+
+// getDiagnosis analyzes the given document using the given registry, defaulting to the built-in
+// checks.
+func getDiagnosis(document *api.Document, registry *check.Registry) (Diagnosis, error) {
+	if registry == nil {
+		registry = NewDefaultRegistry()
+	}
+
+	problematicTransactions, err := registry.GetProblematicTransactions(document)
 	if err != nil {
-		return errors.Wrap(err, "failed to get transactions")
+		return Diagnosis{}, errors.Wrap(err, "failed to query for problematic transactions")
 	}
 
-	problematicTransactions, err := GetProblematicTransactions(
-		settings,
-		accounts,
-		transactions,
+	reconciliations, err := GetReconciliations(
+		document.Settings,
+		document.Accounts,
+		document.Buckets,
+		document.Transactions,
+		document.BucketTransfers,
+		problematicTransactions,
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to query for problematic transactions")
+		return Diagnosis{}, errors.Wrap(err, "failed to reconcile accounts and buckets")
 	}
 
-	for _, problematicTransaction := range problematicTransactions {
-		fmt.Printf("WARNING: %s\n", problematicTransaction.Description)
+	return Diagnosis{
+		ProblematicTransactions: problematicTransactions,
+		Reconciliations:         reconciliations,
+		UnbalancedStatements: GetUnbalancedStatements(
+			document.Statements,
+			document.Transactions,
+		),
+	}, nil
+}
+
+func printDiagnosisText(accounts []api.Account, diagnosis Diagnosis) {
+	for _, problematicTransaction := range diagnosis.ProblematicTransactions {
+		fmt.Printf(
+			"%s: %s\n",
+			strings.ToUpper(GetProblemSeverity(problematicTransaction.Problem)),
+			problematicTransaction.Description,
+		)
+	}
+
+	for _, reconciliation := range diagnosis.Reconciliations {
+		if reconciliation.IsBalanced() {
+			continue
+		}
+
+		explanation := "fully explained by the problems above"
+		if !reconciliation.IsExplained() {
+			explanation = fmt.Sprintf(
+				"of which %s is not explained by any problem",
+				reconciliation.GetUnexplained(),
+			)
+		}
+
+		fmt.Printf(
+			"ERROR: accounts in the cash flow total %s but buckets total %s (off by %s), %s\n",
+			reconciliation.AccountTotal,
+			reconciliation.BucketTotal,
+			reconciliation.GetImbalance(),
+			explanation,
+		)
+	}
+
+	accountsMap := make(map[int64]api.Account, len(accounts))
+	for _, account := range accounts {
+		accountsMap[account.PrimaryKey] = account
+	}
+
+	for _, unbalancedStatement := range diagnosis.UnbalancedStatements {
+		statement := unbalancedStatement.Statement
+		fmt.Printf(
+			"ERROR: statement[%d] from %s to %s against %s ends at %s but its reconciled transactions total %s (off by %s)\n",
+			statement.PrimaryKey,
+			statement.StartingDate.Format("2006-01-02"),
+			statement.EndingDate.Format("2006-01-02"),
+			accountsMap[statement.Account].Name,
+			statement.EndingBalance,
+			unbalancedStatement.ReconciledBalance,
+			unbalancedStatement.GetImbalance(),
+		)
+	}
+}
+
+func printDiagnosisJSON(
+	accounts []api.Account,
+	transactions []api.Transaction,
+	diagnosis Diagnosis,
+) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(getDiagnosisRecord(accounts, transactions, diagnosis)); err != nil {
+		return errors.Wrap(err, "failed to encode json")
 	}
 
 	return nil
 }
+
+func getDiagnosisRecord(
+	accounts []api.Account,
+	transactions []api.Transaction,
+	diagnosis Diagnosis,
+) diagnosisRecord {
+	problematicTransactions := diagnosis.ProblematicTransactions
+
+	accountsMap := make(map[int64]api.Account, len(accounts))
+	for _, account := range accounts {
+		accountsMap[account.PrimaryKey] = account
+	}
+
+	transactionsMap := make(map[int64]api.Transaction, len(transactions))
+	for _, transaction := range transactions {
+		transactionsMap[transaction.PrimaryKey] = transaction
+	}
+
+	record := diagnosisRecord{
+		Problems:        make([]problemRecord, 0, len(problematicTransactions)),
+		Reconciliations: make([]reconciliationRecord, 0, len(diagnosis.Reconciliations)),
+		Statements:      make([]statementRecord, 0, len(diagnosis.UnbalancedStatements)),
+	}
+	for _, problematicTransaction := range problematicTransactions {
+		transaction := transactionsMap[problematicTransaction.Transaction]
+
+		related := problematicTransaction.Related
+		if related == nil {
+			related = []int64{}
+		}
+
+		record.Problems = append(record.Problems, problemRecord{
+			Problem:     problematicTransaction.Problem,
+			Severity:    GetProblemSeverity(problematicTransaction.Problem),
+			Transaction: problematicTransaction.Transaction,
+			AccountID:   transaction.Account,
+			Account:     accountsMap[transaction.Account].Name,
+			Date:        transaction.Date.Format("2006-01-02"),
+			Amount:      transaction.Amount,
+			Payee:       transaction.Payee,
+			Memo:        transaction.Memo,
+			Related:     related,
+			Description: problematicTransaction.Description,
+		})
+	}
+
+	for _, reconciliation := range diagnosis.Reconciliations {
+		contributions := make([]contributionRecord, 0, len(reconciliation.Contributions))
+		for _, contribution := range reconciliation.Contributions {
+			contributions = append(contributions, contributionRecord{
+				Transaction: contribution.Transaction,
+				Amount:      contribution.Amount,
+			})
+		}
+
+		record.Reconciliations = append(record.Reconciliations, reconciliationRecord{
+			Currency:      reconciliation.Currency,
+			AccountTotal:  reconciliation.AccountTotal,
+			BucketTotal:   reconciliation.BucketTotal,
+			Imbalance:     reconciliation.GetImbalance(),
+			Explained:     reconciliation.GetExplained(),
+			Unexplained:   reconciliation.GetUnexplained(),
+			IsBalanced:    reconciliation.IsBalanced(),
+			IsExplained:   reconciliation.IsExplained(),
+			Contributions: contributions,
+		})
+	}
+
+	for _, unbalancedStatement := range diagnosis.UnbalancedStatements {
+		statement := unbalancedStatement.Statement
+		record.Statements = append(record.Statements, statementRecord{
+			Statement:         statement.PrimaryKey,
+			AccountID:         statement.Account,
+			Account:           accountsMap[statement.Account].Name,
+			StartingDate:      statement.StartingDate.Format("2006-01-02"),
+			EndingDate:        statement.EndingDate.Format("2006-01-02"),
+			StartingBalance:   statement.StartingBalance,
+			EndingBalance:     statement.EndingBalance,
+			ReconciledBalance: unbalancedStatement.ReconciledBalance,
+			Imbalance:         unbalancedStatement.GetImbalance(),
+		})
+	}
+
+	return record
+}
+
+func getFormat(options Options) (string, error) {
+	format := options.Format
+	if format == "" {
+		format = FormatText
+	}
+	if format != FormatText && format != FormatJSON {
+		return "", errors.Errorf("unsupported format: %s", format)
+	}
+
+	return format, nil
+}
+
+// loadDocument loads the entities of the given MoneyWell document needed to diagnose it.
+func loadDocument(moneywellPath string) (*api.Document, error) {
+	database, err := api.OpenDocument(moneywellPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", moneywellPath)
+	}
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load %s", moneywellPath)
+	}
+
+	return document, nil
+}
@@ -0,0 +1,230 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+const (
+	// FixActionClearBucket suggests assigning the transaction no bucket.
+	FixActionClearBucket = "clear-bucket"
+	// FixActionAssignBucket suggests assigning the transaction a bucket. Only the user can
+	// decide which bucket is appropriate.
+	FixActionAssignBucket = "assign-bucket"
+	// FixActionClearBucketOptional suggests no longer marking the transaction as bucket
+	// optional.
+	FixActionClearBucketOptional = "clear-bucket-optional"
+	// FixActionSplitRemainder suggests adding a split child for the given amount, or adjusting
+	// an existing child by that amount, so that the children sum to the split parent.
+	FixActionSplitRemainder = "split-remainder"
+	// FixActionDeleteDuplicate suggests deleting the transaction, once confirmed to duplicate
+	// the earlier transaction.
+	FixActionDeleteDuplicate = "delete-duplicate"
+)
+
+// Fix represents a concrete change to a transaction that resolves a diagnosed problem.
+type Fix struct {
+	Transaction int64
+	// UniqueID identifies the transaction across copies of the document.
+	UniqueID string
+	Problem  int
+	Action   string
+	// Amount is the amount of the change, if any, such as the remainder of a split.
+	Amount      money.Money
+	Description string
+}
+
+// GetFixes suggests a fix for each of the given problematic transactions, in the same order.
+// Problems without a known fix, such as those reported by custom checks, are omitted.
+func GetFixes(
+	transactions []api.Transaction,
+	problematicTransactions []check.ProblematicTransaction,
+) ([]Fix, error) {
+	transactionsMap := make(map[int64]api.Transaction, len(transactions))
+	childBalances := make(map[int64]money.Money)
+	for _, transaction := range transactions {
+		transactionsMap[transaction.PrimaryKey] = transaction
+		if transaction.SplitParent != 0 {
+			childBalances[transaction.SplitParent] = childBalances[transaction.SplitParent].Add(
+				transaction.Amount,
+			)
+		}
+	}
+
+	fixes := []Fix{}
+	for _, problematicTransaction := range problematicTransactions {
+		transaction, ok := transactionsMap[problematicTransaction.Transaction]
+		if !ok {
+			return nil, errors.Errorf(
+				"failed to find transaction %d",
+				problematicTransaction.Transaction,
+			)
+		}
+
+		description := fmt.Sprintf(
+			"%s[%d]",
+			describeTransactionKind(transaction),
+			transaction.PrimaryKey,
+		)
+
+		fix := Fix{
+			Transaction: transaction.PrimaryKey,
+			UniqueID:    transaction.UniqueID,
+			Problem:     problematicTransaction.Problem,
+			Amount:      money.Money{Currency: transaction.Amount.Currency},
+		}
+
+		switch problematicTransaction.Problem {
+		case ProblemNotFullySplit:
+			fix.Action = FixActionSplitRemainder
+			fix.Amount = transaction.Amount.Add(
+				childBalances[transaction.PrimaryKey].Multiply(-1),
+			)
+			fix.Description = fmt.Sprintf(
+				"add a split child for %s to %s, or adjust an existing child by that amount",
+				fix.Amount,
+				description,
+			)
+
+		case ProblemSplitParentAssignedBucket,
+			ProblemTransferInsideCashFlowAssignedBucket,
+			ProblemTransferOutsideCashFlowAssignedBucket,
+			ProblemTransferFromCashFlowAssignedBucket,
+			ProblemBucketOutsideCashFlow:
+			fix.Action = FixActionClearBucket
+			fix.Description = fmt.Sprintf("assign %s no bucket", description)
+
+		case ProblemTransferOutOfCashFlowMissingBucket,
+			ProblemMissingBucketInsideCashFlow:
+			fix.Action = FixActionAssignBucket
+			fix.Description = fmt.Sprintf("assign %s a bucket", description)
+
+		case ProblemBucketOptionalInsideCashFlow:
+			fix.Action = FixActionClearBucketOptional
+			fix.Description = fmt.Sprintf("clear bucket optional on %s", description)
+
+		case ProblemProbableDuplicate:
+			fix.Action = FixActionDeleteDuplicate
+			fix.Description = fmt.Sprintf("delete %s if it is a duplicate", description)
+			if len(problematicTransaction.Related) > 0 {
+				fix.Description = fmt.Sprintf(
+					"delete %s if it duplicates transaction[%d]",
+					description,
+					problematicTransaction.Related[0],
+				)
+			}
+
+		default:
+			continue
+		}
+
+		fixes = append(fixes, fix)
+	}
+
+	return fixes, nil
+}
+
+// fixRecord is the stable JSON shape of a Fix.
+type fixRecord struct {
+	Problem     int         `json:"problem"`
+	Action      string      `json:"action"`
+	Transaction int64       `json:"transaction_id"`
+	UniqueID    string      `json:"unique_id"`
+	AccountID   int64       `json:"account_id"`
+	Account     string      `json:"account"`
+	Date        string      `json:"date"`
+	Amount      money.Money `json:"amount"`
+	Payee       string      `json:"payee"`
+	Memo        string      `json:"memo"`
+	Adjustment  money.Money `json:"adjustment"`
+	Description string      `json:"description"`
+}
+
+type planRecord struct {
+	Fixes []fixRecord `json:"fixes"`
+}
+
+// Plan diagnoses the given MoneyWell document, printing and returning a fix for each problem
+// found. The document itself is never changed.
+func Plan(moneywellPath string, options Options) ([]Fix, error) {
+	format, err := getFormat(options)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	document, err := loadDocument(moneywellPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	registry := options.Registry
+	if registry == nil {
+		registry = NewDefaultRegistry()
+	}
+
+	problematicTransactions, err := registry.GetProblematicTransactions(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query for problematic transactions")
+	}
+
+	fixes, err := GetFixes(document.Transactions, problematicTransactions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get fixes")
+	}
+
+	switch format {
+	case FormatJSON:
+		record := planRecord{
+			Fixes: make([]fixRecord, 0, len(fixes)),
+		}
+		for _, fix := range fixes {
+			transaction, _ := document.GetTransaction(fix.Transaction)
+			account, _ := document.GetAccount(transaction.Account)
+			record.Fixes = append(record.Fixes, fixRecord{
+				Problem:     fix.Problem,
+				Action:      fix.Action,
+				Transaction: fix.Transaction,
+				UniqueID:    fix.UniqueID,
+				AccountID:   transaction.Account,
+				Account:     account.Name,
+				Date:        transaction.Date.Format("2006-01-02"),
+				Amount:      transaction.Amount,
+				Payee:       transaction.Payee,
+				Memo:        transaction.Memo,
+				Adjustment:  fix.Amount,
+				Description: fix.Description,
+			})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(record); err != nil {
+			return nil, errors.Wrap(err, "failed to encode json")
+		}
+
+	default:
+		for _, fix := range fixes {
+			transaction, _ := document.GetTransaction(fix.Transaction)
+			account, _ := document.GetAccount(transaction.Account)
+			fmt.Printf("FIX: %s\n", fix.Description)
+			fmt.Printf(
+				"    %s {%s}\n",
+				describeTransaction(
+					describeTransactionKind(transaction),
+					account,
+					transaction,
+				),
+				fix.UniqueID,
+			)
+		}
+	}
+
+	return fixes, nil
+}
@@ -0,0 +1,77 @@
+package doctor_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/doctor"
+)
+
+func TestGetFixes(t *testing.T) {
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	problematicTransactions, err := doctor.GetProblematicTransactions(document)
+	assert.NoError(t, err)
+
+	fixes, err := doctor.GetFixes(document.Transactions, problematicTransactions)
+	assert.NoError(t, err)
+
+	type expectedFix struct {
+		Transaction int64
+		Action      string
+		Amount      money.Money
+	}
+
+	actualFixes := []expectedFix{}
+	for _, fix := range fixes {
+		assert.NotEmpty(t, fix.UniqueID)
+		assert.NotEmpty(t, fix.Description)
+		actualFixes = append(actualFixes, expectedFix{
+			Transaction: fix.Transaction,
+			Action:      fix.Action,
+			Amount:      fix.Amount,
+		})
+	}
+
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+
+	assert.Equal(t, []expectedFix{
+		{3, doctor.FixActionSplitRemainder, cad(-1)},
+		{15, doctor.FixActionClearBucket, cad(0)},
+		{25, doctor.FixActionClearBucketOptional, cad(0)},
+		{25, doctor.FixActionAssignBucket, cad(0)},
+		{27, doctor.FixActionAssignBucket, cad(0)},
+		{20, doctor.FixActionAssignBucket, cad(0)},
+		{17, doctor.FixActionClearBucket, cad(0)},
+		{21, doctor.FixActionClearBucket, cad(0)},
+		{29, doctor.FixActionClearBucket, cad(0)},
+	}, actualFixes)
+}
+
+func TestGetFixesSkipsCustomProblems(t *testing.T) {
+	transactions := []api.Transaction{
+		{PrimaryKey: 1, Amount: money.Money{Currency: "CAD", Amount: -100}},
+	}
+
+	fixes, err := doctor.GetFixes(transactions, []check.ProblematicTransaction{
+		{Transaction: 1, Problem: check.ProblemCustom},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, fixes)
+
+	_, err = doctor.GetFixes(transactions, []check.ProblematicTransaction{
+		{Transaction: 2, Problem: doctor.ProblemMissingBucketInsideCashFlow},
+	})
+	assert.Error(t, err)
+}
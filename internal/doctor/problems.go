@@ -2,11 +2,15 @@ package doctor
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
 	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/match"
 )
 
 const (
@@ -38,126 +42,68 @@ const (
 	// ProblemBucketOutsideCashFlow identifies a non-transfer transaction incorrectly having
 	// a transaction assigned.
 	ProblemBucketOutsideCashFlow = 9
+	// ProblemProbableDuplicate identifies a transaction that probably duplicates an earlier
+	// transaction, typically after importing the same statement twice. This skews the account
+	// balance, but not relative to the buckets.
+	ProblemProbableDuplicate = 10
 )
 
-// ProblematicTranscations represents a transaction diagnosed with a potential problem.
-type ProblematicTransaction struct {
-	Transaction int64
-	Problem     int
-	Description string
-}
+const (
+	// duplicateDateWindow is the number of days by which the dates of two duplicate
+	// transactions may differ.
+	duplicateDateWindow = match.DefaultDateWindow
+	// duplicatePayeeThreshold is the minimum similarity of the payees of two duplicate
+	// transactions.
+	duplicatePayeeThreshold = match.DefaultPayeeThreshold
+)
 
-// GetProblematicTransactions finds transactions with potential problems, typically leading to
-// an imbalance between accounts and buckets within MoneyWell.
-func GetProblematicTransactions(
-	settings api.Settings,
-	accounts []api.Account,
-	transactions []api.Transaction,
-) ([]ProblematicTransaction, error) {
-	problematicTransactions := []ProblematicTransaction{}
-
-	for _, transaction := range transactions {
-		// Ignore transactions before the cash flow start date. They won't contribute
-		// to any current imbalance.
-		if transaction.Date.Before(settings.CashFlowStartDate) {
-			continue
-		}
+const (
+	// SeverityError identifies a problem that leads to an imbalance between accounts and
+	// buckets.
+	SeverityError = "error"
+	// SeverityWarning identifies a problem that is suspicious, but either does not itself lead
+	// to an imbalance or is accompanied by another problem that does.
+	SeverityWarning = "warning"
+)
 
-		// Ignore $0.00 transactions. These won't contribute to an imbalance, and might
-		// be used to demarcate initial balances.
-		if transaction.Amount.IsZero() {
-			continue
-		}
+// GetProblemSeverity returns the severity of the given problem.
+//
+// A transaction inside the cash flow marked as bucket optional is always also reported as
+// missing a bucket, so only the latter is considered an error. A probable duplicate may yet be
+// legitimate, and doesn't itself lead to an imbalance.
+func GetProblemSeverity(problem int) string {
+	switch problem {
+	case ProblemBucketOptionalInsideCashFlow, ProblemProbableDuplicate:
+		return SeverityWarning
+	}
 
-		account, err := getAccount(accounts, transaction.Account)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
+	return SeverityError
+}
 
-		problematicSplitTransactions, err := checkSplitTransaction(
-			account,
-			transactions,
-			transaction,
-		)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		problematicTransactions = append(
-			problematicTransactions,
-			problematicSplitTransactions...,
-		)
-
-		problematicTransferTransactions, err := checkTransferTransaction(
-			accounts,
-			account,
-			transactions,
-			transaction,
-		)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		problematicTransactions = append(
-			problematicTransactions,
-			problematicTransferTransactions...,
-		)
-
-		problematicBucketOptionalTransactions, err := checkBucketOptionalTransaction(
-			account,
-			transactions,
-			transaction,
-		)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		problematicTransactions = append(
-			problematicTransactions,
-			problematicBucketOptionalTransactions...,
-		)
-
-		problematicMissingBucketTransactions, err := checkMissingBucketTransaction(
-			account,
-			transactions,
-			transaction,
-		)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		problematicTransactions = append(
-			problematicTransactions,
-			problematicMissingBucketTransactions...,
-		)
-
-		problematicInvalidBucketTransactions, err := checkInvalidBucketTransaction(
-			account,
-			transactions,
-			transaction,
-		)
-		if err != nil {
-			return nil, errors.WithStack(err)
-		}
-		problematicTransactions = append(
-			problematicTransactions,
-			problematicInvalidBucketTransactions...,
-		)
+// GetProblematicTransactions finds transactions with potential problems, typically leading to
+// an imbalance between accounts and buckets within MoneyWell, using the built-in checks.
+func GetProblematicTransactions(document *api.Document) ([]check.ProblematicTransaction, error) {
+	problematicTransactions, err := NewDefaultRegistry().GetProblematicTransactions(document)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
 	return problematicTransactions, nil
 }
 
-func checkSplitTransaction(
-	account api.Account,
-	transactions []api.Transaction,
-	transaction api.Transaction,
-) ([]ProblematicTransaction, error) {
+func checkSplitTransaction(input check.Input) ([]check.ProblematicTransaction, error) {
+	account := input.Account
+	transaction := input.Transaction
+
 	if !transaction.IsSplit {
 		return nil, nil
 	}
 
-	problematicTransactions := []ProblematicTransaction{}
+	problematicTransactions := []check.ProblematicTransaction{}
 
 	// The split parent in a transaction should not be assigned a bucket.
 	if transaction.Bucket != 0 {
-		problematicTransactions = append(problematicTransactions, ProblematicTransaction{
+		problematicTransactions = append(problematicTransactions, check.ProblematicTransaction{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemSplitParentAssignedBucket,
 			Description: fmt.Sprintf(
@@ -171,17 +117,13 @@ func checkSplitTransaction(
 	// this creates an imbalance that doesn't even show up in the "Unassigned" Smart Bucket
 	// within MoneyWell.
 
-	// Find and sum the children
-	// TODO: Avoid O(n^2) only if this ever seems slow.
 	childBalance := money.Money{}
-	for _, child := range transactions {
-		if child.SplitParent == transaction.PrimaryKey {
-			childBalance = childBalance.Add(child.Amount)
-		}
+	for _, child := range input.Document.GetSplitChildren(transaction.PrimaryKey) {
+		childBalance = childBalance.Add(child.Amount)
 	}
 
 	if transaction.Amount != childBalance {
-		problematicTransactions = append(problematicTransactions, ProblematicTransaction{
+		problematicTransactions = append(problematicTransactions, check.ProblematicTransaction{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemNotFullySplit,
 			Description: fmt.Sprintf(
@@ -201,12 +143,10 @@ func checkSplitTransaction(
 	return problematicTransactions, nil
 }
 
-func checkTransferTransaction(
-	accounts []api.Account,
-	account api.Account,
-	transactions []api.Transaction,
-	transaction api.Transaction,
-) ([]ProblematicTransaction, error) {
+func checkTransferTransaction(input check.Input) ([]check.ProblematicTransaction, error) {
+	account := input.Account
+	transaction := input.Transaction
+
 	if !transaction.IsTransfer() {
 		return nil, nil
 	}
@@ -216,16 +156,16 @@ func checkTransferTransaction(
 		return nil, nil
 	}
 
-	problematicTransactions := []ProblematicTransaction{}
+	problematicTransactions := []check.ProblematicTransaction{}
 
-	transferAccount, err := getAccount(accounts, transaction.TransferAccount)
+	transferAccount, err := getAccount(input.Document, transaction.TransferAccount)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
 	// A transfer between accounts inside the cash flow should not have a bucket assigned.
 	if account.IncludeInCashFlow && transferAccount.IncludeInCashFlow && transaction.Bucket != 0 {
-		problematicTransactions = append(problematicTransactions, ProblematicTransaction{
+		problematicTransactions = append(problematicTransactions, check.ProblematicTransaction{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemTransferInsideCashFlowAssignedBucket,
 			Description: fmt.Sprintf(
@@ -237,7 +177,7 @@ func checkTransferTransaction(
 
 	// A transfer between accounts outside the cash flow should not have a bucket assigned.
 	if !account.IncludeInCashFlow && !transferAccount.IncludeInCashFlow && transaction.Bucket != 0 {
-		problematicTransactions = append(problematicTransactions, ProblematicTransaction{
+		problematicTransactions = append(problematicTransactions, check.ProblematicTransaction{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemTransferOutsideCashFlowAssignedBucket,
 			Description: fmt.Sprintf(
@@ -250,7 +190,7 @@ func checkTransferTransaction(
 	// A transfer between accounts with one in the cash flow and one outside should have a
 	// bucket assigned only on the account inside the cash flow.
 	if account.IncludeInCashFlow && !transferAccount.IncludeInCashFlow && transaction.Bucket == 0 {
-		problematicTransactions = append(problematicTransactions, ProblematicTransaction{
+		problematicTransactions = append(problematicTransactions, check.ProblematicTransaction{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemTransferOutOfCashFlowMissingBucket,
 			Description: fmt.Sprintf(
@@ -259,7 +199,7 @@ func checkTransferTransaction(
 			),
 		})
 	} else if !account.IncludeInCashFlow && transferAccount.IncludeInCashFlow && transaction.Bucket != 0 {
-		problematicTransactions = append(problematicTransactions, ProblematicTransaction{
+		problematicTransactions = append(problematicTransactions, check.ProblematicTransaction{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemTransferFromCashFlowAssignedBucket,
 			Description: fmt.Sprintf(
@@ -272,11 +212,10 @@ func checkTransferTransaction(
 	return problematicTransactions, nil
 }
 
-func checkBucketOptionalTransaction(
-	account api.Account,
-	transactions []api.Transaction,
-	transaction api.Transaction,
-) ([]ProblematicTransaction, error) {
+func checkBucketOptionalTransaction(input check.Input) ([]check.ProblematicTransaction, error) {
+	account := input.Account
+	transaction := input.Transaction
+
 	// If it's not marked as bucket optional, it's not a problem!
 	if !transaction.IsBucketOptional {
 		return nil, nil
@@ -305,7 +244,7 @@ func checkBucketOptionalTransaction(
 
 	// A transaction should generally not be marked as bucket optional in an account that is
 	// part of the of the cash flow.
-	return []ProblematicTransaction{
+	return []check.ProblematicTransaction{
 		{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemBucketOptionalInsideCashFlow,
@@ -317,11 +256,10 @@ func checkBucketOptionalTransaction(
 	}, nil
 }
 
-func checkMissingBucketTransaction(
-	account api.Account,
-	transactions []api.Transaction,
-	transaction api.Transaction,
-) ([]ProblematicTransaction, error) {
+func checkMissingBucketTransaction(input check.Input) ([]check.ProblematicTransaction, error) {
+	account := input.Account
+	transaction := input.Transaction
+
 	// If a bucket is assigned, it's not missing!
 	if transaction.Bucket != 0 {
 		return nil, nil
@@ -337,7 +275,7 @@ func checkMissingBucketTransaction(
 		return nil, nil
 	}
 
-	return []ProblematicTransaction{
+	return []check.ProblematicTransaction{
 		{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemMissingBucketInsideCashFlow,
@@ -349,11 +287,10 @@ func checkMissingBucketTransaction(
 	}, nil
 }
 
-func checkInvalidBucketTransaction(
-	account api.Account,
-	transactions []api.Transaction,
-	transaction api.Transaction,
-) ([]ProblematicTransaction, error) {
+func checkInvalidBucketTransaction(input check.Input) ([]check.ProblematicTransaction, error) {
+	account := input.Account
+	transaction := input.Transaction
+
 	// Assume transfer and split transactions are checked elsewhere.
 	if transaction.IsTransfer() || transaction.IsSplit {
 		return nil, nil
@@ -369,7 +306,7 @@ func checkInvalidBucketTransaction(
 		return nil, nil
 	}
 
-	return []ProblematicTransaction{
+	return []check.ProblematicTransaction{
 		{
 			Transaction: transaction.PrimaryKey,
 			Problem:     ProblemBucketOutsideCashFlow,
@@ -381,6 +318,151 @@ func checkInvalidBucketTransaction(
 	}, nil
 }
 
+// duplicateCheck reports probable duplicates. The candidates in each document are grouped by
+// account and amount, and sorted by date, just once, so that each transaction need only be
+// compared against its neighbours.
+type duplicateCheck struct {
+	mutex      sync.Mutex
+	document   *api.Document
+	candidates map[duplicateKey][]api.Transaction
+}
+
+// duplicateKey identifies the candidates that might duplicate one another.
+type duplicateKey struct {
+	Account int64
+	Amount  money.Money
+}
+
+func newDuplicateCheck() check.Check {
+	return &duplicateCheck{}
+}
+
+func (c *duplicateCheck) Problems() []int {
+	return []int{ProblemProbableDuplicate}
+}
+
+func (c *duplicateCheck) Check(input check.Input) ([]check.ProblematicTransaction, error) {
+	account := input.Account
+	transaction := input.Transaction
+
+	if !isDuplicateCandidate(transaction) {
+		return nil, nil
+	}
+
+	candidates := c.getCandidates(input.Document, duplicateKey{
+		Account: transaction.Account,
+		Amount:  transaction.Amount,
+	})
+	i := sort.Search(len(candidates), func(i int) bool {
+		return !isDuplicateCandidateBefore(candidates[i], transaction)
+	})
+
+	// Report only the later of two duplicates, against the closest earlier transaction.
+	var duplicate api.Transaction
+	duplicateDays := -1
+	for j := i - 1; j >= 0; j-- {
+		other := candidates[j]
+
+		days := match.GetDays(transaction.Date, other.Date)
+		if days > duplicateDateWindow {
+			break
+		}
+
+		// Transactions imported with different ids are known to be distinct, no matter how
+		// similar.
+		if transaction.ExternalID != "" && other.ExternalID != "" {
+			if transaction.ExternalID != other.ExternalID {
+				continue
+			}
+		} else if match.GetPayeeSimilarity(
+			transaction.Payee,
+			other.Payee,
+		) < duplicatePayeeThreshold {
+			continue
+		}
+
+		// Prefer the first entered of the closest earlier transactions.
+		if duplicateDays < 0 || days <= duplicateDays {
+			duplicate = other
+			duplicateDays = days
+		}
+	}
+
+	if duplicateDays < 0 {
+		return nil, nil
+	}
+
+	return []check.ProblematicTransaction{
+		{
+			Transaction: transaction.PrimaryKey,
+			Problem:     ProblemProbableDuplicate,
+			Description: fmt.Sprintf(
+				"%s probably duplicates transaction[%d] on %s",
+				describeTransaction("transaction", account, transaction),
+				duplicate.PrimaryKey,
+				duplicate.Date.Format("2006-01-02"),
+			),
+			Related: []int64{duplicate.PrimaryKey},
+		},
+	}, nil
+}
+
+// getCandidates returns the candidates in the given document with the given account and amount,
+// sorted by date and then by primary key, grouping the candidates if the document is new.
+func (c *duplicateCheck) getCandidates(document *api.Document, key duplicateKey) []api.Transaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.document != document {
+		c.document = document
+		c.candidates = make(map[duplicateKey][]api.Transaction)
+		for _, transaction := range document.Transactions {
+			if !isDuplicateCandidate(transaction) {
+				continue
+			}
+
+			key := duplicateKey{Account: transaction.Account, Amount: transaction.Amount}
+			c.candidates[key] = append(c.candidates[key], transaction)
+		}
+
+		for _, candidates := range c.candidates {
+			sort.Slice(candidates, func(i, j int) bool {
+				return isDuplicateCandidateBefore(candidates[i], candidates[j])
+			})
+		}
+	}
+
+	return c.candidates[key]
+}
+
+// isDuplicateCandidateBefore determines if the given transaction is dated before the other, or on
+// the same date but entered earlier.
+func isDuplicateCandidateBefore(transaction api.Transaction, other api.Transaction) bool {
+	if !transaction.Date.Equal(other.Date) {
+		return transaction.Date.Before(other.Date)
+	}
+
+	return transaction.PrimaryKey < other.PrimaryKey
+}
+
+// isDuplicateCandidate determines if the given transaction could duplicate another. The two
+// sides of a transfer are entered together, and split children merely divide their parent, so
+// neither is considered. Voided and pending transactions don't affect the balance.
+func isDuplicateCandidate(transaction api.Transaction) bool {
+	if transaction.IsTransfer() || transaction.SplitParent != 0 || transaction.Amount.IsZero() {
+		return false
+	}
+
+	switch transaction.Status {
+	case api.TransactionStatusVoided:
+		fallthrough
+	case api.TransactionStatusPending:
+		return false
+	}
+
+	return true
+}
+
 func describeTransaction(description string, account api.Account, transaction api.Transaction) string {
 	memo := transaction.Memo
 	if len(memo) > 1 {
@@ -397,13 +479,20 @@ func describeTransaction(description string, account api.Account, transaction ap
 	)
 }
 
-func getAccount(accounts []api.Account, accountPrimaryKey int64) (api.Account, error) {
-	// TODO: Avoid O(n^2) only if this ever seems slow.
-	for _, account := range accounts {
-		if account.PrimaryKey == accountPrimaryKey {
-			return account, nil
-		}
+// describeTransactionKind describes a transaction as either a transfer or a transaction.
+func describeTransactionKind(transaction api.Transaction) string {
+	if transaction.IsTransfer() {
+		return "transfer"
+	}
+
+	return "transaction"
+}
+
+func getAccount(document *api.Document, accountPrimaryKey int64) (api.Account, error) {
+	account, ok := document.GetAccount(accountPrimaryKey)
+	if !ok {
+		return api.Account{}, errors.Errorf("failed to find account %v", accountPrimaryKey)
 	}
 
-	return api.Account{}, errors.Errorf("failed to find account %v", accountPrimaryKey)
+	return account, nil
 }
@@ -2,10 +2,13 @@ package doctor_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
 	"github.com/lieut-data/go-moneywell/internal/doctor"
 )
 
@@ -14,23 +17,13 @@ func TestDiagnose(t *testing.T) {
 	assert.NoError(t, err)
 	defer database.Close()
 
-	settings, err := api.GetSettings(database)
+	document, err := api.LoadDocument(database)
 	assert.NoError(t, err)
 
-	accounts, err := api.GetAccounts(database)
+	actualProblematicTransactions, err := doctor.GetProblematicTransactions(document)
 	assert.NoError(t, err)
 
-	transactions, err := api.GetTransactions(database)
-	assert.NoError(t, err)
-
-	actualProblematicTransactions, err := doctor.GetProblematicTransactions(
-		settings,
-		accounts,
-		transactions,
-	)
-	assert.NoError(t, err)
-
-	expectedProblematicTransactions := []doctor.ProblematicTransaction{
+	expectedProblematicTransactions := []check.ProblematicTransaction{
 		{
 			Transaction: 3,
 			Problem:     doctor.ProblemNotFullySplit,
@@ -80,3 +73,138 @@ func TestDiagnose(t *testing.T) {
 
 	assert.Equal(t, expectedProblematicTransactions, actualProblematicTransactions)
 }
+
+func TestGetProblemSeverity(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Problem          int
+		ExpectedSeverity string
+	}{
+		{doctor.ProblemNotFullySplit, doctor.SeverityError},
+		{doctor.ProblemSplitParentAssignedBucket, doctor.SeverityError},
+		{doctor.ProblemTransferInsideCashFlowAssignedBucket, doctor.SeverityError},
+		{doctor.ProblemTransferOutsideCashFlowAssignedBucket, doctor.SeverityError},
+		{doctor.ProblemTransferOutOfCashFlowMissingBucket, doctor.SeverityError},
+		{doctor.ProblemTransferFromCashFlowAssignedBucket, doctor.SeverityError},
+		{doctor.ProblemBucketOptionalInsideCashFlow, doctor.SeverityWarning},
+		{doctor.ProblemMissingBucketInsideCashFlow, doctor.SeverityError},
+		{doctor.ProblemBucketOutsideCashFlow, doctor.SeverityError},
+		{doctor.ProblemProbableDuplicate, doctor.SeverityWarning},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(
+			t,
+			testCase.ExpectedSeverity,
+			doctor.GetProblemSeverity(testCase.Problem),
+			"problem %d",
+			testCase.Problem,
+		)
+	}
+}
+
+func TestProbableDuplicates(t *testing.T) {
+	t.Parallel()
+
+	settings := api.Settings{
+		CashFlowStartDate: time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC),
+	}
+	accounts := []api.Account{
+		{PrimaryKey: 1, Name: "Chequing", IncludeInCashFlow: true},
+		{PrimaryKey: 2, Name: "Savings", IncludeInCashFlow: true},
+	}
+
+	transaction := func(
+		primaryKey int64,
+		account int64,
+		day int,
+		amount int64,
+		payee string,
+	) api.Transaction {
+		return api.Transaction{
+			PrimaryKey: primaryKey,
+			Date:       time.Date(2017, 11, day, 0, 0, 0, 0, time.UTC),
+			Amount:     money.Money{Currency: "CAD", Amount: amount},
+			Account:    account,
+			Bucket:     1,
+			Status:     api.TransactionStatusCleared,
+			Payee:      payee,
+		}
+	}
+
+	transactions := []api.Transaction{
+		transaction(1, 1, 5, -3500, "Grocery Store"),
+		// Imported again a day later.
+		transaction(2, 1, 6, -3500, "GROCERY STORE #123 TORONTO"),
+		// Too late to be a duplicate.
+		transaction(3, 1, 12, -3500, "Grocery Store"),
+		// A different payee, amount or account.
+		transaction(4, 1, 5, -3500, "Hardware"),
+		transaction(5, 1, 5, -3600, "Grocery Store"),
+		transaction(6, 2, 5, -3500, "Grocery Store"),
+		// Imported twice with the same id, despite the payees.
+		transaction(7, 1, 20, -1000, "POS 1234"),
+		transaction(8, 1, 20, -1000, "Coffee Shop"),
+		// Imported with different ids, despite the payees.
+		transaction(9, 1, 25, -1000, "Coffee Shop"),
+		transaction(10, 1, 25, -1000, "Coffee Shop"),
+		// Voided transactions, transfers and split children are never duplicates.
+		transaction(11, 1, 5, -3500, "Grocery Store"),
+		transaction(12, 1, 5, -3500, "Grocery Store"),
+		transaction(13, 1, 5, -3500, "Grocery Store"),
+	}
+	transactions[6].ExternalID = "A1"
+	transactions[7].ExternalID = "A1"
+	transactions[8].ExternalID = "B1"
+	transactions[9].ExternalID = "B2"
+	transactions[10].Status = api.TransactionStatusVoided
+	transactions[11].TransferAccount = 2
+	transactions[12].SplitParent = 4
+
+	registry := doctor.NewDefaultRegistry()
+	getDuplicates := func(transactions []api.Transaction) []check.ProblematicTransaction {
+		problematicTransactions, err := registry.GetProblematicTransactions(&api.Document{
+			Settings:     settings,
+			Accounts:     accounts,
+			Transactions: transactions,
+		})
+		assert.NoError(t, err)
+
+		duplicates := []check.ProblematicTransaction{}
+		for _, problematicTransaction := range problematicTransactions {
+			if problematicTransaction.Problem == doctor.ProblemProbableDuplicate {
+				duplicates = append(duplicates, problematicTransaction)
+			}
+		}
+
+		return duplicates
+	}
+
+	duplicates := getDuplicates(transactions)
+	assert.Equal(t, []check.ProblematicTransaction{
+		{
+			Transaction: 2,
+			Problem:     doctor.ProblemProbableDuplicate,
+			Description: "transaction[2] on 2017-11-06 against Chequing for -$35.00 CAD probably duplicates transaction[1] on 2017-11-05",
+			Related:     []int64{1},
+		},
+		{
+			Transaction: 8,
+			Problem:     doctor.ProblemProbableDuplicate,
+			Description: "transaction[8] on 2017-11-20 against Chequing for -$10.00 CAD probably duplicates transaction[7] on 2017-11-20",
+			Related:     []int64{7},
+		},
+	}, duplicates)
+
+	fixes, err := doctor.GetFixes(transactions, duplicates)
+	assert.NoError(t, err)
+	assert.Len(t, fixes, 2)
+	assert.Equal(t, doctor.FixActionDeleteDuplicate, fixes[0].Action)
+	assert.Equal(t, "delete transaction[2] if it duplicates transaction[1]", fixes[0].Description)
+
+	// The candidates are grouped anew for another document checked by the same registry.
+	duplicates = getDuplicates(transactions[1:])
+	assert.Len(t, duplicates, 1)
+	assert.EqualValues(t, 8, duplicates[0].Transaction)
+}
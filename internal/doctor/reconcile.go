@@ -0,0 +1,228 @@
+package doctor
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// ImbalanceContribution represents the amount by which a problematic transaction skews the
+// account total relative to the bucket total.
+type ImbalanceContribution struct {
+	Transaction int64
+	Amount      money.Money
+}
+
+// Reconciliation compares the total balance of the accounts inside the cash flow against the
+// total balance of all buckets in a single currency. MoneyWell expects these to match.
+type Reconciliation struct {
+	Currency      string
+	AccountTotal  money.Money
+	BucketTotal   money.Money
+	Contributions []ImbalanceContribution
+}
+
+// GetImbalance returns the amount by which the account total exceeds the bucket total.
+func (r Reconciliation) GetImbalance() money.Money {
+	return r.AccountTotal.Add(r.BucketTotal.Multiply(-1))
+}
+
+// GetExplained returns the portion of the imbalance caused by problematic transactions.
+func (r Reconciliation) GetExplained() money.Money {
+	explained := money.Money{Currency: r.Currency}
+	for _, contribution := range r.Contributions {
+		explained = explained.Add(contribution.Amount)
+	}
+
+	return explained
+}
+
+// GetUnexplained returns the portion of the imbalance not caused by any problematic
+// transaction.
+func (r Reconciliation) GetUnexplained() money.Money {
+	return r.GetImbalance().Add(r.GetExplained().Multiply(-1))
+}
+
+// IsBalanced determines if the account total matches the bucket total.
+func (r Reconciliation) IsBalanced() bool {
+	return r.GetImbalance().IsZero()
+}
+
+// IsExplained determines if the problematic transactions fully account for the imbalance, if
+// any.
+func (r Reconciliation) IsExplained() bool {
+	return r.GetUnexplained().IsZero()
+}
+
+// GetReconciliations compares the total balance of the accounts inside the cash flow against
+// the total balance of all buckets, returning one reconciliation per currency sorted by
+// currency.
+//
+// Each problematic transaction is attributed the amount by which it skews the account total
+// relative to the bucket total: the amount it contributes to the cash flow accounts, less the
+// amount it contributes to the buckets. A transaction reported with more than one problem is
+// attributed only once, and transactions that don't skew the totals are omitted.
+func GetReconciliations(
+	settings api.Settings,
+	accounts []api.Account,
+	buckets []api.Bucket,
+	transactions []api.Transaction,
+	bucketTransfers []api.BucketTransfer,
+	problematicTransactions []check.ProblematicTransaction,
+) ([]Reconciliation, error) {
+	reconciliations := make(map[string]*Reconciliation)
+	getReconciliation := func(currency string) *Reconciliation {
+		if reconciliations[currency] == nil {
+			reconciliations[currency] = &Reconciliation{
+				Currency:      currency,
+				AccountTotal:  money.Money{Currency: currency},
+				BucketTotal:   money.Money{Currency: currency},
+				Contributions: []ImbalanceContribution{},
+			}
+		}
+
+		return reconciliations[currency]
+	}
+
+	accountsMap := make(map[int64]api.Account, len(accounts))
+	for _, account := range accounts {
+		accountsMap[account.PrimaryKey] = account
+
+		if !account.IncludeInCashFlow {
+			continue
+		}
+
+		reconciliation := getReconciliation(account.CurrencyCode)
+		reconciliation.AccountTotal = reconciliation.AccountTotal.Add(
+			api.GetAccountBalance(account, transactions),
+		)
+	}
+
+	for _, bucket := range buckets {
+		events, err := api.GetBucketEvents(bucket, transactions, bucketTransfers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get bucket events for %s", bucket.Name)
+		}
+
+		balance, err := api.GetBucketBalance(bucket, events, settings)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get bucket balance for %s", bucket.Name)
+		}
+
+		// Buckets don't always record a currency, so prefer that of the balance itself.
+		currency := balance.Currency
+		if currency == "" {
+			currency = bucket.CurrencyCode
+		}
+		if currency == "" && balance.IsZero() {
+			continue
+		}
+
+		reconciliation := getReconciliation(currency)
+		reconciliation.BucketTotal = reconciliation.BucketTotal.Add(balance)
+	}
+
+	transactionsMap := make(map[int64]api.Transaction, len(transactions))
+	splitChildren := make(map[int64][]api.Transaction)
+	for _, transaction := range transactions {
+		transactionsMap[transaction.PrimaryKey] = transaction
+		if transaction.SplitParent != 0 {
+			splitChildren[transaction.SplitParent] = append(
+				splitChildren[transaction.SplitParent],
+				transaction,
+			)
+		}
+	}
+
+	attributed := make(map[int64]bool)
+	for _, problematicTransaction := range problematicTransactions {
+		if attributed[problematicTransaction.Transaction] {
+			continue
+		}
+		attributed[problematicTransaction.Transaction] = true
+
+		transaction, ok := transactionsMap[problematicTransaction.Transaction]
+		if !ok {
+			return nil, errors.Errorf(
+				"failed to find transaction %d",
+				problematicTransaction.Transaction,
+			)
+		}
+
+		contribution := getImbalanceContribution(
+			settings,
+			accountsMap,
+			splitChildren[transaction.PrimaryKey],
+			transaction,
+		)
+		if contribution.IsZero() {
+			continue
+		}
+
+		reconciliation := getReconciliation(contribution.Currency)
+		reconciliation.Contributions = append(reconciliation.Contributions, ImbalanceContribution{
+			Transaction: transaction.PrimaryKey,
+			Amount:      contribution,
+		})
+	}
+
+	currencies := make([]string, 0, len(reconciliations))
+	for currency := range reconciliations {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	sortedReconciliations := make([]Reconciliation, 0, len(currencies))
+	for _, currency := range currencies {
+		sortedReconciliations = append(sortedReconciliations, *reconciliations[currency])
+	}
+
+	return sortedReconciliations, nil
+}
+
+// getImbalanceContribution computes the amount by which the given transaction skews the account
+// total relative to the bucket total.
+//
+// The amount of a split transaction is attributed to its children, leaving the parent with only
+// the amount not covered by the children. A transfer between two accounts inside the cash flow
+// doesn't change the account total. Transactions before the cash flow start date are already
+// reflected in the bucket starting balances.
+func getImbalanceContribution(
+	settings api.Settings,
+	accounts map[int64]api.Account,
+	children []api.Transaction,
+	transaction api.Transaction,
+) money.Money {
+	contribution := money.Money{Currency: transaction.Amount.Currency}
+
+	switch transaction.Status {
+	case api.TransactionStatusVoided:
+		fallthrough
+	case api.TransactionStatusPending:
+		return contribution
+	}
+
+	if transaction.Date.Before(settings.CashFlowStartDate) {
+		return contribution
+	}
+
+	account := accounts[transaction.Account]
+	isInternalTransfer := transaction.IsTransfer() &&
+		accounts[transaction.TransferAccount].IncludeInCashFlow
+	if account.IncludeInCashFlow && !isInternalTransfer {
+		contribution = contribution.Add(transaction.Amount)
+		for _, child := range children {
+			contribution = contribution.Add(child.Amount.Multiply(-1))
+		}
+	}
+
+	if transaction.Bucket != 0 {
+		contribution = contribution.Add(transaction.Amount.Multiply(-1))
+	}
+
+	return contribution
+}
@@ -0,0 +1,92 @@
+package doctor_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/check"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/doctor"
+)
+
+func TestGetReconciliations(t *testing.T) {
+	database, err := api.OpenDocument("Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	problematicTransactions, err := doctor.GetProblematicTransactions(document)
+	assert.NoError(t, err)
+
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+
+	t.Run("fully explained", func(t *testing.T) {
+		reconciliations, err := doctor.GetReconciliations(
+			document.Settings,
+			document.Accounts,
+			document.Buckets,
+			document.Transactions,
+			document.BucketTransfers,
+			problematicTransactions,
+		)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []doctor.Reconciliation{
+			{
+				Currency:     "CAD",
+				AccountTotal: cad(-23002),
+				BucketTotal:  cad(-28500),
+				Contributions: []doctor.ImbalanceContribution{
+					{Transaction: 3, Amount: cad(-1)},
+					{Transaction: 15, Amount: cad(5000)},
+					{Transaction: 25, Amount: cad(-1)},
+					{Transaction: 27, Amount: cad(-500)},
+					{Transaction: 20, Amount: cad(-2500)},
+					{Transaction: 17, Amount: cad(5000)},
+					{Transaction: 21, Amount: cad(-2500)},
+					{Transaction: 29, Amount: cad(1000)},
+				},
+			},
+		}, reconciliations)
+
+		assert.False(t, reconciliations[0].IsBalanced())
+		assert.Equal(t, cad(5498), reconciliations[0].GetImbalance())
+		assert.Equal(t, cad(5498), reconciliations[0].GetExplained())
+		assert.True(t, reconciliations[0].IsExplained())
+	})
+
+	t.Run("partially explained", func(t *testing.T) {
+		// Pretend the doctor overlooked the transfer inside the cash flow.
+		var someProblematicTransactions []check.ProblematicTransaction
+		for _, problematicTransaction := range problematicTransactions {
+			if problematicTransaction.Transaction != 15 {
+				someProblematicTransactions = append(
+					someProblematicTransactions,
+					problematicTransaction,
+				)
+			}
+		}
+
+		reconciliations, err := doctor.GetReconciliations(
+			document.Settings,
+			document.Accounts,
+			document.Buckets,
+			document.Transactions,
+			document.BucketTransfers,
+			someProblematicTransactions,
+		)
+		assert.NoError(t, err)
+		assert.Len(t, reconciliations, 1)
+
+		assert.Equal(t, cad(5498), reconciliations[0].GetImbalance())
+		assert.Equal(t, cad(498), reconciliations[0].GetExplained())
+		assert.Equal(t, cad(5000), reconciliations[0].GetUnexplained())
+		assert.False(t, reconciliations[0].IsExplained())
+	})
+}
@@ -0,0 +1,207 @@
+package doctor
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+// Repair represents the fixes applied to a copy of a MoneyWell document, along with the diagnosis
+// of the copy afterwards.
+type Repair struct {
+	Applied []Fix
+	// Skipped are the fixes needing the user's judgement, such as choosing a bucket or deciding
+	// whether a transaction really is a duplicate.
+	Skipped   []Fix
+	Diagnosis Diagnosis
+}
+
+// IsAutomatic determines if the given fix can be applied without the user's judgement.
+func IsAutomatic(fix Fix) bool {
+	switch fix.Action {
+	case FixActionClearBucket, FixActionClearBucketOptional:
+		return true
+	}
+
+	return false
+}
+
+// applyFix applies the given fix to a writable MoneyWell document.
+func applyFix(database *sql.DB, fix Fix) error {
+	switch fix.Action {
+	case FixActionClearBucket:
+		return api.SetTransactionBucket(database, fix.Transaction, 0)
+	case FixActionClearBucketOptional:
+		return api.SetTransactionBucketOptional(database, fix.Transaction, false)
+	}
+
+	return errors.Errorf("cannot apply %s automatically", fix.Action)
+}
+
+type repairFixRecord struct {
+	fixRecord
+	Applied bool `json:"applied"`
+}
+
+type repairRecord struct {
+	Fixes     []repairFixRecord `json:"fixes"`
+	Diagnosis diagnosisRecord   `json:"diagnosis"`
+}
+
+// ApplyFixes diagnoses the given MoneyWell document, writes a copy to outputPath with each fix
+// that needs no judgement applied, and then diagnoses the copy to confirm that the problems so
+// fixed are gone. The fixes and the diagnosis of the copy are printed and returned.
+//
+// The given document itself is never changed, and outputPath must not already exist.
+func ApplyFixes(moneywellPath string, outputPath string, options Options) (Repair, error) {
+	format, err := getFormat(options)
+	if err != nil {
+		return Repair{}, errors.WithStack(err)
+	}
+
+	document, err := loadDocument(moneywellPath)
+	if err != nil {
+		return Repair{}, errors.WithStack(err)
+	}
+
+	diagnosis, err := getDiagnosis(document, options.Registry)
+	if err != nil {
+		return Repair{}, errors.WithStack(err)
+	}
+
+	fixes, err := GetFixes(document.Transactions, diagnosis.ProblematicTransactions)
+	if err != nil {
+		return Repair{}, errors.Wrap(err, "failed to get fixes")
+	}
+
+	if err := api.CopyDocument(moneywellPath, outputPath); err != nil {
+		return Repair{}, errors.Wrapf(err, "failed to copy %s", moneywellPath)
+	}
+
+	database, err := api.OpenWritableDocument(outputPath)
+	if err != nil {
+		return Repair{}, errors.Wrapf(err, "failed to open %s", outputPath)
+	}
+	defer database.Close()
+
+	repair := Repair{
+		Applied: []Fix{},
+		Skipped: []Fix{},
+	}
+	for _, fix := range fixes {
+		if !IsAutomatic(fix) {
+			repair.Skipped = append(repair.Skipped, fix)
+			continue
+		}
+
+		if err := applyFix(database, fix); err != nil {
+			return Repair{}, errors.Wrapf(err, "failed to %s", fix.Description)
+		}
+		repair.Applied = append(repair.Applied, fix)
+	}
+
+	if err := database.Close(); err != nil {
+		return Repair{}, errors.Wrapf(err, "failed to close %s", outputPath)
+	}
+
+	repairedDocument, err := loadDocument(outputPath)
+	if err != nil {
+		return Repair{}, errors.WithStack(err)
+	}
+
+	repair.Diagnosis, err = getDiagnosis(repairedDocument, options.Registry)
+	if err != nil {
+		return Repair{}, errors.Wrapf(err, "failed to diagnose %s", outputPath)
+	}
+
+	type problemKey struct {
+		transaction int64
+		problem     int
+	}
+	remaining := make(map[problemKey]bool)
+	for _, problematicTransaction := range repair.Diagnosis.ProblematicTransactions {
+		remaining[problemKey{
+			transaction: problematicTransaction.Transaction,
+			problem:     problematicTransaction.Problem,
+		}] = true
+	}
+	for _, fix := range repair.Applied {
+		if remaining[problemKey{transaction: fix.Transaction, problem: fix.Problem}] {
+			return Repair{}, errors.Errorf(
+				"failed to %s: problem %d remains in %s",
+				fix.Description,
+				fix.Problem,
+				outputPath,
+			)
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		record := repairRecord{
+			Fixes: make([]repairFixRecord, 0, len(fixes)),
+			Diagnosis: getDiagnosisRecord(
+				repairedDocument.Accounts,
+				repairedDocument.Transactions,
+				repair.Diagnosis,
+			),
+		}
+		for _, fix := range fixes {
+			transaction, _ := document.GetTransaction(fix.Transaction)
+			account, _ := document.GetAccount(transaction.Account)
+			record.Fixes = append(record.Fixes, repairFixRecord{
+				fixRecord: fixRecord{
+					Problem:     fix.Problem,
+					Action:      fix.Action,
+					Transaction: fix.Transaction,
+					UniqueID:    fix.UniqueID,
+					AccountID:   transaction.Account,
+					Account:     account.Name,
+					Date:        transaction.Date.Format("2006-01-02"),
+					Amount:      transaction.Amount,
+					Payee:       transaction.Payee,
+					Memo:        transaction.Memo,
+					Adjustment:  fix.Amount,
+					Description: fix.Description,
+				},
+				Applied: IsAutomatic(fix),
+			})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(record); err != nil {
+			return Repair{}, errors.Wrap(err, "failed to encode json")
+		}
+
+	default:
+		for _, fix := range fixes {
+			status := "SKIPPED"
+			if IsAutomatic(fix) {
+				status = "FIXED"
+			}
+
+			transaction, _ := document.GetTransaction(fix.Transaction)
+			account, _ := document.GetAccount(transaction.Account)
+			fmt.Printf("%s: %s\n", status, fix.Description)
+			fmt.Printf(
+				"    %s {%s}\n",
+				describeTransaction(
+					describeTransactionKind(transaction),
+					account,
+					transaction,
+				),
+				fix.UniqueID,
+			)
+		}
+
+		printDiagnosisText(repairedDocument.Accounts, repair.Diagnosis)
+	}
+
+	return repair, nil
+}
@@ -0,0 +1,75 @@
+package doctor_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/internal/doctor"
+)
+
+func TestApplyFixes(t *testing.T) {
+	directory := t.TempDir()
+
+	// Fix a copy of the test document, lest opening the original leave files behind.
+	moneywellPath := filepath.Join(directory, "Test.moneywell")
+	assert.NoError(t, api.CopyDocument("Test.moneywell", moneywellPath))
+
+	outputPath := filepath.Join(directory, "Fixed.moneywell")
+	repair, err := doctor.ApplyFixes(moneywellPath, outputPath, doctor.Options{
+		Format: doctor.FormatJSON,
+	})
+	assert.NoError(t, err)
+
+	type expectedFix struct {
+		Transaction int64
+		Action      string
+	}
+	getFixes := func(fixes []doctor.Fix) []expectedFix {
+		actualFixes := []expectedFix{}
+		for _, fix := range fixes {
+			actualFixes = append(actualFixes, expectedFix{fix.Transaction, fix.Action})
+		}
+
+		return actualFixes
+	}
+
+	assert.Equal(t, []expectedFix{
+		{15, doctor.FixActionClearBucket},
+		{25, doctor.FixActionClearBucketOptional},
+		{17, doctor.FixActionClearBucket},
+		{21, doctor.FixActionClearBucket},
+		{29, doctor.FixActionClearBucket},
+	}, getFixes(repair.Applied))
+
+	assert.Equal(t, []expectedFix{
+		{3, doctor.FixActionSplitRemainder},
+		{25, doctor.FixActionAssignBucket},
+		{27, doctor.FixActionAssignBucket},
+		{20, doctor.FixActionAssignBucket},
+	}, getFixes(repair.Skipped))
+
+	problems := []int{}
+	for _, problematicTransaction := range repair.Diagnosis.ProblematicTransactions {
+		problems = append(problems, problematicTransaction.Problem)
+	}
+	assert.Equal(t, []int{1, 8, 8, 5}, problems)
+
+	// The original document is unchanged.
+	database, err := api.OpenDocument(moneywellPath)
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	problematicTransactions, err := doctor.GetProblematicTransactions(document)
+	assert.NoError(t, err)
+	assert.Len(t, problematicTransactions, 9)
+
+	// The copy is never overwritten.
+	_, err = doctor.ApplyFixes(moneywellPath, outputPath, doctor.Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package doctor
+
+import (
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// UnbalancedStatement represents a reconciled statement whose ending balance no longer matches
+// the reconciled transactions within it, typically because such a transaction was since
+// changed, deleted or un-reconciled.
+type UnbalancedStatement struct {
+	Statement         api.Statement
+	ReconciledBalance money.Money
+}
+
+// GetImbalance returns the amount by which the statement's ending balance exceeds the balance
+// of its reconciled transactions.
+func (s UnbalancedStatement) GetImbalance() money.Money {
+	return s.Statement.EndingBalance.Add(s.ReconciledBalance.Multiply(-1))
+}
+
+// GetUnbalancedStatements verifies the ending balance of each reconciled statement against its
+// starting balance and the reconciled transactions dated within it, returning the statements
+// that no longer balance in the given order. Statements not yet reconciled are ignored.
+func GetUnbalancedStatements(
+	statements []api.Statement,
+	transactions []api.Transaction,
+) []UnbalancedStatement {
+	unbalancedStatements := []UnbalancedStatement{}
+	for _, statement := range statements {
+		if !statement.IsReconciled {
+			continue
+		}
+
+		reconciledBalance := statement.GetReconciledBalance(transactions)
+		if reconciledBalance.Amount == statement.EndingBalance.Amount {
+			continue
+		}
+
+		unbalancedStatements = append(unbalancedStatements, UnbalancedStatement{
+			Statement:         statement,
+			ReconciledBalance: reconciledBalance,
+		})
+	}
+
+	return unbalancedStatements
+}
@@ -0,0 +1,69 @@
+package doctor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/doctor"
+)
+
+func TestGetUnbalancedStatements(t *testing.T) {
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+	date := func(day int) time.Time {
+		return time.Date(2017, 11, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	transactions := []api.Transaction{
+		{PrimaryKey: 1, Account: 1, Date: date(2), Amount: cad(-1000), Status: api.TransactionStatusReconciled},
+		{PrimaryKey: 2, Account: 1, Date: date(3), Amount: cad(-500), Status: api.TransactionStatusReconciled},
+		{PrimaryKey: 3, Account: 1, Date: date(3), Amount: cad(-200), Status: api.TransactionStatusReconciled, SplitParent: 2},
+		{PrimaryKey: 4, Account: 1, Date: date(3), Amount: cad(-300), Status: api.TransactionStatusReconciled, SplitParent: 2},
+		{PrimaryKey: 5, Account: 1, Date: date(4), Amount: cad(-700), Status: api.TransactionStatusCleared},
+		{PrimaryKey: 6, Account: 1, Date: date(20), Amount: cad(-900), Status: api.TransactionStatusReconciled},
+		{PrimaryKey: 7, Account: 2, Date: date(4), Amount: cad(-400), Status: api.TransactionStatusReconciled},
+	}
+
+	statements := []api.Statement{
+		// Balanced, ignoring split children, cleared transactions and other accounts.
+		{
+			PrimaryKey:      1,
+			Account:         1,
+			StartingDate:    date(1),
+			EndingDate:      date(15),
+			StartingBalance: cad(10000),
+			EndingBalance:   cad(8500),
+			IsReconciled:    true,
+		},
+		// Unbalanced, as if transaction 6 was changed after reconciling.
+		{
+			PrimaryKey:      2,
+			Account:         1,
+			StartingDate:    date(16),
+			EndingDate:      date(30),
+			StartingBalance: cad(8500),
+			EndingBalance:   cad(7000),
+			IsReconciled:    true,
+		},
+		// Unbalanced, but not yet reconciled.
+		{
+			PrimaryKey:      3,
+			Account:         2,
+			StartingDate:    date(1),
+			EndingDate:      date(30),
+			StartingBalance: cad(0),
+			EndingBalance:   cad(-1000),
+		},
+	}
+
+	unbalancedStatements := doctor.GetUnbalancedStatements(statements, transactions)
+	assert.Equal(t, []doctor.UnbalancedStatement{
+		{Statement: statements[1], ReconciledBalance: cad(7600)},
+	}, unbalancedStatements)
+	assert.Equal(t, cad(-600), unbalancedStatements[0].GetImbalance())
+}
@@ -0,0 +1,455 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+const (
+	// BeancountUncategorizedExpenses balances withdrawals never assigned a bucket.
+	BeancountUncategorizedExpenses = "Expenses:Uncategorized"
+	// BeancountUncategorizedIncome balances deposits never assigned a bucket.
+	BeancountUncategorizedIncome = "Income:Uncategorized"
+	// BeancountTransfers balances transfers between two split transactions.
+	BeancountTransfers = "Equity:Transfers"
+	// BeancountOpeningBalances balances the initial balance of each account.
+	BeancountOpeningBalances = "Equity:Opening-Balances"
+	// BeancountBuckets is the parent of the virtual accounts tracking the balance of each
+	// bucket.
+	BeancountBuckets = "Equity:Buckets"
+	// BeancountCashFlow balances every posting to the virtual bucket accounts, so that its
+	// balance is always that of all buckets negated.
+	BeancountCashFlow = "Equity:Cash-Flow"
+)
+
+var beancountInvalidName = regexp.MustCompile(`[^\p{L}\p{Nd}]+`)
+var beancountInvalidTag = regexp.MustCompile(`[^A-Za-z0-9_/.-]+`)
+
+// getBeancountName sanitizes a MoneyWell name for use as a component of a Beancount account
+// name, which must start with a capital letter or digit and otherwise contain only letters,
+// digits and dashes.
+func getBeancountName(name string) string {
+	name = strings.Trim(beancountInvalidName.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		return "Unnamed"
+	}
+
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// getBeancountTag sanitizes a MoneyWell tag for use as a Beancount tag.
+func getBeancountTag(tag string) string {
+	return strings.Trim(beancountInvalidTag.ReplaceAllString(tag, "-"), "-")
+}
+
+// getBeancountString quotes the given text as a Beancount string.
+func getBeancountString(text string) string {
+	text = strings.Replace(text, `\`, `\\`, -1)
+	text = strings.Replace(text, `"`, `\"`, -1)
+	return `"` + ledgerWhitespace.ReplaceAllString(strings.TrimSpace(text), " ") + `"`
+}
+
+// getBeancountAccounts names the Beancount account of each MoneyWell account and bucket, as
+// well as the virtual account tracking the balance of each bucket.
+//
+// Accounts become assets, or liabilities if debts, credit cards or lines of credit, and buckets
+// become expenses or income. Each is nested under its account or bucket group, if any.
+func getBeancountAccounts(
+	document *api.Document,
+) (map[int64]string, map[int64]string, map[int64]string) {
+	accounts := make(map[int64]string, len(document.Accounts))
+	for _, account := range document.Accounts {
+		components := []string{"Assets"}
+		if account.IsLiability() {
+			components[0] = "Liabilities"
+		}
+		if accountGroup, ok := document.GetAccountGroup(account.AccountGroup); ok {
+			components = append(components, getBeancountName(accountGroup.Name))
+		}
+		components = append(components, getBeancountName(account.Name))
+
+		accounts[account.PrimaryKey] = strings.Join(components, ":")
+	}
+
+	buckets := make(map[int64]string, len(document.Buckets))
+	envelopes := make(map[int64]string, len(document.Buckets))
+	for _, bucket := range document.Buckets {
+		components := []string{}
+		if bucketGroup, ok := document.GetBucketGroup(bucket.BucketGroup); ok {
+			components = append(components, getBeancountName(bucketGroup.Name))
+		}
+		components = append(components, getBeancountName(bucket.Name))
+
+		root := "Expenses"
+		if bucket.Type == api.BucketTypeIncome {
+			root = "Income"
+		}
+
+		buckets[bucket.PrimaryKey] = root + ":" + strings.Join(components, ":")
+		envelopes[bucket.PrimaryKey] = BeancountBuckets + ":" + strings.Join(components, ":")
+	}
+
+	return accounts, buckets, envelopes
+}
+
+// beancountPosting is a single leg of a Beancount transaction.
+type beancountPosting struct {
+	account string
+	amount  money.Money
+	// price is the total cost of the posting, if in a different currency than the entry.
+	price money.Money
+	memo  string
+}
+
+// beancountEntry is a single dated Beancount transaction.
+type beancountEntry struct {
+	date     time.Time
+	header   string
+	postings []beancountPosting
+}
+
+// beancountEnvelopes accumulates the changes to the virtual bucket accounts within a single
+// entry, in the order the buckets are first changed.
+type beancountEnvelopes struct {
+	keys    []envelopeKey
+	amounts map[envelopeKey]money.Money
+}
+
+type envelopeKey struct {
+	bucket   int64
+	currency string
+}
+
+func (e *beancountEnvelopes) add(bucket int64, amount money.Money) {
+	if e.amounts == nil {
+		e.amounts = make(map[envelopeKey]money.Money)
+	}
+
+	key := envelopeKey{bucket: bucket, currency: amount.Currency}
+	if _, ok := e.amounts[key]; !ok {
+		e.keys = append(e.keys, key)
+	}
+	e.amounts[key] = e.amounts[key].Add(amount)
+}
+
+// getPostings returns a posting for each changed bucket, followed by the postings to the cash
+// flow account balancing them in each currency.
+func (e *beancountEnvelopes) getPostings(envelopes map[int64]string) []beancountPosting {
+	postings := []beancountPosting{}
+	currencies := []string{}
+	totals := make(map[string]money.Money)
+	for _, key := range e.keys {
+		if e.amounts[key].IsZero() {
+			continue
+		}
+
+		postings = append(postings, beancountPosting{
+			account: envelopes[key.bucket],
+			amount:  e.amounts[key],
+		})
+
+		if _, ok := totals[key.currency]; !ok {
+			currencies = append(currencies, key.currency)
+		}
+		totals[key.currency] = totals[key.currency].Add(e.amounts[key])
+	}
+
+	for _, currency := range currencies {
+		if totals[currency].IsZero() {
+			continue
+		}
+
+		postings = append(postings, beancountPosting{
+			account: BeancountCashFlow,
+			amount:  totals[currency].Multiply(-1),
+		})
+	}
+
+	return postings
+}
+
+// WriteBeancount writes the transactions of the document as a Beancount ledger, modelling the
+// buckets as envelopes.
+//
+// Every account and bucket is first opened, followed by one transaction per entry as given by
+// getEntries. Cleared and reconciled transactions are flagged as cleared, and MoneyWell tags
+// become Beancount tags.
+//
+// Each bucket also has a virtual account under Equity:Buckets whose balance matches that of
+// GetBucketBalance. The bucket starting balances, bucket transfers and transactions assigned a
+// bucket on or after the cash flow start date are posted to these virtual accounts, balanced by
+// postings to Equity:Cash-Flow. Finally, the balance of every account and bucket is asserted as
+// of the day after the last transaction.
+func WriteBeancount(w io.Writer, document *api.Document) error {
+	accounts, buckets, envelopes := getBeancountAccounts(document)
+	cashFlowStartDate := document.Settings.CashFlowStartDate
+
+	getAccount := func(posting posting) string {
+		switch posting.kind {
+		case postingAccount:
+			return accounts[posting.account]
+		case postingBucket:
+			return buckets[posting.bucket]
+		case postingTransfer:
+			return BeancountTransfers
+		case postingOpeningBalance:
+			return BeancountOpeningBalances
+		}
+
+		if posting.amount.Amount > 0 {
+			return BeancountUncategorizedExpenses
+		}
+		return BeancountUncategorizedIncome
+	}
+
+	beancountEntries := []beancountEntry{}
+
+	startingBalances := beancountEnvelopes{}
+	for _, bucket := range document.Buckets {
+		startingBalances.add(bucket.PrimaryKey, bucket.StartingBalance)
+	}
+	if postings := startingBalances.getPostings(envelopes); len(postings) > 0 {
+		beancountEntries = append(beancountEntries, beancountEntry{
+			date:     cashFlowStartDate,
+			header:   "* " + getBeancountString("Bucket starting balances"),
+			postings: postings,
+		})
+	}
+
+	bucketTransfers := make(map[time.Time]*beancountEnvelopes)
+	bucketTransferDates := []time.Time{}
+	for _, bucketTransfer := range document.BucketTransfers {
+		if bucketTransfer.Date.Before(cashFlowStartDate) {
+			continue
+		}
+
+		if bucketTransfers[bucketTransfer.Date] == nil {
+			bucketTransfers[bucketTransfer.Date] = &beancountEnvelopes{}
+			bucketTransferDates = append(bucketTransferDates, bucketTransfer.Date)
+		}
+		bucketTransfers[bucketTransfer.Date].add(bucketTransfer.Bucket, bucketTransfer.Amount)
+	}
+	for _, date := range bucketTransferDates {
+		postings := bucketTransfers[date].getPostings(envelopes)
+		if len(postings) == 0 {
+			continue
+		}
+
+		beancountEntries = append(beancountEntries, beancountEntry{
+			date:     date,
+			header:   "* " + getBeancountString("Bucket transfers"),
+			postings: postings,
+		})
+	}
+
+	for _, entry := range getEntries(document) {
+		transaction := entry.transaction
+
+		header := "!"
+		if entry.isCleared() {
+			header = "*"
+		}
+		header = fmt.Sprintf(
+			"%s %s %s",
+			header,
+			getBeancountString(transaction.Payee),
+			getBeancountString(transaction.Memo),
+		)
+		for _, tag := range entry.tags {
+			if tag := getBeancountTag(tag); tag != "" {
+				header += " #" + tag
+			}
+		}
+
+		postings := []beancountPosting{}
+		for _, posting := range entry.postings {
+			postings = append(postings, beancountPosting{
+				account: getAccount(posting),
+				amount:  posting.amount,
+				memo:    posting.memo,
+			})
+		}
+
+		// Beancount won't infer the exchange rate of a transfer between currencies, so give
+		// the total cost of the other side explicitly.
+		if len(postings) == 2 && postings[0].amount.Currency != postings[1].amount.Currency {
+			postings[1].price = postings[0].amount
+			if postings[1].price.Amount < 0 {
+				postings[1].price = postings[1].price.Multiply(-1)
+			}
+		}
+
+		envelopeChanges := beancountEnvelopes{}
+		for _, transaction := range entry.transactions {
+			switch transaction.Status {
+			case api.TransactionStatusVoided:
+				fallthrough
+			case api.TransactionStatusPending:
+				continue
+			}
+
+			if transaction.Bucket == 0 || transaction.Date.Before(cashFlowStartDate) {
+				continue
+			}
+
+			envelopeChanges.add(transaction.Bucket, transaction.Amount)
+		}
+		postings = append(postings, envelopeChanges.getPostings(envelopes)...)
+
+		beancountEntries = append(beancountEntries, beancountEntry{
+			date:     transaction.Date,
+			header:   header,
+			postings: postings,
+		})
+	}
+
+	sort.SliceStable(beancountEntries, func(i, j int) bool {
+		return beancountEntries[i].date.Before(beancountEntries[j].date)
+	})
+
+	openDate := cashFlowStartDate
+	balanceDate := cashFlowStartDate
+	for _, beancountEntry := range beancountEntries {
+		if beancountEntry.date.Before(openDate) {
+			openDate = beancountEntry.date
+		}
+		if beancountEntry.date.After(balanceDate) {
+			balanceDate = beancountEntry.date
+		}
+	}
+	balanceDate = balanceDate.AddDate(0, 0, 1)
+
+	opened := make(map[string]bool)
+	openings := []string{}
+	open := func(name string, currency string) {
+		if opened[name] {
+			return
+		}
+		opened[name] = true
+
+		opening := fmt.Sprintf("%s open %s", formatDate(openDate), name)
+		if currency != "" {
+			opening += " " + currency
+		}
+		openings = append(openings, opening)
+	}
+	for _, account := range document.Accounts {
+		open(accounts[account.PrimaryKey], account.CurrencyCode)
+	}
+	for _, bucket := range document.Buckets {
+		open(buckets[bucket.PrimaryKey], "")
+	}
+	for _, bucket := range document.Buckets {
+		open(envelopes[bucket.PrimaryKey], "")
+	}
+
+	others := []string{}
+	seen := make(map[string]bool)
+	for _, beancountEntry := range beancountEntries {
+		for _, posting := range beancountEntry.postings {
+			if !opened[posting.account] && !seen[posting.account] {
+				seen[posting.account] = true
+				others = append(others, posting.account)
+			}
+		}
+	}
+	sort.Strings(others)
+	for _, other := range others {
+		open(other, "")
+	}
+
+	balances := []string{}
+	assertBalance := func(name string, balance money.Money) {
+		balances = append(balances, fmt.Sprintf(
+			"%s balance %s  %s",
+			formatDate(balanceDate),
+			name,
+			formatAmount(balance),
+		))
+	}
+	for _, account := range document.Accounts {
+		balance := api.GetAccountBalance(account, document.Transactions)
+		if balance.Currency == "" {
+			balance.Currency = account.CurrencyCode
+		}
+		if balance.Currency == "" {
+			continue
+		}
+
+		assertBalance(accounts[account.PrimaryKey], balance)
+	}
+	for _, bucket := range document.Buckets {
+		events, err := api.GetBucketEvents(bucket, document.Transactions, document.BucketTransfers)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get bucket events for %s", bucket.Name)
+		}
+
+		balance, err := api.GetBucketBalance(bucket, events, document.Settings)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get bucket balance for %s", bucket.Name)
+		}
+
+		// Buckets don't always record a currency, so prefer that of the balance itself.
+		if balance.Currency == "" {
+			balance.Currency = bucket.CurrencyCode
+		}
+		if balance.Currency == "" {
+			continue
+		}
+
+		assertBalance(envelopes[bucket.PrimaryKey], balance)
+	}
+
+	writer := bufio.NewWriter(w)
+	for _, opening := range openings {
+		fmt.Fprintf(writer, "%s\n", opening)
+	}
+
+	for _, beancountEntry := range beancountEntries {
+		fmt.Fprintf(writer, "\n%s %s\n", formatDate(beancountEntry.date), beancountEntry.header)
+
+		width := 0
+		for _, posting := range beancountEntry.postings {
+			if len(posting.account) > width {
+				width = len(posting.account)
+			}
+		}
+
+		for _, posting := range beancountEntry.postings {
+			line := fmt.Sprintf("  %-*s  %s", width, posting.account, formatAmount(posting.amount))
+			if posting.price.Currency != "" {
+				line += " @@ " + formatAmount(posting.price)
+			}
+			if memo := strings.TrimSpace(posting.memo); memo != "" {
+				line += "  ; " + ledgerWhitespace.ReplaceAllString(memo, " ")
+			}
+			fmt.Fprintf(writer, "%s\n", line)
+		}
+	}
+
+	if len(balances) > 0 {
+		fmt.Fprintf(writer, "\n")
+	}
+	for _, balance := range balances {
+		fmt.Fprintf(writer, "%s\n", balance)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write beancount ledger")
+	}
+
+	return nil
+}
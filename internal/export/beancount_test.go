@@ -0,0 +1,171 @@
+package export_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/export"
+)
+
+// checkBeancount verifies, much like bean-check, that every transaction in the given Beancount
+// ledger balances in each currency and that every balance assertion holds.
+func checkBeancount(t *testing.T, ledger string) {
+	t.Helper()
+
+	balances := make(map[string]string)
+	totals := make(map[string]float64)
+	transaction := make(map[string]float64)
+	checkTransaction := func() {
+		for currency, total := range transaction {
+			assert.InDelta(t, 0, total, 0.001, "transaction unbalanced in %s", currency)
+		}
+		transaction = make(map[string]float64)
+	}
+
+	for _, line := range strings.Split(ledger, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			checkTransaction()
+
+		case strings.HasPrefix(line, "  "):
+			if len(fields) < 3 || strings.HasPrefix(fields[0], ";") {
+				continue
+			}
+
+			amount := parseAmount(t, fields[1])
+			totals[fields[0]+" "+fields[2]] += amount
+
+			// Weigh postings at their total cost, if given.
+			if len(fields) >= 6 && fields[3] == "@@" {
+				amount = parseAmount(t, fields[4])
+				if strings.HasPrefix(fields[1], "-") {
+					amount = -amount
+				}
+				transaction[fields[5]] += amount
+			} else {
+				transaction[fields[2]] += amount
+			}
+
+		case len(fields) == 5 && fields[1] == "balance":
+			balances[fields[2]+" "+fields[4]] = fields[3]
+		}
+	}
+	checkTransaction()
+
+	for account, balance := range balances {
+		assert.InDelta(t, parseAmount(t, balance), totals[account], 0.001, "balance of %s", account)
+	}
+}
+
+func TestWriteBeancount(t *testing.T) {
+	t.Parallel()
+
+	document := loadDocument(t)
+
+	var buffer bytes.Buffer
+	err := export.WriteBeancount(&buffer, document)
+	assert.NoError(t, err)
+	ledger := buffer.String()
+
+	checkBeancount(t, ledger)
+
+	assert.True(t, strings.HasPrefix(ledger, "2017-11-01 open Assets:Cash CAD\n"))
+	assert.Contains(t, ledger, "2017-11-01 open Assets:Cash-USD USD\n")
+	assert.Contains(t, ledger, "2017-11-01 open Liabilities:Other-Bank:Visa CAD\n")
+	assert.Contains(t, ledger, "2017-11-01 open Liabilities:Other-Bank:Line-of-Credit CAD\n")
+	assert.Contains(t, ledger, "2017-11-01 open Expenses:Bills:Mortgage-Rent\n")
+	assert.Contains(t, ledger, "2017-11-01 open Equity:Buckets:Bills:Mortgage-Rent\n")
+
+	assert.Contains(t, ledger, `
+2017-11-05 ! "Grocery Store" "Chick peas and tuna." #tag2 #tag4
+  Assets:Bank:Chequing-Account  -350.00 CAD
+  Expenses:Groceries            350.00 CAD
+  Equity:Buckets:Groceries      -350.00 CAD
+  Equity:Cash-Flow              350.00 CAD
+`)
+
+	// Only the split child assigned a bucket changes the bucket.
+	assert.Contains(t, ledger, `
+2017-11-12 ! "Split Test" ""
+  Assets:Bank:Chequing-Account  -500.00 CAD
+  Expenses:Groceries            100.00 CAD
+  Assets:Cash                   400.00 CAD
+  Equity:Buckets:Groceries      -100.00 CAD
+  Equity:Cash-Flow              100.00 CAD
+`)
+
+	// The initial balance of an account is an opening balance, but those of $0.00 are omitted.
+	assert.Contains(t, ledger, "2017-11-01 open Equity:Opening-Balances\n")
+	assert.Contains(t, ledger, `
+2017-11-12 * "Initial Balance" ""
+  Assets:Bank:Savings      100.00 CAD
+  Equity:Opening-Balances  -100.00 CAD
+`)
+	assert.NotContains(t, ledger, `2017-11-01 * "Initial Balance"`)
+	assert.NotContains(t, ledger, "Income:Uncategorized")
+
+	assert.Contains(t, ledger, `
+2017-11-19 * "Bucket transfers"
+  Equity:Buckets:Discretionary:Hobbies  100.00 CAD
+  Equity:Buckets:Groceries              250.00 CAD
+  Equity:Buckets:Bills:Mortgage-Rent    550.00 CAD
+  Equity:Buckets:Salary:Salary          -900.00 CAD
+`)
+
+	assert.Contains(t, ledger, "2017-11-20 balance Assets:Bank:Chequing-Account  -350.00 CAD\n")
+	assert.Contains(t, ledger, "2017-11-20 balance Equity:Buckets:Salary:Salary  100.00 CAD\n")
+	assert.Contains(t, ledger, "2017-11-20 balance Equity:Buckets:Groceries  -200.00 CAD\n")
+	assert.Contains(t, ledger, "2017-11-20 balance Equity:Buckets:Bills:Mortgage-Rent  50.00 CAD\n")
+}
+
+func TestWriteBeancountCurrencyTransfer(t *testing.T) {
+	t.Parallel()
+
+	document := loadDocument(t)
+
+	date := time.Date(2017, 11, 20, 0, 0, 0, 0, time.UTC)
+	document = withTransactions(document,
+		api.Transaction{
+			PrimaryKey:      1001,
+			Date:            date,
+			Amount:          money.Money{Currency: "CAD", Amount: -10000},
+			Account:         1,
+			TransferAccount: 4,
+			TransferSibling: 1002,
+			Status:          api.TransactionStatusReconciled,
+			Payee:           "Exchange",
+		},
+		api.Transaction{
+			PrimaryKey:      1002,
+			Date:            date,
+			Amount:          money.Money{Currency: "USD", Amount: 7500},
+			Account:         4,
+			TransferAccount: 1,
+			TransferSibling: 1001,
+			Status:          api.TransactionStatusReconciled,
+			Payee:           "Exchange",
+		},
+	)
+
+	var buffer bytes.Buffer
+	err := export.WriteBeancount(&buffer, document)
+	assert.NoError(t, err)
+	ledger := buffer.String()
+
+	checkBeancount(t, ledger)
+
+	assert.Contains(t, ledger, `
+2017-11-20 * "Exchange" ""
+  Assets:Bank:Chequing-Account  -100.00 CAD
+  Assets:Cash-USD               75.00 USD @@ 100.00 CAD
+`)
+	assert.Contains(t, ledger, "2017-11-21 balance Assets:Cash-USD  75.00 USD\n")
+	assert.Contains(t, ledger, "2017-11-21 balance Assets:Bank:Chequing-Account  -450.00 CAD\n")
+}
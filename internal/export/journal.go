@@ -0,0 +1,244 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+const (
+	// postingAccount posts against a MoneyWell account.
+	postingAccount = iota
+	// postingBucket posts against a MoneyWell bucket.
+	postingBucket
+	// postingUncategorized posts against neither an account nor a bucket, balancing a
+	// transaction that was never assigned a bucket.
+	postingUncategorized
+	// postingTransfer balances one side of a transfer that can't be paired with the other side
+	// within a single entry, such as a transfer between two split children.
+	postingTransfer
+	// postingOpeningBalance balances the initial balance of an account, which is neither income
+	// nor an expense.
+	postingOpeningBalance
+)
+
+// initialBalancePayee is the payee MoneyWell gives the transaction recording the balance of a
+// new account.
+const initialBalancePayee = "Initial Balance"
+
+// posting is a single leg of a journal entry.
+type posting struct {
+	kind    int
+	account int64
+	bucket  int64
+	amount  money.Money
+	memo    string
+}
+
+// entry is a balanced journal entry derived from one or more MoneyWell transactions.
+type entry struct {
+	transaction api.Transaction
+	// transactions are all the transactions represented by the entry, including the
+	// transaction itself, any split children and any transfer siblings.
+	transactions []api.Transaction
+	tags         []string
+	postings     []posting
+}
+
+// isCleared determines if the entry's transaction has cleared the bank.
+func (e entry) isCleared() bool {
+	switch e.transaction.Status {
+	case api.TransactionStatusReconciled, api.TransactionStatusCleared:
+		return true
+	}
+
+	return false
+}
+
+// isPosted determines if the transaction affects the balance of its account, omitting voided and
+// pending transactions.
+func isPosted(transaction api.Transaction) bool {
+	switch transaction.Status {
+	case api.TransactionStatusVoided:
+		fallthrough
+	case api.TransactionStatusPending:
+		return false
+	}
+
+	return true
+}
+
+// getEntries converts the transactions of the document into balanced journal entries, in the
+// order of the transactions.
+//
+// Voided and pending transactions are omitted. A split parent becomes a single entry with a
+// posting for each of its children, and the two sides of a transfer become a single entry
+// against both accounts. The earliest initial balance of each account is posted as an opening
+// balance, but any other amount not assigned to a bucket is posted as uncategorized. Entries
+// that don't move any money, such as an empty initial balance, are omitted altogether.
+func getEntries(document *api.Document) []entry {
+	exported := make(map[int64]bool)
+
+	openingBalances := make(map[int64]api.Transaction)
+	for _, transaction := range document.Transactions {
+		if !isPosted(transaction) || transaction.Payee != initialBalancePayee {
+			continue
+		}
+
+		if transaction.IsSplit || transaction.SplitParent != 0 || transaction.IsTransfer() {
+			continue
+		}
+
+		if transaction.Bucket != 0 {
+			continue
+		}
+
+		openingBalance, ok := openingBalances[transaction.Account]
+		if !ok || transaction.Date.Before(openingBalance.Date) {
+			openingBalances[transaction.Account] = transaction
+		}
+	}
+
+	// getCounterPosting balances the given transaction, or split child, and notes any
+	// transfer sibling already accounted for. It returns the posting alongside the given
+	// transaction and its sibling, if any.
+	getCounterPosting := func(transaction api.Transaction) (posting, []api.Transaction) {
+		transactions := []api.Transaction{transaction}
+		counterPosting := posting{
+			kind:   postingUncategorized,
+			amount: transaction.Amount.Multiply(-1),
+			memo:   transaction.Memo,
+		}
+
+		if transaction.IsTransfer() {
+			sibling, ok := document.GetTransaction(transaction.TransferSibling)
+			switch {
+			case !ok:
+				counterPosting.kind = postingAccount
+				counterPosting.account = transaction.TransferAccount
+			case transaction.SplitParent != 0 && sibling.SplitParent != 0:
+				counterPosting.kind = postingTransfer
+			default:
+				counterPosting.kind = postingAccount
+				counterPosting.account = sibling.Account
+				counterPosting.amount = sibling.Amount
+				exported[sibling.PrimaryKey] = true
+				transactions = append(transactions, sibling)
+			}
+		} else if transaction.Bucket != 0 {
+			counterPosting.kind = postingBucket
+			counterPosting.bucket = transaction.Bucket
+		}
+
+		return counterPosting, transactions
+	}
+
+	entries := []entry{}
+	for _, transaction := range document.Transactions {
+		if !isPosted(transaction) {
+			continue
+		}
+
+		if transaction.SplitParent != 0 || exported[transaction.PrimaryKey] {
+			continue
+		}
+
+		// Leave a transfer from a split child to be exported alongside the split parent.
+		if transaction.IsTransfer() {
+			sibling, ok := document.GetTransaction(transaction.TransferSibling)
+			if ok && sibling.SplitParent != 0 {
+				continue
+			}
+		}
+
+		exported[transaction.PrimaryKey] = true
+
+		tags := []string{}
+		for _, primaryKey := range document.TransactionTags[transaction.PrimaryKey] {
+			tag, _ := document.GetTag(primaryKey)
+			tags = append(tags, tag.Name)
+		}
+
+		postings := []posting{{
+			kind:    postingAccount,
+			account: transaction.Account,
+			amount:  transaction.Amount,
+		}}
+
+		var transactions []api.Transaction
+		if transaction.IsSplit {
+			transactions = []api.Transaction{transaction}
+			remainder := transaction.Amount.Multiply(-1)
+			for _, child := range document.GetSplitChildren(transaction.PrimaryKey) {
+				counterPosting, childTransactions := getCounterPosting(child)
+				postings = append(postings, counterPosting)
+				transactions = append(transactions, childTransactions...)
+				remainder = remainder.Add(child.Amount)
+			}
+
+			if !remainder.IsZero() {
+				postings = append(postings, posting{
+					kind:   postingUncategorized,
+					amount: remainder,
+				})
+			}
+		} else {
+			var counterPosting posting
+			counterPosting, transactions = getCounterPosting(transaction)
+			counterPosting.memo = ""
+			openingBalance, ok := openingBalances[transaction.Account]
+			if ok && openingBalance.PrimaryKey == transaction.PrimaryKey {
+				counterPosting.kind = postingOpeningBalance
+			}
+			postings = append(postings, counterPosting)
+		}
+
+		isZero := true
+		for _, posting := range postings {
+			if !posting.amount.IsZero() {
+				isZero = false
+				break
+			}
+		}
+		if isZero {
+			continue
+		}
+
+		entries = append(entries, entry{
+			transaction:  transaction,
+			transactions: transactions,
+			tags:         tags,
+			postings:     postings,
+		})
+	}
+
+	return entries
+}
+
+// formatDate formats a date as YYYY-MM-DD.
+func formatDate(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// formatDecimal formats the amount of money as a decimal without its currency, e.g. -10.50.
+func formatDecimal(amount money.Money) string {
+	sign := ""
+	cents := amount.Amount
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// formatAmount formats the money as a decimal followed by its currency, e.g. -10.50 CAD.
+func formatAmount(amount money.Money) string {
+	if amount.Currency == "" {
+		return formatDecimal(amount)
+	}
+
+	return fmt.Sprintf("%s %s", formatDecimal(amount), amount.Currency)
+}
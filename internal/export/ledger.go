@@ -0,0 +1,190 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+const (
+	// LedgerUncategorizedExpenses balances withdrawals never assigned a bucket.
+	LedgerUncategorizedExpenses = "expenses:uncategorized"
+	// LedgerUncategorizedIncome balances deposits never assigned a bucket.
+	LedgerUncategorizedIncome = "income:uncategorized"
+	// LedgerTransfers balances transfers between two split transactions.
+	LedgerTransfers = "equity:transfers"
+	// LedgerOpeningBalances balances the initial balance of each account.
+	LedgerOpeningBalances = "equity:opening balances"
+)
+
+var ledgerWhitespace = regexp.MustCompile(`\s+`)
+
+// getLedgerName sanitizes a MoneyWell name for use as a component of a ledger account name,
+// which may contain single spaces but neither colons nor consecutive whitespace.
+func getLedgerName(name string) string {
+	name = strings.Replace(name, ":", "-", -1)
+	name = ledgerWhitespace.ReplaceAllString(strings.TrimSpace(name), " ")
+	if name == "" {
+		name = "unnamed"
+	}
+
+	return name
+}
+
+// getLedgerTag sanitizes a MoneyWell tag for use as a ledger tag name, which may contain
+// neither whitespace nor colons.
+func getLedgerTag(tag string) string {
+	tag = strings.Replace(tag, ":", "-", -1)
+	return ledgerWhitespace.ReplaceAllString(strings.TrimSpace(tag), "_")
+}
+
+// getLedgerAccounts names the ledger account of each MoneyWell account and bucket.
+//
+// Accounts become assets, or liabilities if debts, credit cards or lines of credit, and buckets
+// become expenses or income. Each is nested under its account or bucket group, if any.
+func getLedgerAccounts(document *api.Document) (map[int64]string, map[int64]string) {
+	accounts := make(map[int64]string, len(document.Accounts))
+	for _, account := range document.Accounts {
+		components := []string{"assets"}
+		if account.IsLiability() {
+			components[0] = "liabilities"
+		}
+		if accountGroup, ok := document.GetAccountGroup(account.AccountGroup); ok {
+			components = append(components, getLedgerName(accountGroup.Name))
+		}
+		components = append(components, getLedgerName(account.Name))
+
+		accounts[account.PrimaryKey] = strings.Join(components, ":")
+	}
+
+	buckets := make(map[int64]string, len(document.Buckets))
+	for _, bucket := range document.Buckets {
+		components := []string{"expenses"}
+		if bucket.Type == api.BucketTypeIncome {
+			components[0] = "income"
+		}
+		if bucketGroup, ok := document.GetBucketGroup(bucket.BucketGroup); ok {
+			components = append(components, getLedgerName(bucketGroup.Name))
+		}
+		components = append(components, getLedgerName(bucket.Name))
+
+		buckets[bucket.PrimaryKey] = strings.Join(components, ":")
+	}
+
+	return accounts, buckets
+}
+
+// WriteLedger writes the transactions of the document as a journal readable by both ledger and
+// hledger.
+//
+// Every account and bucket is first declared, followed by one entry per transaction as given by
+// getEntries. Cleared and reconciled transactions are marked as cleared, and MoneyWell tags
+// become ledger tags. Amounts are written in the currency of their account, so transfers
+// between accounts of different currencies leave ledger to infer the exchange rate.
+func WriteLedger(w io.Writer, document *api.Document) error {
+	accounts, buckets := getLedgerAccounts(document)
+	entries := getEntries(document)
+
+	getAccount := func(posting posting) string {
+		switch posting.kind {
+		case postingAccount:
+			return accounts[posting.account]
+		case postingBucket:
+			return buckets[posting.bucket]
+		case postingTransfer:
+			return LedgerTransfers
+		case postingOpeningBalance:
+			return LedgerOpeningBalances
+		}
+
+		if posting.amount.Amount > 0 {
+			return LedgerUncategorizedExpenses
+		}
+		return LedgerUncategorizedIncome
+	}
+
+	declared := make(map[string]bool)
+	declarations := []string{}
+	declare := func(name string) {
+		if !declared[name] {
+			declared[name] = true
+			declarations = append(declarations, name)
+		}
+	}
+	for _, account := range document.Accounts {
+		declare(accounts[account.PrimaryKey])
+	}
+	for _, bucket := range document.Buckets {
+		declare(buckets[bucket.PrimaryKey])
+	}
+
+	others := []string{}
+	for _, entry := range entries {
+		for _, posting := range entry.postings {
+			if name := getAccount(posting); !declared[name] {
+				declared[name] = true
+				others = append(others, name)
+			}
+		}
+	}
+	sort.Strings(others)
+	declarations = append(declarations, others...)
+
+	writer := bufio.NewWriter(w)
+	for _, declaration := range declarations {
+		fmt.Fprintf(writer, "account %s\n", declaration)
+	}
+
+	for _, entry := range entries {
+		transaction := entry.transaction
+
+		header := formatDate(transaction.Date)
+		if entry.isCleared() {
+			header += " *"
+		}
+		if payee := strings.TrimSpace(transaction.Payee); payee != "" {
+			header += " " + ledgerWhitespace.ReplaceAllString(payee, " ")
+		}
+		if memo := strings.TrimSpace(transaction.Memo); memo != "" {
+			header += "  ; " + ledgerWhitespace.ReplaceAllString(memo, " ")
+		}
+		fmt.Fprintf(writer, "\n%s\n", header)
+
+		for _, tag := range entry.tags {
+			fmt.Fprintf(writer, "    ; %s:\n", getLedgerTag(tag))
+		}
+
+		width := 0
+		for _, posting := range entry.postings {
+			if len(getAccount(posting)) > width {
+				width = len(getAccount(posting))
+			}
+		}
+
+		for _, posting := range entry.postings {
+			line := fmt.Sprintf(
+				"    %-*s  %s",
+				width,
+				getAccount(posting),
+				formatAmount(posting.amount),
+			)
+			if memo := strings.TrimSpace(posting.memo); memo != "" {
+				line += "  ; " + ledgerWhitespace.ReplaceAllString(memo, " ")
+			}
+			fmt.Fprintf(writer, "%s\n", line)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write ledger journal")
+	}
+
+	return nil
+}
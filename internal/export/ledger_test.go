@@ -0,0 +1,217 @@
+package export_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/export"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func loadDocument(t *testing.T) *api.Document {
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	return document
+}
+
+// loadDocumentWithCheck copies the test document, inserting a check against the chequing
+// account, and fetches the copy.
+func loadDocumentWithCheck(t *testing.T) (*api.Document, api.Transaction) {
+	moneywellPath := filepath.Join(t.TempDir(), "Test.moneywell")
+	assert.NoError(t, api.CopyDocument("../../api/Test.moneywell", moneywellPath))
+
+	database, err := api.OpenWritableDocument(moneywellPath)
+	assert.NoError(t, err)
+	defer database.Close()
+
+	check, err := api.InsertTransaction(database, api.Transaction{
+		Date:        time.Date(2017, 11, 11, 0, 0, 0, 0, time.UTC),
+		Amount:      money.Money{Amount: -120 * 100},
+		Account:     1,
+		Bucket:      2,
+		Status:      api.TransactionStatusOpen,
+		Payee:       "Landlord",
+		CheckNumber: "1042",
+	})
+	assert.NoError(t, err)
+
+	document, err := api.LoadDocument(database)
+	assert.NoError(t, err)
+
+	return document, check
+}
+
+// withTransactions copies the given document, appending the given transactions. The document is
+// indexed on first use, so transactions cannot be appended to it in place.
+func withTransactions(document *api.Document, transactions ...api.Transaction) *api.Document {
+	return &api.Document{
+		Settings:        document.Settings,
+		AccountGroups:   document.AccountGroups,
+		Accounts:        document.Accounts,
+		BucketGroups:    document.BucketGroups,
+		Buckets:         document.Buckets,
+		BucketTransfers: document.BucketTransfers,
+		Tags:            document.Tags,
+		Transactions:    append(append([]api.Transaction{}, document.Transactions...), transactions...),
+		TransactionTags: document.TransactionTags,
+		Statements:      document.Statements,
+	}
+}
+
+func parseAmount(t *testing.T, amount string) float64 {
+	value, err := strconv.ParseFloat(amount, 64)
+	assert.NoError(t, err)
+
+	return value
+}
+
+func TestWriteLedger(t *testing.T) {
+	t.Parallel()
+
+	document := loadDocument(t)
+
+	var buffer bytes.Buffer
+	err := export.WriteLedger(&buffer, document)
+	assert.NoError(t, err)
+	journal := buffer.String()
+
+	assert.True(t, strings.HasPrefix(journal, "account assets:Cash\n"))
+	assert.Contains(t, journal, "account liabilities:Other Bank:Visa\n")
+	assert.Contains(t, journal, "account liabilities:Other Bank:Line of Credit\n")
+	assert.Contains(t, journal, "account expenses:Bills:Mortgage/Rent\n")
+	assert.Contains(t, journal, "account equity:opening balances\n")
+
+	assert.Contains(t, journal, `
+2017-11-01 * Work
+    ; tag1:
+    assets:Bank:Chequing Account  1000.00 CAD
+    income:Salary:Salary          -1000.00 CAD
+`)
+
+	assert.Contains(t, journal, `
+2017-11-05 Grocery Store  ; Chick peas and tuna.
+    ; tag2:
+    ; tag4:
+    assets:Bank:Chequing Account  -350.00 CAD
+    expenses:Groceries            350.00 CAD
+`)
+
+	// The split parent absorbs both its children and the other side of the transfer.
+	assert.Contains(t, journal, `
+2017-11-12 Split Test
+    assets:Bank:Chequing Account  -500.00 CAD
+    expenses:Groceries            100.00 CAD
+    assets:Cash                   400.00 CAD
+`)
+	assert.Equal(t, 1, strings.Count(journal, " 400.00 CAD\n"))
+
+	// The initial balance of an account is an opening balance, but those of $0.00 are omitted.
+	assert.Contains(t, journal, `
+2017-11-12 * Initial Balance
+    assets:Bank:Savings      100.00 CAD
+    equity:opening balances  -100.00 CAD
+`)
+	assert.NotContains(t, journal, "2017-11-01 * Initial Balance")
+
+	// Voided and pending transactions are omitted.
+	assert.NotContains(t, journal, "2017-11-25")
+	assert.NotContains(t, journal, "2099-12-31")
+}
+
+func TestWriteLedgerOpeningBalance(t *testing.T) {
+	t.Parallel()
+
+	// Only the earliest initial balance of an account is an opening balance.
+	document := withTransactions(loadDocument(t), api.Transaction{
+		PrimaryKey: 1001,
+		Date:       time.Date(2017, 11, 20, 0, 0, 0, 0, time.UTC),
+		Amount:     money.Money{Currency: "CAD", Amount: 5000},
+		Account:    2,
+		Status:     api.TransactionStatusReconciled,
+		Payee:      "Initial Balance",
+	})
+
+	var buffer bytes.Buffer
+	err := export.WriteLedger(&buffer, document)
+	assert.NoError(t, err)
+	journal := buffer.String()
+
+	assert.Equal(t, 1, strings.Count(journal, "equity:opening balances  -"))
+	assert.Contains(t, journal, "account income:uncategorized\n")
+	assert.Contains(t, journal, `
+2017-11-20 * Initial Balance
+    assets:Bank:Savings   50.00 CAD
+    income:uncategorized  -50.00 CAD
+`)
+}
+
+func TestWriteLedgerTransfer(t *testing.T) {
+	t.Parallel()
+
+	document := loadDocument(t)
+
+	date := time.Date(2017, 11, 20, 0, 0, 0, 0, time.UTC)
+	document = withTransactions(document,
+		api.Transaction{
+			PrimaryKey:      1001,
+			Date:            date,
+			Amount:          money.Money{Currency: "CAD", Amount: -2500},
+			Account:         1,
+			TransferAccount: 2,
+			TransferSibling: 1002,
+			Status:          api.TransactionStatusCleared,
+			Payee:           "Transfer",
+		},
+		api.Transaction{
+			PrimaryKey:      1002,
+			Date:            date,
+			Amount:          money.Money{Currency: "CAD", Amount: 2500},
+			Account:         2,
+			TransferAccount: 1,
+			TransferSibling: 1001,
+			Status:          api.TransactionStatusCleared,
+			Payee:           "Transfer",
+		},
+		api.Transaction{
+			PrimaryKey: 1003,
+			Date:       date,
+			Amount:     money.Money{Currency: "CAD", Amount: -1050},
+			Account:    6,
+			Status:     api.TransactionStatusOpen,
+			Payee:      "Bookstore",
+		},
+	)
+
+	var buffer bytes.Buffer
+	err := export.WriteLedger(&buffer, document)
+	assert.NoError(t, err)
+	journal := buffer.String()
+
+	assert.Equal(t, 1, strings.Count(journal, "* Transfer\n"))
+	assert.Contains(t, journal, `
+2017-11-20 * Transfer
+    assets:Bank:Chequing Account  -25.00 CAD
+    assets:Bank:Savings           25.00 CAD
+`)
+
+	assert.Contains(t, journal, "account expenses:uncategorized\n")
+	assert.Contains(t, journal, `
+2017-11-20 Bookstore
+    liabilities:Other Bank:Visa  -10.50 CAD
+    expenses:uncategorized       10.50 CAD
+`)
+}
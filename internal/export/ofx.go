@@ -0,0 +1,254 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+// ofxHeader is the header of an OFX 2.2 document.
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// ofxNameLength is the maximum length of the payee of an OFX transaction.
+const ofxNameLength = 32
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"SONRS>STATUS"`
+	Server   string    `xml:"SONRS>DTSERVER"`
+	Language string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxBankAccount struct {
+	BankID      string `xml:"BANKID"`
+	AccountID   string `xml:"ACCTID"`
+	AccountType string `xml:"ACCTTYPE"`
+}
+
+type ofxCreditCardAccount struct {
+	AccountID string `xml:"ACCTID"`
+}
+
+type ofxTransaction struct {
+	Type        string `xml:"TRNTYPE"`
+	Posted      string `xml:"DTPOSTED"`
+	Amount      string `xml:"TRNAMT"`
+	ID          string `xml:"FITID"`
+	CheckNumber string `xml:"CHECKNUM,omitempty"`
+	Name        string `xml:"NAME,omitempty"`
+	Memo        string `xml:"MEMO,omitempty"`
+}
+
+type ofxTransactionList struct {
+	Start        string           `xml:"DTSTART"`
+	End          string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxStatement struct {
+	Currency          string                `xml:"CURDEF"`
+	BankAccount       *ofxBankAccount       `xml:"BANKACCTFROM,omitempty"`
+	CreditCardAccount *ofxCreditCardAccount `xml:"CCACCTFROM,omitempty"`
+	Transactions      ofxTransactionList    `xml:"BANKTRANLIST"`
+	LedgerBalance     ofxBalance            `xml:"LEDGERBAL"`
+}
+
+type ofxStatementResponse struct {
+	ID        string       `xml:"TRNUID"`
+	Status    ofxStatus    `xml:"STATUS"`
+	Statement ofxStatement `xml:"STMTRS"`
+}
+
+type ofxCreditCardStatementResponse struct {
+	ID        string       `xml:"TRNUID"`
+	Status    ofxStatus    `xml:"STATUS"`
+	Statement ofxStatement `xml:"CCSTMTRS"`
+}
+
+type ofxDocument struct {
+	XMLName    xml.Name                        `xml:"OFX"`
+	SignOn     ofxSignOn                       `xml:"SIGNONMSGSRSV1"`
+	Bank       *ofxStatementResponse           `xml:"BANKMSGSRSV1>STMTTRNRS,omitempty"`
+	CreditCard *ofxCreditCardStatementResponse `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS,omitempty"`
+}
+
+// formatOFXDate formats a date as YYYYMMDD.
+func formatOFXDate(date time.Time) string {
+	return date.Format("20060102")
+}
+
+// getOFXTransactionType returns the OFX transaction type of the given transaction.
+func getOFXTransactionType(transaction api.Transaction) string {
+	switch {
+	case transaction.CheckNumber != "" ||
+		transaction.TransactionType == api.TransactionTypeCheck:
+		return "CHECK"
+	case transaction.IsTransfer():
+		return "XFER"
+	case transaction.Amount.Amount < 0:
+		return "DEBIT"
+	}
+
+	return "CREDIT"
+}
+
+// getOFXName truncates the payee to the maximum length allowed by OFX.
+func getOFXName(payee string) string {
+	payee = ledgerWhitespace.ReplaceAllString(strings.TrimSpace(payee), " ")
+	if utf8.RuneCountInString(payee) > ofxNameLength {
+		payee = string([]rune(payee)[:ofxNameLength])
+	}
+
+	return payee
+}
+
+// WriteOFX writes the transactions against the given account as an OFX 2.2 bank or credit card
+// statement.
+//
+// Each transaction includes its payee, memo and check number, identified by the MoneyWell
+// unique id. OFX has no notion of categories or splits, so buckets are omitted and split
+// transactions are written as the split parent alone. Voided and pending transactions are
+// omitted, and the statement ends on the date of the last transaction with the balance of the
+// account. The statement is generated as of the given date, which also bounds a statement
+// without any transactions. An account without a currency takes that of its transactions, failing
+// if it has none.
+func WriteOFX(w io.Writer, document *api.Document, account api.Account, now time.Time) error {
+	var start, end time.Time
+	currency := account.CurrencyCode
+	ofxTransactions := []ofxTransaction{}
+	for _, transaction := range document.GetAccountTransactions(account.PrimaryKey) {
+		if !isPosted(transaction) || transaction.SplitParent != 0 {
+			continue
+		}
+
+		if currency == "" {
+			currency = transaction.Amount.Currency
+		}
+
+		if start.IsZero() || transaction.Date.Before(start) {
+			start = transaction.Date
+		}
+		if transaction.Date.After(end) {
+			end = transaction.Date
+		}
+
+		id := transaction.UniqueID
+		if id == "" {
+			id = fmt.Sprintf("%d", transaction.PrimaryKey)
+		}
+
+		ofxTransactions = append(ofxTransactions, ofxTransaction{
+			Type:        getOFXTransactionType(transaction),
+			Posted:      formatOFXDate(transaction.Date),
+			Amount:      formatDecimal(transaction.Amount),
+			ID:          id,
+			CheckNumber: strings.TrimSpace(transaction.CheckNumber),
+			Name:        getOFXName(transaction.Payee),
+			Memo:        ledgerWhitespace.ReplaceAllString(strings.TrimSpace(transaction.Memo), " "),
+		})
+	}
+
+	if len(ofxTransactions) == 0 {
+		start = now
+		end = now
+	}
+
+	// OFX requires the currency of the statement.
+	if currency == "" {
+		return errors.Errorf("failed to determine the currency of account %s", account.Name)
+	}
+
+	status := ofxStatus{Code: 0, Severity: "INFO"}
+	accountID := account.AccountNumber
+	if accountID == "" {
+		accountID = fmt.Sprintf("%d", account.PrimaryKey)
+	}
+
+	statement := ofxStatement{
+		Currency: currency,
+		Transactions: ofxTransactionList{
+			Start:        formatOFXDate(start),
+			End:          formatOFXDate(end),
+			Transactions: ofxTransactions,
+		},
+		LedgerBalance: ofxBalance{
+			Amount: formatDecimal(api.GetAccountBalance(account, document.Transactions)),
+			AsOf:   formatOFXDate(end),
+		},
+	}
+
+	ofx := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   status,
+			Server:   formatOFXDate(now),
+			Language: "ENG",
+		},
+	}
+
+	if account.AccountType == api.AccountTypeCreditCard {
+		statement.CreditCardAccount = &ofxCreditCardAccount{AccountID: accountID}
+		ofx.CreditCard = &ofxCreditCardStatementResponse{
+			ID:        "0",
+			Status:    status,
+			Statement: statement,
+		}
+	} else {
+		accountType := "CHECKING"
+		switch {
+		case account.IsLiability():
+			accountType = "CREDITLINE"
+		case account.AccountType == api.AccountTypeSavings:
+			accountType = "SAVINGS"
+		}
+
+		bankID := account.RoutingNumber
+		if bankID == "" {
+			bankID = "0"
+		}
+
+		statement.BankAccount = &ofxBankAccount{
+			BankID:      bankID,
+			AccountID:   accountID,
+			AccountType: accountType,
+		}
+		ofx.Bank = &ofxStatementResponse{
+			ID:        "0",
+			Status:    status,
+			Statement: statement,
+		}
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return errors.Wrap(err, "failed to write ofx header")
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(ofx); err != nil {
+		return errors.Wrap(err, "failed to encode ofx")
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return errors.Wrap(err, "failed to write ofx")
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/internal/export"
+)
+
+func TestWriteOFX(t *testing.T) {
+	t.Parallel()
+
+	document, check := loadDocumentWithCheck(t)
+	now := time.Date(2018, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	type transaction struct {
+		Type        string `xml:"TRNTYPE"`
+		Posted      string `xml:"DTPOSTED"`
+		Amount      string `xml:"TRNAMT"`
+		ID          string `xml:"FITID"`
+		CheckNumber string `xml:"CHECKNUM"`
+		Name        string `xml:"NAME"`
+		Memo        string `xml:"MEMO"`
+	}
+
+	type statement struct {
+		Currency      string        `xml:"CURDEF"`
+		BankID        string        `xml:"BANKACCTFROM>BANKID"`
+		AccountID     string        `xml:"BANKACCTFROM>ACCTID"`
+		AccountType   string        `xml:"BANKACCTFROM>ACCTTYPE"`
+		CardAccountID string        `xml:"CCACCTFROM>ACCTID"`
+		Start         string        `xml:"BANKTRANLIST>DTSTART"`
+		End           string        `xml:"BANKTRANLIST>DTEND"`
+		Transactions  []transaction `xml:"BANKTRANLIST>STMTTRN"`
+		Balance       string        `xml:"LEDGERBAL>BALAMT"`
+		AsOf          string        `xml:"LEDGERBAL>DTASOF"`
+	}
+
+	type ofx struct {
+		Server     string    `xml:"SIGNONMSGSRSV1>SONRS>DTSERVER"`
+		Bank       statement `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+		CreditCard statement `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS>CCSTMTRS"`
+	}
+
+	write := func(t *testing.T, account api.Account) (string, ofx) {
+		var buffer bytes.Buffer
+		err := export.WriteOFX(&buffer, document, account, now)
+		assert.NoError(t, err)
+
+		var parsed ofx
+		err = xml.Unmarshal(buffer.Bytes(), &parsed)
+		assert.NoError(t, err)
+
+		return buffer.String(), parsed
+	}
+
+	parse := func(t *testing.T, accountName string) (string, ofx) {
+		account, ok := document.FindAccount(accountName)
+		assert.True(t, ok)
+
+		return write(t, account)
+	}
+
+	t.Run("chequing account", func(t *testing.T) {
+		output, parsed := parse(t, "Chequing Account")
+
+		assert.True(t, strings.HasPrefix(output, "<?xml"))
+		assert.Contains(t, output, `<?OFX OFXHEADER="200" VERSION="220"`)
+		assert.NotContains(t, output, "CREDITCARDMSGSRSV1")
+
+		assert.Equal(t, "CAD", parsed.Bank.Currency)
+		assert.Equal(t, "1245", parsed.Bank.BankID)
+		assert.Equal(t, "5678", parsed.Bank.AccountID)
+		assert.Equal(t, "CHECKING", parsed.Bank.AccountType)
+		assert.Equal(t, "20171101", parsed.Bank.Start)
+		assert.Equal(t, "20171112", parsed.Bank.End)
+		assert.Equal(t, "-470.00", parsed.Bank.Balance)
+		assert.Equal(t, "20171112", parsed.Bank.AsOf)
+		assert.Equal(t, "20180115", parsed.Server)
+
+		assert.Equal(t, []transaction{
+			{"CREDIT", "20171101", "0.00", "E1B13D32-32B2-4D24-807E-C68CF1429FBA", "", "Initial Balance", ""},
+			{"CREDIT", "20171101", "1000.00", "324E2646-5C05-43B1-BC0A-33BC46941CE9", "", "Work", ""},
+			{"DEBIT", "20171105", "-350.00", "659DBBAC-1741-45E5-851C-087772FD9200", "", "Grocery Store", "Chick peas and tuna."},
+			{"DEBIT", "20171110", "-500.00", "38B5327A-AD15-41D6-84C4-B0AE4154362A", "", "Rent", ""},
+			{"CHECK", "20171111", "-120.00", check.UniqueID, "1042", "Landlord", ""},
+			{"DEBIT", "20171112", "-500.00", "E894FBEB-C5D6-48E3-A27C-F3E3E1368739", "", "Split Test", ""},
+		}, parsed.Bank.Transactions)
+	})
+
+	t.Run("cash account", func(t *testing.T) {
+		_, parsed := parse(t, "Cash")
+
+		assert.Equal(t, "0", parsed.Bank.BankID)
+		assert.Equal(t, "3", parsed.Bank.AccountID)
+		assert.Len(t, parsed.Bank.Transactions, 2)
+		assert.Equal(t, "XFER", parsed.Bank.Transactions[1].Type)
+		assert.Equal(t, "400.00", parsed.Bank.Balance)
+	})
+
+	t.Run("line of credit", func(t *testing.T) {
+		_, parsed := parse(t, "Line of Credit")
+
+		assert.Equal(t, "CREDITLINE", parsed.Bank.AccountType)
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		_, parsed := write(t, api.Account{
+			PrimaryKey:   1000,
+			Name:         "Empty",
+			CurrencyCode: "CAD",
+		})
+
+		assert.Empty(t, parsed.Bank.Transactions)
+		assert.Equal(t, "20180115", parsed.Bank.Start)
+		assert.Equal(t, "20180115", parsed.Bank.End)
+		assert.Equal(t, "20180115", parsed.Bank.AsOf)
+		assert.Equal(t, "20180115", parsed.Server)
+	})
+
+	t.Run("no currency", func(t *testing.T) {
+		account, ok := document.FindAccount("Chequing Account")
+		assert.True(t, ok)
+		account.CurrencyCode = ""
+
+		_, parsed := write(t, account)
+		assert.Equal(t, "CAD", parsed.Bank.Currency)
+
+		var buffer bytes.Buffer
+		err := export.WriteOFX(&buffer, document, api.Account{PrimaryKey: 1000, Name: "Empty"}, now)
+		assert.Error(t, err)
+	})
+
+	t.Run("credit card", func(t *testing.T) {
+		output, parsed := parse(t, "Visa")
+
+		assert.NotContains(t, output, "BANKMSGSRSV1")
+		assert.Equal(t, "0987654321", parsed.CreditCard.CardAccountID)
+		assert.Equal(t, "0.00", parsed.CreditCard.Balance)
+	})
+}
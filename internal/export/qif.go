@@ -0,0 +1,116 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lieut-data/go-moneywell/api"
+)
+
+// getQIFType returns the QIF account type of the given account. Liabilities other than credit
+// cards, such as lines of credit and other accounts marked as debt, are other liabilities.
+func getQIFType(account api.Account) string {
+	switch {
+	case account.AccountType == api.AccountTypeCash:
+		return "Cash"
+	case account.AccountType == api.AccountTypeCreditCard:
+		return "CCard"
+	case account.IsLiability():
+		return "Oth L"
+	}
+
+	return "Bank"
+}
+
+// getQIFName sanitizes a MoneyWell name for use as a QIF category or account, where a colon
+// would separate a subcategory and a slash a class.
+func getQIFName(name string) string {
+	name = strings.Replace(name, ":", "-", -1)
+	name = strings.Replace(name, "/", "-", -1)
+	return ledgerWhitespace.ReplaceAllString(strings.TrimSpace(name), " ")
+}
+
+// getQIFText collapses the given text onto a single line.
+func getQIFText(text string) string {
+	return ledgerWhitespace.ReplaceAllString(strings.TrimSpace(text), " ")
+}
+
+// WriteQIF writes the transactions against the given account in the Quicken Interchange Format.
+//
+// Each transaction includes its payee, memo, check number and whether it was cleared (*) or
+// reconciled (X). Its bucket becomes its category, and a transfer names the other account as
+// its category in square brackets. A split transaction lists each split child as a split line
+// instead, with any remainder not covered by the children on an uncategorized split line.
+// Voided and pending transactions are omitted.
+func WriteQIF(w io.Writer, document *api.Document, account api.Account) error {
+	getCategory := func(transaction api.Transaction) string {
+		if transaction.IsTransfer() {
+			transferAccount, _ := document.GetAccount(transaction.TransferAccount)
+			return fmt.Sprintf("[%s]", getQIFName(transferAccount.Name))
+		}
+		if transaction.Bucket != 0 {
+			bucket, _ := document.GetBucket(transaction.Bucket)
+			return getQIFName(bucket.Name)
+		}
+
+		return ""
+	}
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "!Type:%s\n", getQIFType(account))
+	for _, transaction := range document.GetAccountTransactions(account.PrimaryKey) {
+		if !isPosted(transaction) || transaction.SplitParent != 0 {
+			continue
+		}
+
+		fmt.Fprintf(writer, "D%s\n", transaction.Date.Format("01/02/2006"))
+		fmt.Fprintf(writer, "T%s\n", formatDecimal(transaction.Amount))
+
+		switch transaction.Status {
+		case api.TransactionStatusCleared:
+			fmt.Fprintf(writer, "C*\n")
+		case api.TransactionStatusReconciled:
+			fmt.Fprintf(writer, "CX\n")
+		}
+
+		if checkNumber := getQIFText(transaction.CheckNumber); checkNumber != "" {
+			fmt.Fprintf(writer, "N%s\n", checkNumber)
+		}
+		if payee := getQIFText(transaction.Payee); payee != "" {
+			fmt.Fprintf(writer, "P%s\n", payee)
+		}
+		if memo := getQIFText(transaction.Memo); memo != "" {
+			fmt.Fprintf(writer, "M%s\n", memo)
+		}
+
+		if transaction.IsSplit {
+			remainder := transaction.Amount
+			for _, child := range document.GetSplitChildren(transaction.PrimaryKey) {
+				fmt.Fprintf(writer, "S%s\n", getCategory(child))
+				if memo := getQIFText(child.Memo); memo != "" {
+					fmt.Fprintf(writer, "E%s\n", memo)
+				}
+				fmt.Fprintf(writer, "$%s\n", formatDecimal(child.Amount))
+				remainder = remainder.Add(child.Amount.Multiply(-1))
+			}
+
+			if !remainder.IsZero() {
+				fmt.Fprintf(writer, "S\n$%s\n", formatDecimal(remainder))
+			}
+		} else if category := getCategory(transaction); category != "" {
+			fmt.Fprintf(writer, "L%s\n", category)
+		}
+
+		fmt.Fprintf(writer, "^\n")
+	}
+
+	if err := writer.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write qif")
+	}
+
+	return nil
+}
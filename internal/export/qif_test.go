@@ -0,0 +1,62 @@
+package export_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/internal/export"
+)
+
+func TestWriteQIF(t *testing.T) {
+	t.Parallel()
+
+	document, _ := loadDocumentWithCheck(t)
+
+	t.Run("chequing account", func(t *testing.T) {
+		account, ok := document.FindAccount("Chequing Account")
+		assert.True(t, ok)
+
+		var buffer bytes.Buffer
+		err := export.WriteQIF(&buffer, document, account)
+		assert.NoError(t, err)
+		qif := buffer.String()
+
+		assert.True(t, strings.HasPrefix(qif, "!Type:Bank\n"))
+		assert.Contains(t, qif, "^\nD11/01/2017\nT1000.00\nC*\nPWork\nLSalary\n^\n")
+		assert.Contains(t, qif, "D11/05/2017\nT-350.00\nPGrocery Store\nMChick peas and tuna.\nLGroceries\n^\n")
+		assert.Contains(t, qif, "D11/10/2017\nT-500.00\nPRent\nLMortgage-Rent\n^\n")
+		assert.Contains(t, qif, "D11/11/2017\nT-120.00\nN1042\nPLandlord\nLMortgage-Rent\n^\n")
+		assert.Contains(t, qif, "D11/12/2017\nT-500.00\nPSplit Test\nSGroceries\n$-100.00\nS[Cash]\n$-400.00\n^\n")
+
+		// Voided and pending transactions are omitted.
+		assert.NotContains(t, qif, "D11/25/2017")
+		assert.NotContains(t, qif, "D12/31/2099")
+	})
+
+	t.Run("cash account", func(t *testing.T) {
+		account, ok := document.FindAccount("Cash")
+		assert.True(t, ok)
+
+		var buffer bytes.Buffer
+		err := export.WriteQIF(&buffer, document, account)
+		assert.NoError(t, err)
+		qif := buffer.String()
+
+		assert.True(t, strings.HasPrefix(qif, "!Type:Cash\n"))
+		assert.Contains(t, qif, "D11/12/2017\nT400.00\nPSplit Test\nL[Chequing Account]\n^\n")
+	})
+
+	t.Run("line of credit", func(t *testing.T) {
+		account, ok := document.FindAccount("Line of Credit")
+		assert.True(t, ok)
+
+		var buffer bytes.Buffer
+		err := export.WriteQIF(&buffer, document, account)
+		assert.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(buffer.String(), "!Type:Oth L\n"))
+	})
+}
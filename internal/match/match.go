@@ -0,0 +1,92 @@
+// Package match compares transactions, such as the rows of a bank statement against those already
+// in a MoneyWell document, or two transactions within a document that may be duplicates.
+package match
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultDateWindow is the number of days by which two matching transactions may differ,
+	// unless configured.
+	DefaultDateWindow = 3
+	// DefaultPayeeThreshold is the minimum payee similarity for two transactions to match,
+	// unless configured.
+	DefaultPayeeThreshold = 0.4
+)
+
+// GetDays returns the absolute number of days between the given dates.
+func GetDays(a time.Time, b time.Time) int {
+	days := int(a.Sub(b).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+
+	return days
+}
+
+// normalizePayee lowercases the payee, keeping only letters and digits separated by single
+// spaces.
+func normalizePayee(payee string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(payee), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// getBigrams returns the pairs of adjacent characters in each word of the text.
+func getBigrams(text string) map[string]int {
+	bigrams := make(map[string]int)
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		if len(runes) == 1 {
+			bigrams[word]++
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			bigrams[string(runes[i:i+2])]++
+		}
+	}
+
+	return bigrams
+}
+
+// GetPayeeSimilarity returns the similarity, between 0 and 1, of two payees.
+//
+// Payees are compared ignoring case and punctuation. A payee wholly contained within the other,
+// such as "Grocery Store" within "GROCERY STORE #123 TORONTO", is considered identical. Otherwise,
+// the similarity is the Dice coefficient of the pairs of adjacent characters in each word. An
+// empty payee is considered identical to any other, since it says nothing either way.
+func GetPayeeSimilarity(a string, b string) float64 {
+	a = normalizePayee(a)
+	b = normalizePayee(b)
+	if a == "" || b == "" {
+		return 1
+	}
+
+	if strings.Contains(" "+a+" ", " "+b+" ") || strings.Contains(" "+b+" ", " "+a+" ") {
+		return 1
+	}
+
+	bigramsA := getBigrams(a)
+	bigramsB := getBigrams(b)
+
+	total := 0
+	for _, count := range bigramsA {
+		total += count
+	}
+	for _, count := range bigramsB {
+		total += count
+	}
+
+	shared := 0
+	for bigram, count := range bigramsA {
+		if other := bigramsB[bigram]; other < count {
+			shared += other
+		} else {
+			shared += count
+		}
+	}
+
+	return 2 * float64(shared) / float64(total)
+}
@@ -0,0 +1,30 @@
+package match_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/internal/match"
+)
+
+func TestGetDays(t *testing.T) {
+	t.Parallel()
+
+	a := time.Date(2017, 11, 5, 0, 0, 0, 0, time.UTC)
+	b := time.Date(2017, 11, 8, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 3, match.GetDays(a, b))
+	assert.Equal(t, 3, match.GetDays(b, a))
+	assert.Equal(t, 0, match.GetDays(a, a))
+}
+
+func TestGetPayeeSimilarity(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 1.0, match.GetPayeeSimilarity("Grocery Store", "GROCERY STORE #123"))
+	assert.Equal(t, 1.0, match.GetPayeeSimilarity("", "Anything"))
+	assert.Equal(t, 0.0, match.GetPayeeSimilarity("Rent", "Hydro"))
+	assert.InDelta(t, 0.8, match.GetPayeeSimilarity("Groceries", "Grocery"), 0.1)
+	assert.True(t, match.GetPayeeSimilarity("Work", "Worker") < 1)
+}
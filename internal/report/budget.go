@@ -0,0 +1,216 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// BudgetLine compares the planned and actual activity of a single bucket.
+//
+// Amounts are signed as they affect the cash flow: income is positive and expenses are negative.
+// A positive variance is therefore favourable, whether more was earned or less was spent than
+// planned.
+type BudgetLine struct {
+	Bucket  int64
+	Planned money.Money
+	Actual  money.Money
+}
+
+// GetVariance returns the amount by which the actual activity exceeded the plan.
+func (l BudgetLine) GetVariance() money.Money {
+	return l.Actual.Add(l.Planned.Multiply(-1))
+}
+
+// BudgetGroup totals the budget lines of the buckets in a single bucket group and currency.
+// Buckets without a group are reported with a BucketGroup of 0.
+type BudgetGroup struct {
+	BucketGroup int64
+	Currency    string
+	Planned     money.Money
+	Actual      money.Money
+	Lines       []BudgetLine
+}
+
+// GetVariance returns the amount by which the actual activity of the group exceeded the plan.
+func (g BudgetGroup) GetVariance() money.Money {
+	return g.Actual.Add(g.Planned.Multiply(-1))
+}
+
+// BudgetMonth compares the planned and actual activity of each bucket within a single month.
+type BudgetMonth struct {
+	// Month is the first day of the month.
+	Month time.Time
+	// From and To are the dates covered within the month, which differ from the month itself
+	// only at either end of the report.
+	From   time.Time
+	To     time.Time
+	Groups []BudgetGroup
+}
+
+// GetBudget compares the spending plan against the actual transactions assigned to each bucket
+// for each month between from and to inclusive, returning the months in order.
+//
+// The planned activity of a bucket sums the spending plan events expected in the month, ignoring
+// bucket fills. The actual activity sums the transactions assigned to the bucket, ignoring
+// voided and pending transactions. Groups and their lines are ordered as the buckets are given,
+// and buckets with neither planned nor actual activity in a month are omitted. A bucket with
+// activity in more than one currency in a month has a line for each currency, in the group of
+// that currency, ordered by currency.
+func GetBudget(
+	from time.Time,
+	to time.Time,
+	buckets []api.Bucket,
+	transactions []api.Transaction,
+	spendingPlan []api.SpendingPlan,
+	recurrenceRules map[int64]api.RecurrenceRule,
+) []BudgetMonth {
+	bucketsMap := make(map[int64]api.Bucket, len(buckets))
+	for _, bucket := range buckets {
+		bucketsMap[bucket.PrimaryKey] = bucket
+	}
+
+	months := []BudgetMonth{}
+	monthIndexes := make(map[time.Time]int)
+	for month := getMonth(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		monthFrom := month
+		if monthFrom.Before(from) {
+			monthFrom = from
+		}
+		monthTo := month.AddDate(0, 1, -1)
+		if monthTo.After(to) {
+			monthTo = to
+		}
+
+		monthIndexes[month] = len(months)
+		months = append(months, BudgetMonth{
+			Month:  month,
+			From:   monthFrom,
+			To:     monthTo,
+			Groups: []BudgetGroup{},
+		})
+	}
+
+	type bucketMonth struct {
+		month  int
+		bucket int64
+	}
+	type key struct {
+		bucketMonth
+		currency string
+	}
+	planned := make(map[key]money.Money)
+	actual := make(map[key]money.Money)
+	currencies := make(map[bucketMonth]map[string]bool)
+
+	addActivity := func(
+		activity map[key]money.Money,
+		date time.Time,
+		bucket int64,
+		amount money.Money,
+	) {
+		// Amounts don't always record a currency, so fall back to that of the bucket.
+		currency := amount.Currency
+		if currency == "" {
+			currency = bucketsMap[bucket].CurrencyCode
+		}
+
+		k := key{bucketMonth{monthIndexes[getMonth(date)], bucket}, currency}
+		activity[k] = activity[k].Add(amount)
+
+		if currencies[k.bucketMonth] == nil {
+			currencies[k.bucketMonth] = make(map[string]bool)
+		}
+		currencies[k.bucketMonth][currency] = true
+	}
+
+	schedule := api.GetSpendingPlanSchedule(spendingPlan, recurrenceRules, bucketsMap, from, to)
+	for _, occurrence := range schedule {
+		if occurrence.Type != api.SpendingPlanOccurrenceTypeEvent {
+			continue
+		}
+
+		amount := occurrence.Amount
+		if bucketsMap[occurrence.Bucket].Type == api.BucketTypeExpense {
+			amount = amount.Multiply(-1)
+		}
+
+		addActivity(planned, occurrence.Date, occurrence.Bucket, amount)
+	}
+
+	for _, transaction := range transactions {
+		switch transaction.Status {
+		case api.TransactionStatusVoided:
+			fallthrough
+		case api.TransactionStatusPending:
+			continue
+		}
+
+		if transaction.Bucket == 0 {
+			continue
+		}
+		if transaction.Date.Before(from) || transaction.Date.After(to) {
+			continue
+		}
+
+		addActivity(actual, transaction.Date, transaction.Bucket, transaction.Amount)
+	}
+
+	for i := range months {
+		for _, bucket := range buckets {
+			bucketCurrencies := []string{}
+			for currency := range currencies[bucketMonth{i, bucket.PrimaryKey}] {
+				bucketCurrencies = append(bucketCurrencies, currency)
+			}
+			sort.Strings(bucketCurrencies)
+
+			for _, currency := range bucketCurrencies {
+				k := key{bucketMonth{i, bucket.PrimaryKey}, currency}
+				if planned[k].IsZero() && actual[k].IsZero() {
+					continue
+				}
+
+				line := BudgetLine{
+					Bucket:  bucket.PrimaryKey,
+					Planned: planned[k].Add(money.Money{Currency: currency}),
+					Actual:  actual[k].Add(money.Money{Currency: currency}),
+				}
+
+				group := getBudgetGroup(&months[i], bucket.BucketGroup, currency)
+				group.Planned = group.Planned.Add(line.Planned)
+				group.Actual = group.Actual.Add(line.Actual)
+				group.Lines = append(group.Lines, line)
+			}
+		}
+	}
+
+	return months
+}
+
+// getBudgetGroup finds the group of the given bucket group and currency within the given month,
+// adding the group if not already present.
+func getBudgetGroup(month *BudgetMonth, bucketGroup int64, currency string) *BudgetGroup {
+	for i := range month.Groups {
+		group := &month.Groups[i]
+		if group.BucketGroup == bucketGroup && group.Currency == currency {
+			return group
+		}
+	}
+
+	month.Groups = append(month.Groups, BudgetGroup{
+		BucketGroup: bucketGroup,
+		Currency:    currency,
+		Planned:     money.Money{Currency: currency},
+		Actual:      money.Money{Currency: currency},
+		Lines:       []BudgetLine{},
+	})
+
+	return &month.Groups[len(month.Groups)-1]
+}
+
+// getMonth returns the first day of the month containing the given date.
+func getMonth(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
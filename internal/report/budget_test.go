@@ -0,0 +1,183 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/report"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestGetBudget(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	buckets, err := api.GetBuckets(database)
+	assert.NoError(t, err)
+
+	spendingPlan, err := api.GetSpendingPlan(database)
+	assert.NoError(t, err)
+
+	recurrenceRules, err := api.GetRecurrenceRulesMap(database)
+	assert.NoError(t, err)
+
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2018, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount * 100}
+	}
+
+	transactions := []api.Transaction{
+		{PrimaryKey: 1, Date: date(4, 1), Amount: cad(100), Bucket: 3, Status: api.TransactionStatusCleared},
+		{PrimaryKey: 2, Date: date(4, 2), Amount: cad(-15), Bucket: 13, Status: api.TransactionStatusCleared},
+		{PrimaryKey: 3, Date: date(4, 3), Amount: cad(-40), Bucket: 27, Status: api.TransactionStatusOpen},
+		// Ignored, since voided, pending, unassigned or outside the report.
+		{PrimaryKey: 4, Date: date(4, 3), Amount: cad(-5), Bucket: 13, Status: api.TransactionStatusVoided},
+		{PrimaryKey: 5, Date: date(4, 3), Amount: cad(-5), Bucket: 13, Status: api.TransactionStatusPending},
+		{PrimaryKey: 6, Date: date(4, 3), Amount: cad(-5), Status: api.TransactionStatusOpen},
+		{PrimaryKey: 7, Date: date(4, 4), Amount: cad(-5), Bucket: 13, Status: api.TransactionStatusOpen},
+	}
+
+	budget := report.GetBudget(
+		date(3, 31),
+		date(4, 3),
+		buckets,
+		transactions,
+		spendingPlan,
+		recurrenceRules,
+	)
+
+	assert.Equal(t, []report.BudgetMonth{
+		{
+			Month:  date(3, 1),
+			From:   date(3, 31),
+			To:     date(3, 31),
+			Groups: []report.BudgetGroup{},
+		},
+		{
+			Month: date(4, 1),
+			From:  date(4, 1),
+			To:    date(4, 3),
+			Groups: []report.BudgetGroup{
+				{
+					BucketGroup: 2,
+					Currency:    "CAD",
+					Planned:     cad(0),
+					Actual:      cad(100),
+					Lines: []report.BudgetLine{
+						{Bucket: 3, Planned: cad(0), Actual: cad(100)},
+					},
+				},
+				{
+					BucketGroup: 0,
+					Currency:    "CAD",
+					Planned:     cad(-10),
+					Actual:      cad(-15),
+					Lines: []report.BudgetLine{
+						{Bucket: 13, Planned: cad(-10), Actual: cad(-15)},
+					},
+				},
+				{
+					BucketGroup: 4,
+					Currency:    "CAD",
+					Planned:     cad(-40),
+					Actual:      cad(-40),
+					Lines: []report.BudgetLine{
+						{Bucket: 27, Planned: cad(-40), Actual: cad(-40)},
+					},
+				},
+				{
+					BucketGroup: 3,
+					Currency:    "CAD",
+					Planned:     cad(-30),
+					Actual:      cad(0),
+					Lines: []report.BudgetLine{
+						{Bucket: 2, Planned: cad(-30), Actual: cad(0)},
+					},
+				},
+			},
+		},
+	}, budget)
+
+	april := budget[1]
+	assert.Equal(t, cad(100), april.Groups[0].GetVariance())
+	assert.Equal(t, cad(-5), april.Groups[1].Lines[0].GetVariance())
+	assert.Equal(t, cad(0), april.Groups[2].GetVariance())
+	assert.Equal(t, cad(30), april.Groups[3].GetVariance())
+}
+
+func TestGetBudgetMixedCurrencies(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	buckets, err := api.GetBuckets(database)
+	assert.NoError(t, err)
+
+	date := time.Date(2018, 4, 2, 0, 0, 0, 0, time.UTC)
+	transactions := []api.Transaction{
+		{
+			PrimaryKey: 1,
+			Date:       date,
+			Amount:     money.Money{Currency: "CAD", Amount: -15 * 100},
+			Bucket:     13,
+			Status:     api.TransactionStatusCleared,
+		},
+		{
+			PrimaryKey: 2,
+			Date:       date,
+			Amount:     money.Money{Currency: "USD", Amount: -20 * 100},
+			Bucket:     13,
+			Status:     api.TransactionStatusCleared,
+		},
+	}
+
+	budget := report.GetBudget(date, date, buckets, transactions, nil, nil)
+
+	assert.Equal(t, []report.BudgetMonth{
+		{
+			Month: time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC),
+			From:  date,
+			To:    date,
+			Groups: []report.BudgetGroup{
+				{
+					BucketGroup: 0,
+					Currency:    "CAD",
+					Planned:     money.Money{Currency: "CAD"},
+					Actual:      money.Money{Currency: "CAD", Amount: -15 * 100},
+					Lines: []report.BudgetLine{
+						{
+							Bucket:  13,
+							Planned: money.Money{Currency: "CAD"},
+							Actual:  money.Money{Currency: "CAD", Amount: -15 * 100},
+						},
+					},
+				},
+				{
+					BucketGroup: 0,
+					Currency:    "USD",
+					Planned:     money.Money{Currency: "USD"},
+					Actual:      money.Money{Currency: "USD", Amount: -20 * 100},
+					Lines: []report.BudgetLine{
+						{
+							Bucket:  13,
+							Planned: money.Money{Currency: "USD"},
+							Actual:  money.Money{Currency: "USD", Amount: -20 * 100},
+						},
+					},
+				},
+			},
+		},
+	}, budget)
+}
@@ -0,0 +1,147 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// ForecastDay represents the projected cash flow balance at the end of a day on which spending
+// plan events are expected.
+type ForecastDay struct {
+	Date    time.Time
+	Events  []api.SpendingPlanOccurrence
+	Change  money.Money
+	Balance money.Money
+}
+
+// IsNegative determines if the projected cash flow balance is below zero.
+func (d ForecastDay) IsNegative() bool {
+	return d.Balance.Amount < 0
+}
+
+// Forecast represents the projected cash flow balance in a single currency.
+type Forecast struct {
+	Currency        string
+	From            time.Time
+	To              time.Time
+	StartingBalance money.Money
+	Days            []ForecastDay
+	Lowest          ForecastDay
+}
+
+// GetNegativeDays returns the days on which the projected cash flow balance is below zero.
+func (f Forecast) GetNegativeDays() []ForecastDay {
+	negativeDays := []ForecastDay{}
+	for _, day := range f.Days {
+		if day.IsNegative() {
+			negativeDays = append(negativeDays, day)
+		}
+	}
+
+	return negativeDays
+}
+
+// GetForecast projects the cash flow balance between from and to inclusive, returning one forecast
+// per currency sorted by currency.
+//
+// The starting balance sums the balances of the accounts in the cash flow as of the day before
+// the forecast begins. Each spending plan event is then applied on the date it is expected,
+// adding income and subtracting expenses. Transactions dated on or after the start of the
+// forecast are ignored, as they typically duplicate the spending plan. The lowest point is the
+// starting balance unless some day falls below it.
+func GetForecast(
+	from time.Time,
+	to time.Time,
+	accounts []api.Account,
+	transactions []api.Transaction,
+	buckets map[int64]api.Bucket,
+	spendingPlan []api.SpendingPlan,
+	recurrenceRules map[int64]api.RecurrenceRule,
+) []Forecast {
+	pastTransactions := []api.Transaction{}
+	for _, transaction := range transactions {
+		if transaction.Date.Before(from) {
+			pastTransactions = append(pastTransactions, transaction)
+		}
+	}
+
+	forecasts := make(map[string]*Forecast)
+	getForecast := func(currency string) *Forecast {
+		if forecasts[currency] == nil {
+			forecasts[currency] = &Forecast{
+				Currency:        currency,
+				From:            from,
+				To:              to,
+				StartingBalance: money.Money{Currency: currency},
+			}
+		}
+
+		return forecasts[currency]
+	}
+
+	for _, account := range accounts {
+		if !account.IncludeInCashFlow {
+			continue
+		}
+
+		forecast := getForecast(account.CurrencyCode)
+		forecast.StartingBalance = forecast.StartingBalance.Add(
+			api.GetAccountBalance(account, pastTransactions),
+		)
+	}
+
+	schedule := api.GetSpendingPlanSchedule(spendingPlan, recurrenceRules, buckets, from, to)
+	for _, occurrence := range schedule {
+		if occurrence.Type != api.SpendingPlanOccurrenceTypeEvent {
+			continue
+		}
+
+		change := occurrence.Amount
+		if buckets[occurrence.Bucket].Type == api.BucketTypeExpense {
+			change = change.Multiply(-1)
+		}
+
+		forecast := getForecast(change.Currency)
+		if len(forecast.Days) == 0 || !forecast.Days[len(forecast.Days)-1].Date.Equal(occurrence.Date) {
+			forecast.Days = append(forecast.Days, ForecastDay{
+				Date:   occurrence.Date,
+				Change: money.Money{Currency: change.Currency},
+			})
+		}
+
+		day := &forecast.Days[len(forecast.Days)-1]
+		day.Events = append(day.Events, occurrence)
+		day.Change = day.Change.Add(change)
+	}
+
+	currencies := make([]string, 0, len(forecasts))
+	for currency, forecast := range forecasts {
+		currencies = append(currencies, currency)
+
+		forecast.Lowest = ForecastDay{
+			Date:    from,
+			Change:  money.Money{Currency: currency},
+			Balance: forecast.StartingBalance,
+		}
+		balance := forecast.StartingBalance
+		for i := range forecast.Days {
+			balance = balance.Add(forecast.Days[i].Change)
+			forecast.Days[i].Balance = balance
+
+			if balance.Amount < forecast.Lowest.Balance.Amount {
+				forecast.Lowest = forecast.Days[i]
+			}
+		}
+	}
+	sort.Strings(currencies)
+
+	sortedForecasts := make([]Forecast, 0, len(currencies))
+	for _, currency := range currencies {
+		sortedForecasts = append(sortedForecasts, *forecasts[currency])
+	}
+
+	return sortedForecasts
+}
@@ -0,0 +1,100 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/report"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestGetForecast(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	accounts, err := api.GetAccounts(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	buckets, err := api.GetBucketsMap(database)
+	assert.NoError(t, err)
+
+	spendingPlan, err := api.GetSpendingPlan(database)
+	assert.NoError(t, err)
+
+	recurrenceRules, err := api.GetRecurrenceRulesMap(database)
+	assert.NoError(t, err)
+
+	date := func(day int) time.Time {
+		return time.Date(2018, 4, day, 0, 0, 0, 0, time.UTC)
+	}
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount}
+	}
+	event := func(spendingPlan int64, day int, amount int64, bucket int64) api.SpendingPlanOccurrence {
+		return api.SpendingPlanOccurrence{
+			SpendingPlan: spendingPlan,
+			Type:         api.SpendingPlanOccurrenceTypeEvent,
+			Date:         date(day),
+			Amount:       cad(amount),
+			Bucket:       bucket,
+		}
+	}
+
+	forecasts := report.GetForecast(
+		date(1),
+		date(3),
+		accounts,
+		transactions,
+		buckets,
+		spendingPlan,
+		recurrenceRules,
+	)
+	assert.Len(t, forecasts, 2)
+
+	// The Savings account is outside the cash flow, and the future transaction is ignored.
+	cadForecast := forecasts[0]
+	assert.Equal(t, "CAD", cadForecast.Currency)
+	assert.Equal(t, cad(5000), cadForecast.StartingBalance)
+
+	lastDay := report.ForecastDay{
+		Date:    date(3),
+		Events:  []api.SpendingPlanOccurrence{event(22, 3, 2000, 27), event(23, 3, 3000, 2)},
+		Change:  cad(-5000),
+		Balance: cad(-3000),
+	}
+	assert.Equal(t, []report.ForecastDay{
+		{
+			Date:    date(1),
+			Events:  []api.SpendingPlanOccurrence{event(21, 1, 1000, 13)},
+			Change:  cad(-1000),
+			Balance: cad(4000),
+		},
+		{
+			Date:    date(2),
+			Events:  []api.SpendingPlanOccurrence{event(22, 2, 2000, 27)},
+			Change:  cad(-2000),
+			Balance: cad(2000),
+		},
+		lastDay,
+	}, cadForecast.Days)
+	assert.Equal(t, lastDay, cadForecast.Lowest)
+	assert.Equal(t, []report.ForecastDay{lastDay}, cadForecast.GetNegativeDays())
+
+	usdForecast := forecasts[1]
+	assert.Equal(t, "USD", usdForecast.Currency)
+	assert.Empty(t, usdForecast.Days)
+	assert.Equal(t, date(1), usdForecast.Lowest.Date)
+	assert.Equal(t, money.Money{Currency: "USD"}, usdForecast.Lowest.Balance)
+	assert.Empty(t, usdForecast.GetNegativeDays())
+}
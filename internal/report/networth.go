@@ -0,0 +1,174 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+)
+
+// NetWorthGroup represents the assets and liabilities of the accounts in a single account group.
+// Accounts without a group are reported with an AccountGroup of 0.
+type NetWorthGroup struct {
+	AccountGroup int64
+	Assets       money.Money
+	Liabilities  money.Money
+}
+
+// GetTotal returns the assets less the liabilities of the group.
+func (g NetWorthGroup) GetTotal() money.Money {
+	return g.Assets.Add(g.Liabilities)
+}
+
+// NetWorth represents the assets and liabilities of the accounts included in the net worth at
+// the end of a single day, in a single currency.
+//
+// Liabilities are the balances of the debt, credit card and line of credit accounts, and so are
+// typically negative.
+type NetWorth struct {
+	Currency    string
+	Date        time.Time
+	Assets      money.Money
+	Liabilities money.Money
+	Groups      []NetWorthGroup
+}
+
+// GetTotal returns the assets less the liabilities.
+func (n NetWorth) GetTotal() money.Money {
+	return n.Assets.Add(n.Liabilities)
+}
+
+// GetNetWorth computes the net worth at the end of the given date, returning one net worth per
+// currency sorted by currency. Groups are ordered as the accounts are given.
+//
+// Only accounts included in the net worth are considered, whether or not they are hidden. An
+// account counts towards the liabilities if it is a liability, and towards the assets otherwise.
+func GetNetWorth(date time.Time, accounts []api.Account, transactions []api.Transaction) []NetWorth {
+	balances := make(map[int64]money.Money, len(accounts))
+	for _, transaction := range transactions {
+		if !countsTowardsNetWorth(transaction) || transaction.Date.After(date) {
+			continue
+		}
+
+		balances[transaction.Account] = balances[transaction.Account].Add(transaction.Amount)
+	}
+
+	return getNetWorth(date, accounts, balances)
+}
+
+// GetNetWorthHistory computes the net worth at the end of each month, from the month of the
+// earliest transaction up to the last month ending on or before the given date, returning the
+// net worths sorted by date and then by currency.
+func GetNetWorthHistory(
+	to time.Time,
+	accounts []api.Account,
+	transactions []api.Transaction,
+) []NetWorth {
+	sortedTransactions := []api.Transaction{}
+	for _, transaction := range transactions {
+		if countsTowardsNetWorth(transaction) {
+			sortedTransactions = append(sortedTransactions, transaction)
+		}
+	}
+	sort.SliceStable(sortedTransactions, func(i, j int) bool {
+		return sortedTransactions[i].Date.Before(sortedTransactions[j].Date)
+	})
+
+	history := []NetWorth{}
+	if len(sortedTransactions) == 0 {
+		return history
+	}
+
+	balances := make(map[int64]money.Money, len(accounts))
+	next := 0
+	first := sortedTransactions[0].Date
+	for month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC); ; month = month.AddDate(0, 1, 0) {
+		monthEnd := month.AddDate(0, 1, -1)
+		if monthEnd.After(to) {
+			break
+		}
+
+		for ; next < len(sortedTransactions) && !sortedTransactions[next].Date.After(monthEnd); next++ {
+			transaction := sortedTransactions[next]
+			balances[transaction.Account] = balances[transaction.Account].Add(transaction.Amount)
+		}
+
+		history = append(history, getNetWorth(monthEnd, accounts, balances)...)
+	}
+
+	return history
+}
+
+// countsTowardsNetWorth determines if the transaction changes an account balance, mirroring
+// api.GetAccountBalance.
+func countsTowardsNetWorth(transaction api.Transaction) bool {
+	switch transaction.Status {
+	case api.TransactionStatusVoided:
+		fallthrough
+	case api.TransactionStatusPending:
+		return false
+	}
+
+	return transaction.SplitParent == 0
+}
+
+func getNetWorth(date time.Time, accounts []api.Account, balances map[int64]money.Money) []NetWorth {
+	netWorths := make(map[string]*NetWorth)
+	for _, account := range accounts {
+		if !account.IncludeInNetWorth {
+			continue
+		}
+
+		currency := account.CurrencyCode
+		netWorth := netWorths[currency]
+		if netWorth == nil {
+			netWorth = &NetWorth{
+				Currency:    currency,
+				Date:        date,
+				Assets:      money.Money{Currency: currency},
+				Liabilities: money.Money{Currency: currency},
+				Groups:      []NetWorthGroup{},
+			}
+			netWorths[currency] = netWorth
+		}
+
+		var group *NetWorthGroup
+		for i := range netWorth.Groups {
+			if netWorth.Groups[i].AccountGroup == account.AccountGroup {
+				group = &netWorth.Groups[i]
+				break
+			}
+		}
+		if group == nil {
+			netWorth.Groups = append(netWorth.Groups, NetWorthGroup{
+				AccountGroup: account.AccountGroup,
+				Assets:       money.Money{Currency: currency},
+				Liabilities:  money.Money{Currency: currency},
+			})
+			group = &netWorth.Groups[len(netWorth.Groups)-1]
+		}
+
+		balance := balances[account.PrimaryKey]
+		if account.IsLiability() {
+			netWorth.Liabilities = netWorth.Liabilities.Add(balance)
+			group.Liabilities = group.Liabilities.Add(balance)
+		} else {
+			netWorth.Assets = netWorth.Assets.Add(balance)
+			group.Assets = group.Assets.Add(balance)
+		}
+	}
+
+	currencies := make([]string, 0, len(netWorths))
+	for currency := range netWorths {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	sortedNetWorths := make([]NetWorth, 0, len(currencies))
+	for _, currency := range currencies {
+		sortedNetWorths = append(sortedNetWorths, *netWorths[currency])
+	}
+
+	return sortedNetWorths
+}
@@ -0,0 +1,152 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lieut-data/go-moneywell/api"
+	"github.com/lieut-data/go-moneywell/api/money"
+	"github.com/lieut-data/go-moneywell/internal/report"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestGetNetWorth(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	accounts, err := api.GetAccounts(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	// Charge the Visa, a credit card, to exercise the liabilities.
+	transactions = append(transactions, api.Transaction{
+		PrimaryKey: 100,
+		Date:       time.Date(2017, 11, 20, 0, 0, 0, 0, time.UTC),
+		Amount:     money.Money{Currency: "CAD", Amount: -75 * 100},
+		Account:    6,
+		Status:     api.TransactionStatusOpen,
+	})
+
+	cad := func(amount int64) money.Money {
+		return money.Money{Currency: "CAD", Amount: amount * 100}
+	}
+	usd := func(date time.Time) report.NetWorth {
+		return report.NetWorth{
+			Currency:    "USD",
+			Date:        date,
+			Assets:      money.Money{Currency: "USD"},
+			Liabilities: money.Money{Currency: "USD"},
+			Groups: []report.NetWorthGroup{
+				{
+					AccountGroup: 0,
+					Assets:       money.Money{Currency: "USD"},
+					Liabilities:  money.Money{Currency: "USD"},
+				},
+			},
+		}
+	}
+
+	t.Run("before the charge", func(t *testing.T) {
+		date := time.Date(2017, 11, 12, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, []report.NetWorth{
+			{
+				Currency:    "CAD",
+				Date:        date,
+				Assets:      cad(150),
+				Liabilities: cad(0),
+				Groups: []report.NetWorthGroup{
+					{AccountGroup: 0, Assets: cad(400), Liabilities: cad(0)},
+					{AccountGroup: 2, Assets: cad(-250), Liabilities: cad(0)},
+					{AccountGroup: 1, Assets: cad(0), Liabilities: cad(0)},
+				},
+			},
+			usd(date),
+		}, report.GetNetWorth(date, accounts, transactions))
+	})
+
+	t.Run("after the charge", func(t *testing.T) {
+		date := time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC)
+		netWorths := report.GetNetWorth(date, accounts, transactions)
+		assert.Equal(t, []report.NetWorth{
+			{
+				Currency:    "CAD",
+				Date:        date,
+				Assets:      cad(150),
+				Liabilities: cad(-75),
+				Groups: []report.NetWorthGroup{
+					{AccountGroup: 0, Assets: cad(400), Liabilities: cad(0)},
+					{AccountGroup: 2, Assets: cad(-250), Liabilities: cad(0)},
+					{AccountGroup: 1, Assets: cad(0), Liabilities: cad(-75)},
+				},
+			},
+			usd(date),
+		}, netWorths)
+		assert.Equal(t, cad(75), netWorths[0].GetTotal())
+	})
+
+	t.Run("excluding an account", func(t *testing.T) {
+		// The test document includes every account in the net worth, so exclude and hide the
+		// USD account as MoneyWell would.
+		excludedAccounts := make([]api.Account, len(accounts))
+		copy(excludedAccounts, accounts)
+		for i := range excludedAccounts {
+			if excludedAccounts[i].Name == "Cash (USD)" {
+				excludedAccounts[i].IncludeInNetWorth = false
+				excludedAccounts[i].IsHidden = true
+			}
+		}
+
+		date := time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC)
+		netWorths := report.GetNetWorth(date, excludedAccounts, transactions)
+		assert.Len(t, netWorths, 1)
+		assert.Equal(t, "CAD", netWorths[0].Currency)
+	})
+}
+
+func TestGetNetWorthHistory(t *testing.T) {
+	t.Parallel()
+
+	database, err := api.OpenDocument("../../api/Test.moneywell")
+	assert.NoError(t, err)
+	defer database.Close()
+
+	accounts, err := api.GetAccounts(database)
+	assert.NoError(t, err)
+
+	transactions, err := api.GetTransactions(database)
+	assert.NoError(t, err)
+
+	history := report.GetNetWorthHistory(
+		time.Date(2018, 1, 30, 0, 0, 0, 0, time.UTC),
+		accounts,
+		transactions,
+	)
+
+	dates := []time.Time{}
+	totals := []money.Money{}
+	for _, netWorth := range history {
+		dates = append(dates, netWorth.Date)
+		totals = append(totals, netWorth.GetTotal())
+	}
+
+	assert.Equal(t, []time.Time{
+		time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2017, 11, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC),
+	}, dates)
+	assert.Equal(t, []money.Money{
+		{Currency: "CAD", Amount: 150 * 100},
+		{Currency: "USD", Amount: 0},
+		{Currency: "CAD", Amount: 150 * 100},
+		{Currency: "USD", Amount: 0},
+	}, totals)
+}